@@ -6,6 +6,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/omeyang/gokit/metrics"
 )
 
 // DefaultNotifyTimeout 是通知观察者的默认超时时间
@@ -31,6 +33,8 @@ type baseConfigInternal struct {
 	mu            sync.RWMutex
 	stopCh        chan struct{}
 	notifyTimeout time.Duration
+	reloaded      metrics.Counter // 配置源变更后成功重新加载的次数
+	reloadFailed  metrics.Counter // 配置源变更后解析失败的次数
 }
 
 // BaseConfig 是 Config 接口的基础实现
@@ -55,9 +59,16 @@ func WithNotifyTimeout(timeout time.Duration) BaseConfigOption {
 // NewBaseConfig 创建一个新的 BaseConfig 实例
 func NewBaseConfig[T any](ctx context.Context, source Source,
 	parser Parser[T], opts ...BaseConfigOption) (*BaseConfig[T], error) {
+	reloads := metrics.Default().Counter(metrics.Opts{
+		Name:   "gokit_config_reloads_total",
+		Help:   "Number of configuration reloads triggered by source changes.",
+		Labels: []string{"result"},
+	})
 	internal := &baseConfigInternal{
 		stopCh:        make(chan struct{}),
 		notifyTimeout: DefaultNotifyTimeout,
+		reloaded:      reloads.With("success"),
+		reloadFailed:  reloads.With("error"),
 	}
 
 	// 应用可选配置
@@ -173,7 +184,9 @@ func (bc *BaseConfig[T]) Start(ctx context.Context) error {
 				if err == nil {
 					bc.value.Store(config)
 					bc.notifyWatchers(config)
+					bc.internal.reloaded.Inc()
 				} else {
+					bc.internal.reloadFailed.Inc()
 					log.Printf("错误: 解析配置失败: %v", err)
 				}
 			}
@@ -0,0 +1,200 @@
+// xlogcat 读取、过滤并格式化输出 xlog 的 JSON 日志
+//
+// 用法：
+//
+//	xlogcat [flags] [file ...]
+//
+// file 为 roator 的 Filename，会按时间顺序读取其所有备份（包括 .gz）和当前日志文件；
+// 不指定文件或指定 "-" 时从标准输入读取。例如：
+//
+//	xlogcat -level WARN -since 1h -where 'logger=storage.*' /var/log/app/app.log
+//	xlogcat -f -trace 4bf92f3577b34da6a3ce929d0e0e4736 /var/log/app/app.log
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/omeyang/gokit/xlog"
+	"github.com/omeyang/gokit/xlog/roator"
+	"github.com/omeyang/gokit/xlog/xlogcat"
+)
+
+// exprFlags 收集可重复指定的 -where 参数
+type exprFlags []xlogcat.Expr
+
+func (f *exprFlags) String() string {
+	return fmt.Sprint(len(*f))
+}
+
+func (f *exprFlags) Set(s string) error {
+	expr, err := xlogcat.ParseExpr(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, expr)
+	return nil
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run 执行命令并返回退出码
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("xlogcat", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		level   = fs.String("level", "", "minimum level: DEBUG, INFO, WARN, ERROR or FATAL")
+		since   = fs.String("since", "", "only records at or after this time (RFC3339, 2006-01-02[ 15:04:05] or a duration like 15m)")
+		until   = fs.String("until", "", "only records before this time, same formats as -since")
+		traceID = fs.String("trace", "", "only records with this trace_id")
+		follow  = fs.Bool("f", false, "follow the live log file")
+		pattern = fs.String("pattern", "", "roator FilenamePattern used when the files were written, e.g. app-2006-01-02.log")
+		local   = fs.Bool("local", false, "rotated file names use local time (roator LocalTime)")
+		raw     = fs.Bool("json", false, "print matching records as raw JSON lines")
+		color   = fs.String("color", "auto", "colour output: auto, always or never")
+		exprs   exprFlags
+	)
+	fs.Var(&exprs, "where", "field expression, repeatable: key=v, key!=v, key~re, key!~re, key>n, key>=n, key<n, key<=n, key, !key")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	filter, err := buildFilter(*level, *since, *until, *traceID, exprs)
+	if err != nil {
+		fmt.Fprintln(stderr, "xlogcat:", err)
+		return 2
+	}
+	useColor, err := colorEnabled(*color, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, "xlogcat:", err)
+		return 2
+	}
+	printer := xlogcat.NewPrinter(stdout, useColor)
+	handle := printer.Print
+	if *raw {
+		handle = func(e xlogcat.Entry) error {
+			_, err := fmt.Fprintf(stdout, "%s\n", e.Raw)
+			return err
+		}
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	if *follow && (len(files) != 1 || files[0] == "-") {
+		fmt.Fprintln(stderr, "xlogcat: -f needs exactly one file")
+		return 2
+	}
+
+	for _, name := range files {
+		config := roator.RotatorConfig{Filename: name, FilenamePattern: *pattern, LocalTime: *local}
+		if err := catFile(ctx, config, *follow, stdin, filter, handle); err != nil {
+			fmt.Fprintln(stderr, "xlogcat:", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// catFile 输出一个日志文件序列，follow 为 true 时读完后继续跟踪当前日志文件
+func catFile(ctx context.Context, config roator.RotatorConfig, follow bool, stdin io.Reader, filter xlogcat.Filter, handle xlogcat.HandlerFunc) error {
+	if config.Filename == "-" {
+		return xlogcat.Scan(stdin, "-", filter, handle)
+	}
+	files, err := xlogcat.Files(config)
+	if err != nil && !(follow && errors.Is(err, os.ErrNotExist)) {
+		return err
+	}
+	if !follow {
+		return xlogcat.ReadFiles(files, filter, handle)
+	}
+
+	// 当前日志文件由 Follow 从头读取，这里只读取之前的备份
+	live := config.Filename
+	if config.FilenamePattern != "" {
+		live = filepath.Join(filepath.Dir(config.Filename), roator.DefaultSymlinkName)
+		if _, err := os.Stat(live); err != nil && len(files) > 0 {
+			live = files[len(files)-1]
+		}
+	}
+	if n := len(files); n > 0 && sameFile(files[n-1], live) {
+		files = files[:n-1]
+	}
+	if err := xlogcat.ReadFiles(files, filter, handle); err != nil {
+		return err
+	}
+	return xlogcat.Follow(ctx, live, filter, handle)
+}
+
+// sameFile 判断两个路径是否指向同一个文件
+func sameFile(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	return err == nil && os.SameFile(ia, ib)
+}
+
+// buildFilter 根据命令行参数构造过滤条件
+func buildFilter(level, since, until, traceID string, exprs []xlogcat.Expr) (xlogcat.Filter, error) {
+	filter := xlogcat.Filter{TraceID: traceID, Exprs: exprs}
+	now := time.Now()
+	if level != "" {
+		l, err := xlog.ParseLevel(level)
+		if err != nil {
+			return filter, err
+		}
+		filter.Level = l
+	}
+	if since != "" {
+		t, err := xlogcat.ParseTime(since, now)
+		if err != nil {
+			return filter, fmt.Errorf("invalid -since: %w", err)
+		}
+		filter.Since = t
+	}
+	if until != "" {
+		t, err := xlogcat.ParseTime(until, now)
+		if err != nil {
+			return filter, fmt.Errorf("invalid -until: %w", err)
+		}
+		filter.Until = t
+	}
+	return filter, nil
+}
+
+// colorEnabled 根据 -color 参数判断是否使用颜色，auto 模式下仅在输出到终端且未设置 NO_COLOR 时使用
+func colorEnabled(mode string, w io.Writer) (bool, error) {
+	switch strings.ToLower(mode) {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		f, ok := w.(*os.File)
+		if !ok {
+			return false, nil
+		}
+		info, err := f.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, fmt.Errorf("invalid -color %q, want auto, always or never", mode)
+	}
+}
@@ -0,0 +1,165 @@
+// Package metrics 提供轻量的指标接口与实现，包括 Counter、Gauge、Histogram、Summary 以及标签，
+// Registry 实现了 Provider 并可以通过 Handler 以 Prometheus 文本格式暴露指标。
+// gokit 的各个模块在创建时从 Default 获取 Provider 记录指标，
+// 需要通过 OpenTelemetry 导出时，在创建这些模块之前调用 SetDefault(NewOTelProvider(...))
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Counter 是只增不减的计数器
+type Counter interface {
+	// Inc 加一
+	Inc()
+	// Add 增加 delta，delta 不能为负数
+	Add(delta float64)
+	// With 返回指定标签值对应的计数器，标签值的数量与顺序必须与定义时的标签名一致
+	With(labelValues ...string) Counter
+}
+
+// Gauge 是可增可减的瞬时值
+type Gauge interface {
+	// Set 设置为 value
+	Set(value float64)
+	// Inc 加一
+	Inc()
+	// Dec 减一
+	Dec()
+	// Add 增加 delta，delta 可以为负数
+	Add(delta float64)
+	// With 返回指定标签值对应的瞬时值
+	With(labelValues ...string) Gauge
+}
+
+// Histogram 按预先定义的桶统计观测值的分布
+type Histogram interface {
+	// Observe 记录一个观测值
+	Observe(value float64)
+	// With 返回指定标签值对应的直方图
+	With(labelValues ...string) Histogram
+}
+
+// Summary 统计观测值在滑动时间窗口内的分位数
+type Summary interface {
+	// Observe 记录一个观测值
+	Observe(value float64)
+	// With 返回指定标签值对应的摘要
+	With(labelValues ...string) Summary
+}
+
+// Provider 创建指标
+// 同名指标重复创建时返回同一个指标，名称相同但类型或标签不同时 panic
+type Provider interface {
+	Counter(opts Opts) Counter
+	Gauge(opts Opts) Gauge
+	Histogram(opts HistogramOpts) Histogram
+	Summary(opts SummaryOpts) Summary
+}
+
+// Opts 定义指标的名称、说明与标签名
+type Opts struct {
+	Name   string   // 指标名称，例如 "gokit_xlog_dropped_total"
+	Help   string   // 指标说明
+	Labels []string // 标签名
+}
+
+// HistogramOpts 定义直方图
+type HistogramOpts struct {
+	Opts
+	// 各个桶的上界，按升序排列，为空时使用 DefaultBuckets
+	Buckets []float64
+}
+
+// SummaryOpts 定义摘要
+type SummaryOpts struct {
+	Opts
+	// 需要计算的分位数，为空时使用 DefaultQuantiles
+	Quantiles []float64
+	// 计算分位数的滑动时间窗口，不大于 0 时使用 DefaultMaxAge
+	MaxAge time.Duration
+	// 每个时间窗口内保留的样本数，不大于 0 时使用 DefaultSampleSize
+	SampleSize int
+}
+
+// 指标的默认配置
+var (
+	// DefaultBuckets 默认的直方图桶，适用于以秒为单位的请求耗时
+	DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultQuantiles 默认的摘要分位数
+	DefaultQuantiles = []float64{0.5, 0.9, 0.99}
+)
+
+const (
+	// DefaultMaxAge 摘要默认的滑动时间窗口
+	DefaultMaxAge = 10 * time.Minute
+	// DefaultSampleSize 摘要在每个时间窗口内默认保留的样本数
+	DefaultSampleSize = 1024
+)
+
+// providerHolder 包装 Provider，使 atomic.Value 中始终存储同一具体类型
+type providerHolder struct {
+	provider Provider
+}
+
+var (
+	defaultRegistry = NewRegistry()
+	defaultProvider atomic.Value
+)
+
+func init() {
+	defaultProvider.Store(providerHolder{defaultRegistry})
+}
+
+// DefaultRegistry 返回默认的 Registry，未调用 SetDefault 时 gokit 的模块将指标记录在这里
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Default 返回当前默认的 Provider
+func Default() Provider {
+	return defaultProvider.Load().(providerHolder).provider
+}
+
+// SetDefault 设置默认的 Provider，只影响之后创建的模块；provider 为 nil 时恢复为 DefaultRegistry
+func SetDefault(provider Provider) {
+	if provider == nil {
+		provider = defaultRegistry
+	}
+	defaultProvider.Store(providerHolder{provider})
+}
+
+// Nop 是不记录任何指标的 Provider，用于关闭指标
+var Nop Provider = nopProvider{}
+
+type nopProvider struct{}
+
+func (nopProvider) Counter(Opts) Counter              { return nopCounter{} }
+func (nopProvider) Gauge(Opts) Gauge                  { return nopGauge{} }
+func (nopProvider) Histogram(HistogramOpts) Histogram { return nopHistogram{} }
+func (nopProvider) Summary(SummaryOpts) Summary       { return nopSummary{} }
+
+type nopCounter struct{}
+
+func (nopCounter) Inc()                     {}
+func (nopCounter) Add(float64)              {}
+func (c nopCounter) With(...string) Counter { return c }
+
+type nopGauge struct{}
+
+func (nopGauge) Set(float64)            {}
+func (nopGauge) Inc()                   {}
+func (nopGauge) Dec()                   {}
+func (nopGauge) Add(float64)            {}
+func (g nopGauge) With(...string) Gauge { return g }
+
+type nopHistogram struct{}
+
+func (nopHistogram) Observe(float64)            {}
+func (h nopHistogram) With(...string) Histogram { return h }
+
+type nopSummary struct{}
+
+func (nopSummary) Observe(float64)          {}
+func (s nopSummary) With(...string) Summary { return s }
@@ -0,0 +1,294 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// otelScopeName 是指标使用的 instrumentation scope 名称
+const otelScopeName = "github.com/omeyang/gokit/metrics"
+
+// OTelProvider 将指标映射到 OpenTelemetry 指标 API，实现了 Provider
+// 通过 SetDefault 设置后，gokit 各模块的指标经由 MeterProvider 导出（例如 OTLP），无需修改埋点代码：
+//   - Counter 对应 Float64Counter
+//   - Gauge 对应 Float64ObservableGauge，取值保存在本地，采集时上报
+//   - Histogram 对应使用相同桶上界的 Float64Histogram
+//   - Summary 对应 Float64Histogram，OpenTelemetry 没有分位数摘要，Quantiles、MaxAge 与 SampleSize 被忽略，
+//     分位数由后端根据直方图计算，可以通过 SDK 的 View 改用指数直方图提高精度
+//
+// 标签映射为字符串类型的属性
+type OTelProvider struct {
+	meter metric.Meter
+
+	mu          sync.Mutex
+	instruments map[string]otelInstrument
+}
+
+// otelInstrument 记录已创建的指标，用于同名指标的去重与冲突检查
+type otelInstrument struct {
+	kind    metricKind
+	labels  []string
+	buckets []float64 // 直方图的桶上界
+	metric  any
+}
+
+// NewOTelProvider 使用指定的 MeterProvider 创建 OTelProvider，provider 为 nil 时使用 otel.GetMeterProvider()
+func NewOTelProvider(provider metric.MeterProvider) *OTelProvider {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	return &OTelProvider{
+		meter:       provider.Meter(otelScopeName),
+		instruments: make(map[string]otelInstrument),
+	}
+}
+
+// Counter 创建或获取计数器
+func (p *OTelProvider) Counter(opts Opts) Counter {
+	return p.instrument(opts, kindCounter, nil, func() any {
+		inst, err := p.meter.Float64Counter(opts.Name, metric.WithDescription(opts.Help))
+		handleOTelError(err)
+		c := otelCounter{f: newOTelFamily(opts), inst: inst}
+		if len(opts.Labels) == 0 {
+			return c.With()
+		}
+		return c
+	}).(Counter)
+}
+
+// Gauge 创建或获取瞬时值
+func (p *OTelProvider) Gauge(opts Opts) Gauge {
+	return p.instrument(opts, kindGauge, nil, func() any {
+		f := &otelGaugeFamily{otelFamily: newOTelFamily(opts), series: make(map[string]*otelGaugeSeries)}
+		_, err := p.meter.Float64ObservableGauge(opts.Name, metric.WithDescription(opts.Help),
+			metric.WithFloat64Callback(f.observe))
+		handleOTelError(err)
+		g := otelGauge{f: f}
+		if len(opts.Labels) == 0 {
+			return g.With()
+		}
+		return g
+	}).(Gauge)
+}
+
+// Histogram 创建或获取直方图
+func (p *OTelProvider) Histogram(opts HistogramOpts) Histogram {
+	buckets := histogramBuckets(opts)
+	return p.instrument(opts.Opts, kindHistogram, buckets, func() any {
+		inst, err := p.meter.Float64Histogram(opts.Name, metric.WithDescription(opts.Help),
+			metric.WithExplicitBucketBoundaries(buckets...))
+		handleOTelError(err)
+		h := otelHistogram{f: newOTelFamily(opts.Opts), inst: inst}
+		if len(opts.Labels) == 0 {
+			return h.With()
+		}
+		return h
+	}).(Histogram)
+}
+
+// Summary 创建或获取摘要，以使用 SDK 默认聚合方式的 Float64Histogram 记录
+func (p *OTelProvider) Summary(opts SummaryOpts) Summary {
+	return p.instrument(opts.Opts, kindSummary, nil, func() any {
+		inst, err := p.meter.Float64Histogram(opts.Name, metric.WithDescription(opts.Help))
+		handleOTelError(err)
+		s := otelSummary{otelHistogram{f: newOTelFamily(opts.Opts), inst: inst}}
+		if len(opts.Labels) == 0 {
+			return s.With()
+		}
+		return s
+	}).(Summary)
+}
+
+// instrument 创建指标，同名同类型同标签同桶上界的指标直接返回已有的指标
+func (p *OTelProvider) instrument(opts Opts, kind metricKind, buckets []float64, create func() any) any {
+	validateOpts(opts, kind)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	inst, ok := p.instruments[opts.Name]
+	if !ok {
+		inst = otelInstrument{kind: kind, labels: slices.Clone(opts.Labels), buckets: buckets, metric: create()}
+		p.instruments[opts.Name] = inst
+	}
+	if inst.kind != kind || !slices.Equal(inst.labels, opts.Labels) {
+		panic(fmt.Sprintf("metrics: %s already registered as %s with labels %v", opts.Name, inst.kind, inst.labels))
+	}
+	if !slices.Equal(inst.buckets, buckets) {
+		panic(fmt.Sprintf("metrics: %s already registered with buckets %v", opts.Name, inst.buckets))
+	}
+	return inst.metric
+}
+
+// handleOTelError 将创建指标时的错误交给 OpenTelemetry 的全局错误处理器，
+// 与 SDK 的约定一致，出错时返回的指标仍然可以使用
+func handleOTelError(err error) {
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+// otelFamily 保存指标名称与标签名
+type otelFamily struct {
+	name   string
+	labels []string
+}
+
+func newOTelFamily(opts Opts) *otelFamily {
+	return &otelFamily{name: opts.Name, labels: slices.Clone(opts.Labels)}
+}
+
+// attributes 将标签值转换为属性集合
+func (f *otelFamily) attributes(labelValues []string) attribute.Set {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values %v, got %d", f.name, len(f.labels), f.labels, len(labelValues)))
+	}
+	kvs := make([]attribute.KeyValue, len(labelValues))
+	for i, v := range labelValues {
+		kvs[i] = attribute.String(f.labels[i], v)
+	}
+	return attribute.NewSet(kvs...)
+}
+
+// mustBound 有标签的指标未调用 With 时 panic
+func (f *otelFamily) mustBound(bound bool) {
+	if !bound {
+		panic(fmt.Sprintf("metrics: %s has labels %v, call With first", f.name, f.labels))
+	}
+}
+
+// otelCounter 实现 Counter
+type otelCounter struct {
+	f     *otelFamily
+	inst  metric.Float64Counter
+	opts  []metric.AddOption // 绑定的属性，预先构造以避免每次记录时分配
+	bound bool
+}
+
+func (c otelCounter) Inc() {
+	c.Add(1)
+}
+
+func (c otelCounter) Add(delta float64) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.f.name))
+	}
+	c.f.mustBound(c.bound)
+	c.inst.Add(context.Background(), delta, c.opts...)
+}
+
+func (c otelCounter) With(labelValues ...string) Counter {
+	set := c.f.attributes(labelValues)
+	return otelCounter{f: c.f, inst: c.inst, opts: []metric.AddOption{metric.WithAttributeSet(set)}, bound: true}
+}
+
+// otelGaugeFamily 在本地保存瞬时值，由 Float64ObservableGauge 的回调在采集时上报
+type otelGaugeFamily struct {
+	*otelFamily
+
+	mu     sync.RWMutex
+	series map[string]*otelGaugeSeries
+}
+
+// otelGaugeSeries 是一组标签值对应的瞬时值
+type otelGaugeSeries struct {
+	opt   metric.ObserveOption
+	value atomicFloat
+}
+
+// with 返回标签值对应的序列，不存在时创建
+func (f *otelGaugeFamily) with(labelValues []string) *otelGaugeSeries {
+	key := strings.Join(labelValues, labelSep)
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+	set := f.attributes(labelValues)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok = f.series[key]; !ok {
+		s = &otelGaugeSeries{opt: metric.WithAttributeSet(set)}
+		f.series[key] = s
+	}
+	return s
+}
+
+// observe 上报所有序列的当前值
+func (f *otelGaugeFamily) observe(_ context.Context, o metric.Float64Observer) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, s := range f.series {
+		o.Observe(s.value.load(), s.opt)
+	}
+	return nil
+}
+
+// otelGauge 实现 Gauge
+type otelGauge struct {
+	f *otelGaugeFamily
+	s *otelGaugeSeries
+}
+
+func (g otelGauge) Set(value float64) {
+	g.f.mustBound(g.s != nil)
+	g.s.value.store(value)
+}
+
+func (g otelGauge) Inc() {
+	g.Add(1)
+}
+
+func (g otelGauge) Dec() {
+	g.Add(-1)
+}
+
+func (g otelGauge) Add(delta float64) {
+	g.f.mustBound(g.s != nil)
+	g.s.value.add(delta)
+}
+
+func (g otelGauge) With(labelValues ...string) Gauge {
+	return otelGauge{f: g.f, s: g.f.with(labelValues)}
+}
+
+// otelHistogram 实现 Histogram
+type otelHistogram struct {
+	f     *otelFamily
+	inst  metric.Float64Histogram
+	opts  []metric.RecordOption
+	bound bool
+}
+
+func (h otelHistogram) Observe(value float64) {
+	h.f.mustBound(h.bound)
+	h.inst.Record(context.Background(), value, h.opts...)
+}
+
+func (h otelHistogram) With(labelValues ...string) Histogram {
+	return h.with(labelValues)
+}
+
+func (h otelHistogram) with(labelValues []string) otelHistogram {
+	set := h.f.attributes(labelValues)
+	return otelHistogram{f: h.f, inst: h.inst, opts: []metric.RecordOption{metric.WithAttributeSet(set)}, bound: true}
+}
+
+// otelSummary 实现 Summary
+type otelSummary struct {
+	h otelHistogram
+}
+
+func (s otelSummary) Observe(value float64) {
+	s.h.Observe(value)
+}
+
+func (s otelSummary) With(labelValues ...string) Summary {
+	return otelSummary{s.h.with(labelValues)}
+}
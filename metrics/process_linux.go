@@ -0,0 +1,147 @@
+//go:build linux
+
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processSupported 表示当前平台可以采集进程指标
+const processSupported = true
+
+// clockTicks 是 /proc 中 CPU 时间的单位（USER_HZ），Linux 在所有主流架构上固定为 100
+const clockTicks = 100
+
+// processCollector 从 /proc/self 采集进程指标
+type processCollector struct {
+	cpu       Counter
+	rss       Gauge
+	vsize     Gauge
+	openFDs   Gauge
+	maxFDs    Gauge
+	startTime Gauge
+
+	lastCPU  float64
+	bootTime float64 // 系统启动时间（Unix 秒），为 0 表示尚未读取
+}
+
+func newProcessCollector(provider Provider) *processCollector {
+	return &processCollector{
+		cpu:       provider.Counter(Opts{Name: "process_cpu_seconds_total", Help: "Total user and system CPU time spent in seconds."}),
+		rss:       provider.Gauge(Opts{Name: "process_resident_memory_bytes", Help: "Resident memory size in bytes."}),
+		vsize:     provider.Gauge(Opts{Name: "process_virtual_memory_bytes", Help: "Virtual memory size in bytes."}),
+		openFDs:   provider.Gauge(Opts{Name: "process_open_fds", Help: "Number of open file descriptors."}),
+		maxFDs:    provider.Gauge(Opts{Name: "process_max_fds", Help: "Maximum number of open file descriptors."}),
+		startTime: provider.Gauge(Opts{Name: "process_start_time_seconds", Help: "Start time of the process since unix epoch in seconds."}),
+	}
+}
+
+// collect 读取 /proc/self 并更新指标，单项读取失败不影响其他指标，错误合并后返回
+func (p *processCollector) collect() error {
+	var errs []error
+	if err := p.collectStat(); err != nil {
+		errs = append(errs, err)
+	}
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		p.openFDs.Set(float64(len(fds)))
+	} else {
+		errs = append(errs, err)
+	}
+	if limit, err := maxOpenFiles(); err == nil {
+		p.maxFDs.Set(limit)
+	} else {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// collectStat 解析 /proc/self/stat，字段含义见 proc(5)
+func (p *processCollector) collectStat() error {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return err
+	}
+	// 第二个字段是括号中的进程名，可能包含空格，从最后一个右括号之后开始解析
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return fmt.Errorf("metrics: malformed /proc/self/stat")
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	// fields[0] 是第 3 个字段 state
+	const (
+		utime     = 14 - 3
+		stime     = 15 - 3
+		starttime = 22 - 3
+		vsize     = 23 - 3
+		rss       = 24 - 3
+	)
+	if len(fields) <= rss {
+		return fmt.Errorf("metrics: malformed /proc/self/stat")
+	}
+	parse := func(i int) float64 {
+		v, _ := strconv.ParseFloat(fields[i], 64)
+		return v
+	}
+
+	cpu := (parse(utime) + parse(stime)) / clockTicks
+	if cpu > p.lastCPU {
+		p.cpu.Add(cpu - p.lastCPU)
+		p.lastCPU = cpu
+	}
+	p.vsize.Set(parse(vsize))
+	p.rss.Set(parse(rss) * float64(os.Getpagesize()))
+
+	if p.bootTime == 0 {
+		if p.bootTime, err = bootTime(); err != nil {
+			return err
+		}
+	}
+	p.startTime.Set(p.bootTime + parse(starttime)/clockTicks)
+	return nil
+}
+
+// bootTime 从 /proc/stat 读取系统启动时间
+func bootTime() (float64, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			return strconv.ParseFloat(strings.TrimSpace(v), 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("metrics: btime not found in /proc/stat")
+}
+
+// maxOpenFiles 从 /proc/self/limits 读取打开文件数的软限制
+func maxOpenFiles() (float64, error) {
+	data, err := os.ReadFile("/proc/self/limits")
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "Max open files"); ok {
+			fields := strings.Fields(v)
+			if len(fields) == 0 {
+				break
+			}
+			if fields[0] == "unlimited" {
+				return 0, nil
+			}
+			return strconv.ParseFloat(fields[0], 64)
+		}
+	}
+	return 0, fmt.Errorf("metrics: max open files not found in /proc/self/limits")
+}
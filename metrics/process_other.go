@@ -0,0 +1,17 @@
+//go:build !linux
+
+package metrics
+
+// processSupported 表示当前平台可以采集进程指标，非 Linux 平台没有 /proc，只采集运行时指标
+const processSupported = false
+
+// processCollector 在非 Linux 平台上不会被创建
+type processCollector struct{}
+
+func newProcessCollector(Provider) *processCollector {
+	return nil
+}
+
+func (*processCollector) collect() error {
+	return nil
+}
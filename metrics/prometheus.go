@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType 是 Prometheus 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler 返回以 Prometheus 文本格式输出所有指标的 http.Handler
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		if err := r.WriteText(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Handler 返回输出 DefaultRegistry 中所有指标的 http.Handler
+func Handler() http.Handler {
+	return defaultRegistry.Handler()
+}
+
+// WriteText 以 Prometheus 文本格式输出所有指标，指标按名称排序，序列按标签值排序
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.RUnlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.writeText(bw)
+	}
+	return bw.Flush()
+}
+
+// writeText 输出一个指标的所有序列
+func (f *family) writeText(w *bufio.Writer) {
+	all := f.snapshot()
+	if len(all) == 0 {
+		return
+	}
+	if f.help != "" {
+		w.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+	}
+	w.WriteString("# TYPE " + f.name + " " + string(f.kind) + "\n")
+	now := f.now()
+	for _, s := range all {
+		switch f.kind {
+		case kindCounter, kindGauge:
+			writeSample(w, f.name, f.labels, s.labelValues, "", "", s.value.load())
+		case kindHistogram:
+			// 观测次数取各桶之和，保证与 +Inf 桶一致，不受并发 Observe 影响
+			var count uint64
+			for i, bound := range f.buckets {
+				count += s.counts[i].Load()
+				writeSample(w, f.name+"_bucket", f.labels, s.labelValues, "le", formatFloat(bound), float64(count))
+			}
+			count += s.counts[len(f.buckets)].Load()
+			writeSample(w, f.name+"_bucket", f.labels, s.labelValues, "le", "+Inf", float64(count))
+			writeSample(w, f.name+"_sum", f.labels, s.labelValues, "", "", s.sum.load())
+			writeSample(w, f.name+"_count", f.labels, s.labelValues, "", "", float64(count))
+		case kindSummary:
+			values := s.window.quantiles(f.quantiles, now)
+			for i, q := range f.quantiles {
+				writeSample(w, f.name, f.labels, s.labelValues, "quantile", formatFloat(q), values[i])
+			}
+			writeSample(w, f.name+"_sum", f.labels, s.labelValues, "", "", s.sum.load())
+			writeSample(w, f.name+"_count", f.labels, s.labelValues, "", "", float64(s.count.Load()))
+		}
+	}
+}
+
+// snapshot 返回按标签值排序的序列
+func (f *family) snapshot() []*series {
+	f.mu.RLock()
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	f.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i].labelValues, all[j].labelValues
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+	return all
+}
+
+// writeSample 输出一行样本，extraName 不为空时追加 le 或 quantile 标签
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l + `="` + escapeLabel(values[i]) + `"`)
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraName + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+// formatFloat 按 Prometheus 文本格式输出浮点数
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
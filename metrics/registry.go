@@ -0,0 +1,414 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omeyang/gokit/metrics/sample"
+)
+
+// metricKind 表示指标类型，取值与 Prometheus 文本格式中的 TYPE 一致
+type metricKind string
+
+const (
+	kindCounter   metricKind = "counter"
+	kindGauge     metricKind = "gauge"
+	kindHistogram metricKind = "histogram"
+	kindSummary   metricKind = "summary"
+)
+
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// labelSep 拼接标签值作为序列的键，标签值中不会出现该字符
+const labelSep = "\xff"
+
+// Registry 在内存中保存指标，实现了 Provider，并可以通过 Handler 以 Prometheus 文本格式输出
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+	now      func() time.Time
+}
+
+// RegistryOption 定义 Registry 的可选配置
+type RegistryOption func(*Registry)
+
+// WithClock 设置摘要滑动窗口使用的时钟，默认使用 time.Now
+func WithClock(now func() time.Time) RegistryOption {
+	return func(r *Registry) {
+		if now != nil {
+			r.now = now
+		}
+	}
+}
+
+// NewRegistry 创建一个空的 Registry
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{families: make(map[string]*family), now: time.Now}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// family 是同名指标的所有序列
+type family struct {
+	name      string
+	help      string
+	kind      metricKind
+	labels    []string
+	buckets   []float64
+	quantiles []float64
+	maxAge    time.Duration
+	size      int
+	now       func() time.Time
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+// series 是一组标签值对应的指标值
+type series struct {
+	labelValues []string
+	value       atomicFloat     // 计数器与瞬时值
+	counts      []atomic.Uint64 // 直方图各个桶的计数（非累计），最后一个为 +Inf 桶
+	count       atomic.Uint64   // 摘要的观测次数，直方图的观测次数由各桶计数求和得到
+	sum         atomicFloat     // 直方图与摘要的观测值之和
+	window      *summaryWindow  // 摘要的滑动窗口
+}
+
+// Counter 创建或获取计数器
+func (r *Registry) Counter(opts Opts) Counter {
+	f := r.register(opts, kindCounter, func(f *family) {})
+	return counter{f: f, s: f.defaultSeries()}
+}
+
+// Gauge 创建或获取瞬时值
+func (r *Registry) Gauge(opts Opts) Gauge {
+	f := r.register(opts, kindGauge, func(f *family) {})
+	return gauge{f: f, s: f.defaultSeries()}
+}
+
+// Histogram 创建或获取直方图
+func (r *Registry) Histogram(opts HistogramOpts) Histogram {
+	buckets := histogramBuckets(opts)
+	f := r.register(opts.Opts, kindHistogram, func(f *family) {
+		f.buckets = buckets
+	})
+	return histogram{f: f, s: f.defaultSeries()}
+}
+
+// histogramBuckets 返回去重后的桶上界，不包含 +Inf；未设置时使用 DefaultBuckets，未排序时 panic
+func histogramBuckets(opts HistogramOpts) []float64 {
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: histogram %s buckets must be sorted", opts.Name))
+	}
+	buckets = slices.Compact(slices.Clone(buckets))
+	if math.IsInf(buckets[len(buckets)-1], 1) {
+		buckets = buckets[:len(buckets)-1]
+	}
+	return buckets
+}
+
+// Summary 创建或获取摘要
+func (r *Registry) Summary(opts SummaryOpts) Summary {
+	quantiles := opts.Quantiles
+	if len(quantiles) == 0 {
+		quantiles = DefaultQuantiles
+	}
+	for _, q := range quantiles {
+		if q < 0 || q > 1 {
+			panic(fmt.Sprintf("metrics: summary %s quantile %v out of range [0, 1]", opts.Name, q))
+		}
+	}
+	f := r.register(opts.Opts, kindSummary, func(f *family) {
+		f.quantiles = slices.Clone(quantiles)
+		sort.Float64s(f.quantiles)
+		f.maxAge = opts.MaxAge
+		if f.maxAge <= 0 {
+			f.maxAge = DefaultMaxAge
+		}
+		f.size = opts.SampleSize
+		if f.size <= 0 {
+			f.size = DefaultSampleSize
+		}
+	})
+	return summary{f: f, s: f.defaultSeries()}
+}
+
+// register 注册指标，同名同类型同标签同配置的指标直接返回已有的指标，配置不一致时 panic
+func (r *Registry) register(opts Opts, kind metricKind, init func(*family)) *family {
+	validateOpts(opts, kind)
+
+	r.mu.RLock()
+	f, ok := r.families[opts.Name]
+	r.mu.RUnlock()
+	created := false
+	if !ok {
+		r.mu.Lock()
+		if f, ok = r.families[opts.Name]; !ok {
+			f = &family{
+				name:   opts.Name,
+				help:   opts.Help,
+				kind:   kind,
+				labels: slices.Clone(opts.Labels),
+				now:    r.now,
+				series: make(map[string]*series),
+			}
+			init(f)
+			r.families[opts.Name] = f
+			created = true
+		}
+		r.mu.Unlock()
+	}
+	if f.kind != kind || !slices.Equal(f.labels, opts.Labels) {
+		panic(fmt.Sprintf("metrics: %s already registered as %s with labels %v", opts.Name, f.kind, f.labels))
+	}
+	if !created {
+		want := &family{}
+		init(want)
+		if !sameShape(f, want) {
+			panic(fmt.Sprintf("metrics: %s already registered with buckets %v, quantiles %v, max age %v, sample size %d",
+				opts.Name, f.buckets, f.quantiles, f.maxAge, f.size))
+		}
+	}
+	return f
+}
+
+// sameShape 判断两个指标的桶、分位数与窗口配置是否一致
+func sameShape(a, b *family) bool {
+	return slices.Equal(a.buckets, b.buckets) && slices.Equal(a.quantiles, b.quantiles) &&
+		a.maxAge == b.maxAge && a.size == b.size
+}
+
+// validateOpts 校验指标名称与标签名，不合法时 panic
+func validateOpts(opts Opts, kind metricKind) {
+	if !metricNameRE.MatchString(opts.Name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", opts.Name))
+	}
+	for _, l := range opts.Labels {
+		if !labelNameRE.MatchString(l) || strings.HasPrefix(l, "__") ||
+			(kind == kindHistogram && l == "le") || (kind == kindSummary && l == "quantile") {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", l, opts.Name))
+		}
+	}
+}
+
+// defaultSeries 返回没有标签的指标的唯一序列，有标签的指标返回 nil，需要通过 With 指定标签值
+func (f *family) defaultSeries() *series {
+	if len(f.labels) > 0 {
+		return nil
+	}
+	return f.with(nil)
+}
+
+// with 返回标签值对应的序列，不存在时创建
+func (f *family) with(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values %v, got %d", f.name, len(f.labels), f.labels, len(labelValues)))
+	}
+	key := strings.Join(labelValues, labelSep)
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok = f.series[key]; ok {
+		return s
+	}
+	s = &series{labelValues: slices.Clone(labelValues)}
+	switch f.kind {
+	case kindHistogram:
+		s.counts = make([]atomic.Uint64, len(f.buckets)+1)
+	case kindSummary:
+		s.window = newSummaryWindow(f.size, f.maxAge, f.now())
+	}
+	f.series[key] = s
+	return s
+}
+
+// mustSeries 返回绑定的序列，有标签的指标未调用 With 时 panic
+func (f *family) mustSeries(s *series) *series {
+	if s == nil {
+		panic(fmt.Sprintf("metrics: %s has labels %v, call With first", f.name, f.labels))
+	}
+	return s
+}
+
+// counter 实现 Counter
+type counter struct {
+	f *family
+	s *series
+}
+
+func (c counter) Inc() {
+	c.Add(1)
+}
+
+func (c counter) Add(delta float64) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.f.name))
+	}
+	c.f.mustSeries(c.s).value.add(delta)
+}
+
+func (c counter) With(labelValues ...string) Counter {
+	return counter{f: c.f, s: c.f.with(labelValues)}
+}
+
+// gauge 实现 Gauge
+type gauge struct {
+	f *family
+	s *series
+}
+
+func (g gauge) Set(value float64) {
+	g.f.mustSeries(g.s).value.store(value)
+}
+
+func (g gauge) Inc() {
+	g.Add(1)
+}
+
+func (g gauge) Dec() {
+	g.Add(-1)
+}
+
+func (g gauge) Add(delta float64) {
+	g.f.mustSeries(g.s).value.add(delta)
+}
+
+func (g gauge) With(labelValues ...string) Gauge {
+	return gauge{f: g.f, s: g.f.with(labelValues)}
+}
+
+// histogram 实现 Histogram
+type histogram struct {
+	f *family
+	s *series
+}
+
+func (h histogram) Observe(value float64) {
+	s := h.f.mustSeries(h.s)
+	s.sum.add(value)
+	s.counts[sort.SearchFloat64s(h.f.buckets, value)].Add(1)
+}
+
+func (h histogram) With(labelValues ...string) Histogram {
+	return histogram{f: h.f, s: h.f.with(labelValues)}
+}
+
+// summary 实现 Summary
+type summary struct {
+	f *family
+	s *series
+}
+
+func (m summary) Observe(value float64) {
+	s := m.f.mustSeries(m.s)
+	s.window.observe(value, m.f.now())
+	s.sum.add(value)
+	s.count.Add(1)
+}
+
+func (m summary) With(labelValues ...string) Summary {
+	return summary{f: m.f, s: m.f.with(labelValues)}
+}
+
+// summaryWindow 使用两个交替的蓄水池近似滑动时间窗口：
+// 每半个窗口轮换一次，分位数由当前与上一个蓄水池的样本共同计算
+type summaryWindow struct {
+	mu        sync.Mutex
+	half      time.Duration
+	rotatedAt time.Time
+	current   *sample.Reservoir[float64]
+	previous  *sample.Reservoir[float64]
+}
+
+func newSummaryWindow(size int, maxAge time.Duration, now time.Time) *summaryWindow {
+	return &summaryWindow{
+		half:      maxAge / 2,
+		rotatedAt: now,
+		current:   sample.NewReservoir[float64](size),
+		previous:  sample.NewReservoir[float64](size),
+	}
+}
+
+// rotate 按时间轮换蓄水池，调用方需持有锁
+func (w *summaryWindow) rotate(now time.Time) {
+	elapsed := now.Sub(w.rotatedAt)
+	if elapsed < w.half {
+		return
+	}
+	if elapsed >= 2*w.half {
+		w.previous.Reset()
+	} else {
+		w.previous, w.current = w.current, w.previous
+	}
+	w.current.Reset()
+	w.rotatedAt = now
+}
+
+func (w *summaryWindow) observe(value float64, now time.Time) {
+	w.mu.Lock()
+	w.rotate(now)
+	w.current.Offer(value)
+	w.mu.Unlock()
+}
+
+// quantiles 计算窗口内样本的分位数，没有样本时返回 NaN
+func (w *summaryWindow) quantiles(qs []float64, now time.Time) []float64 {
+	w.mu.Lock()
+	w.rotate(now)
+	values := append(w.previous.Snapshot(), w.current.Snapshot()...)
+	w.mu.Unlock()
+
+	sort.Float64s(values)
+	out := make([]float64, len(qs))
+	for i, q := range qs {
+		if len(values) == 0 {
+			out[i] = math.NaN()
+			continue
+		}
+		out[i] = values[int(math.Round(q*float64(len(values)-1)))]
+	}
+	return out
+}
+
+// atomicFloat 是支持原子加法的 float64
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
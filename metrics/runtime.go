@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	rtmetrics "runtime/metrics"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// runtimeValue 描述一个从 runtime/metrics 读取的标量指标
+type runtimeValue struct {
+	sample  string // runtime/metrics 中的名称
+	name    string
+	help    string
+	counter bool // 累计值，以计数器记录两次采集之间的增量
+}
+
+var runtimeValues = []runtimeValue{
+	{"/sched/goroutines:goroutines", "go_goroutines", "Number of live goroutines.", false},
+	{"/sched/gomaxprocs:threads", "go_gomaxprocs", "Current GOMAXPROCS setting.", false},
+	{"/memory/classes/heap/objects:bytes", "go_heap_objects_bytes", "Memory occupied by live and not yet swept heap objects.", false},
+	{"/gc/heap/objects:objects", "go_heap_objects", "Number of live and not yet swept heap objects.", false},
+	{"/gc/heap/goal:bytes", "go_gc_heap_goal_bytes", "Heap size target for the end of the GC cycle.", false},
+	{"/memory/classes/total:bytes", "go_memory_total_bytes", "All memory mapped by the Go runtime.", false},
+	{"/gc/heap/allocs:bytes", "go_heap_allocs_bytes_total", "Cumulative bytes allocated on the heap.", true},
+	{"/gc/cycles/total:gc-cycles", "go_gc_cycles_total", "Number of completed GC cycles.", true},
+}
+
+// runtimeHistogram 描述一个从 runtime/metrics 读取的分布，
+// 以瞬时值记录两次采集之间新增样本的分位数，并以计数器记录新增样本数
+type runtimeHistogram struct {
+	samples []string // runtime/metrics 中的名称，使用第一个可用的
+	name    string
+	count   string // 样本数计数器的名称
+	help    string
+}
+
+var runtimeHistograms = []runtimeHistogram{
+	// Go 1.22 起 /gc/pauses:seconds 被 /sched/pauses/total/gc:seconds 取代
+	{[]string{"/sched/pauses/total/gc:seconds", "/gc/pauses:seconds"}, "go_gc_pause_seconds", "go_gc_pauses_total", "Stop-the-world pause latencies caused by GC"},
+	{[]string{"/sched/latencies:seconds"}, "go_sched_latency_seconds", "go_sched_latencies_total", "Time goroutines spent runnable before running"},
+}
+
+// RuntimeQuantiles 运行时分布输出的分位数，1 表示最大值
+var RuntimeQuantiles = []float64{0.5, 0.9, 0.99, 1}
+
+// RuntimeCollector 定期采集 Go 运行时指标（runtime/metrics）与进程指标（/proc/self，仅 Linux），
+// 记录到 Provider 中，使所有服务有一致的基础监控。需要显式创建并调用 Start 或 Collect
+type RuntimeCollector struct {
+	mu      sync.Mutex
+	samples []rtmetrics.Sample
+
+	values     []collectedValue
+	histograms []collectedHistogram
+	process    *processCollector
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	started  atomic.Bool
+}
+
+// collectedValue 是已注册的标量指标
+type collectedValue struct {
+	index   int // 在 samples 中的位置
+	gauge   Gauge
+	counter Counter
+	last    float64
+}
+
+// collectedHistogram 是已注册的分布
+type collectedHistogram struct {
+	index     int
+	quantiles []Gauge
+	count     Counter
+	last      []uint64 // 上一次采集时各个桶的计数
+}
+
+// NewRuntimeCollector 创建运行时指标采集器并在 provider 中注册指标，provider 为 nil 时使用 Default()
+// 当前 Go 版本不支持的运行时指标会被跳过
+func NewRuntimeCollector(provider Provider) *RuntimeCollector {
+	if provider == nil {
+		provider = Default()
+	}
+	supported := make(map[string]bool)
+	for _, d := range rtmetrics.All() {
+		supported[d.Name] = true
+	}
+
+	c := &RuntimeCollector{stopCh: make(chan struct{})}
+	for _, v := range runtimeValues {
+		if !supported[v.sample] {
+			continue
+		}
+		cv := collectedValue{index: len(c.samples)}
+		opts := Opts{Name: v.name, Help: v.help}
+		if v.counter {
+			cv.counter = provider.Counter(opts)
+		} else {
+			cv.gauge = provider.Gauge(opts)
+		}
+		c.samples = append(c.samples, rtmetrics.Sample{Name: v.sample})
+		c.values = append(c.values, cv)
+	}
+	for _, h := range runtimeHistograms {
+		for _, sample := range h.samples {
+			if !supported[sample] {
+				continue
+			}
+			quantiles := provider.Gauge(Opts{
+				Name:   h.name,
+				Help:   h.help + " since the previous collection, by quantile.",
+				Labels: []string{"quantile"},
+			})
+			ch := collectedHistogram{
+				index: len(c.samples),
+				count: provider.Counter(Opts{Name: h.count, Help: h.help + ", number of samples."}),
+			}
+			for _, q := range RuntimeQuantiles {
+				ch.quantiles = append(ch.quantiles, quantiles.With(strconv.FormatFloat(q, 'g', -1, 64)))
+			}
+			c.samples = append(c.samples, rtmetrics.Sample{Name: sample})
+			c.histograms = append(c.histograms, ch)
+			break
+		}
+	}
+	if processSupported {
+		c.process = newProcessCollector(provider)
+	}
+	return c
+}
+
+// Collect 立即采集一次，返回读取进程指标时的错误，运行时指标总会被更新
+func (c *RuntimeCollector) Collect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rtmetrics.Read(c.samples)
+	for i := range c.values {
+		v := &c.values[i]
+		value := sampleValue(c.samples[v.index].Value)
+		if v.gauge != nil {
+			v.gauge.Set(value)
+			continue
+		}
+		if value > v.last {
+			v.counter.Add(value - v.last)
+		}
+		v.last = value
+	}
+	for i := range c.histograms {
+		h := &c.histograms[i]
+		value := c.samples[h.index].Value
+		if value.Kind() != rtmetrics.KindFloat64Histogram {
+			continue
+		}
+		hist := value.Float64Histogram()
+		delta := make([]uint64, len(hist.Counts))
+		var total uint64
+		for j, n := range hist.Counts {
+			if j < len(h.last) && n >= h.last[j] {
+				n -= h.last[j]
+			}
+			delta[j] = n
+			total += n
+		}
+		h.last = append(h.last[:0], hist.Counts...)
+		h.count.Add(float64(total))
+		for j, q := range RuntimeQuantiles {
+			h.quantiles[j].Set(histogramQuantile(q, delta, total, hist.Buckets))
+		}
+	}
+
+	if c.process != nil {
+		return c.process.collect()
+	}
+	return nil
+}
+
+// Start 按 interval 周期性采集，直到 ctx 结束或调用 Stop；interval 不大于 0 时使用 15 秒
+// 只有第一次调用生效，重复调用不会启动新的采集协程
+func (c *RuntimeCollector) Start(ctx context.Context, interval time.Duration) {
+	if c.started.Swap(true) {
+		return
+	}
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	_ = c.Collect()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.stopCh:
+				return
+			case <-ticker.C:
+				_ = c.Collect()
+			}
+		}
+	}()
+}
+
+// Stop 停止周期性采集
+func (c *RuntimeCollector) Stop() {
+	c.stopOnce.Do(func() { close(c.stopCh) })
+}
+
+// sampleValue 将标量样本转换为 float64，不支持的类型返回 0
+func sampleValue(v rtmetrics.Value) float64 {
+	switch v.Kind() {
+	case rtmetrics.KindUint64:
+		return float64(v.Uint64())
+	case rtmetrics.KindFloat64:
+		return v.Float64()
+	default:
+		return 0
+	}
+}
+
+// histogramQuantile 返回分布中分位数 q 所在桶的上界，上界为 +Inf 时使用下界；没有样本时返回 0
+// buckets 是 len(counts)+1 个桶边界
+func histogramQuantile(q float64, counts []uint64, total uint64, buckets []float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	rank = max(rank, 1)
+	var cumulative uint64
+	for i, n := range counts {
+		cumulative += n
+		if cumulative < rank {
+			continue
+		}
+		if upper := buckets[i+1]; !math.IsInf(upper, 1) {
+			return upper
+		}
+		return buckets[i]
+	}
+	return buckets[len(buckets)-1]
+}
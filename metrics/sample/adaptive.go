@@ -0,0 +1,133 @@
+package sample
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AdaptiveSamplerType 表示按目标吞吐量自适应的采样器
+const AdaptiveSamplerType SamplerType = "adaptive"
+
+// adaptiveBuckets 滑动窗口划分的子窗口数量
+const adaptiveBuckets = 10
+
+// AdaptiveSampler 实现按目标吞吐量自适应的采样
+// 滑动窗口被划分为若干子窗口，每个子窗口结束时根据整个窗口内的请求量重新计算采样率，
+// 使每秒保留的记录数接近 target，采样率始终限制在 [minRate, maxRate] 之间。
+// 流量低于目标时按 maxRate 全量保留，流量高峰时采样率随之下降
+type AdaptiveSampler struct {
+	target    atomic.Uint64 // 每秒目标采样数，float64 的位表示
+	minRate   float64
+	maxRate   float64
+	bucketDur int64 // 子窗口时长（纳秒）
+
+	rate    atomic.Uint64 // 当前采样率，与 RateSampler 相同的定点数表示
+	current atomic.Int64  // 当前子窗口的序号
+	count   atomic.Uint64 // 当前子窗口内的请求数
+
+	mu      sync.Mutex              // 保护子窗口轮转
+	buckets [adaptiveBuckets]uint64 // 已结束的子窗口的请求数，最近的在前
+	filled  int                     // 已结束的子窗口数量，未满一个窗口时只使用已有的子窗口
+
+	opts options
+}
+
+// NewAdaptiveSampler 创建一个新的 AdaptiveSampler
+// target 为每秒目标采样数；window 为计算请求量的滑动窗口，不大于 0 时使用 10 秒；
+// minRate、maxRate 为采样率的上下限，超出 [0, 1] 时截断，maxRate 为 0 时使用 1
+func NewAdaptiveSampler(target float64, window time.Duration, minRate, maxRate float64, opts ...Option) *AdaptiveSampler {
+	if window <= 0 {
+		window = 10 * time.Second
+	}
+	if maxRate <= 0 || maxRate > 1 {
+		maxRate = 1
+	}
+	minRate = math.Max(0, math.Min(minRate, maxRate))
+	s := &AdaptiveSampler{
+		minRate:   minRate,
+		maxRate:   maxRate,
+		bucketDur: max(int64(window)/adaptiveBuckets, 1),
+		opts:      newOptions(opts),
+	}
+	s.target.Store(math.Float64bits(math.Max(target, 0)))
+	s.rate.Store(rateToFixed(maxRate))
+	s.current.Store(s.opts.now().UnixNano() / s.bucketDur)
+	return s
+}
+
+// Sample 按当前采样率采样，并计入滑动窗口的请求量
+func (s *AdaptiveSampler) Sample() bool {
+	idx := s.opts.now().UnixNano() / s.bucketDur
+	if idx != s.current.Load() {
+		s.advance(idx)
+	}
+	s.count.Add(1)
+	return s.opts.below(s.rate.Load())
+}
+
+// advance 结束当前子窗口并重新计算采样率
+func (s *AdaptiveSampler) advance(idx int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur := s.current.Load()
+	if idx <= cur {
+		return // 其他 goroutine 已经完成轮转
+	}
+	s.push(s.count.Swap(0))
+	// 中间没有请求的子窗口计为 0
+	for i := cur + 1; i < idx && i-cur <= adaptiveBuckets; i++ {
+		s.push(0)
+	}
+	s.current.Store(idx)
+	s.rate.Store(rateToFixed(s.computeRate()))
+}
+
+// push 记录一个已结束的子窗口
+func (s *AdaptiveSampler) push(n uint64) {
+	copy(s.buckets[1:], s.buckets[:adaptiveBuckets-1])
+	s.buckets[0] = n
+	if s.filled < adaptiveBuckets {
+		s.filled++
+	}
+}
+
+// computeRate 根据窗口内的请求量计算采样率
+func (s *AdaptiveSampler) computeRate() float64 {
+	var total uint64
+	for _, n := range s.buckets[:s.filled] {
+		total += n
+	}
+	if total == 0 {
+		return s.maxRate
+	}
+	seconds := float64(int64(s.filled)*s.bucketDur) / float64(time.Second)
+	rate := math.Float64frombits(s.target.Load()) / (float64(total) / seconds)
+	return math.Max(s.minRate, math.Min(rate, s.maxRate))
+}
+
+// SetRate 直接设置采样率，在下一个子窗口结束时会按目标吞吐量重新计算
+func (s *AdaptiveSampler) SetRate(rate float64) {
+	s.rate.Store(rateToFixed(math.Max(s.minRate, math.Min(rate, s.maxRate))))
+}
+
+// GetRate 获取当前采样率
+func (s *AdaptiveSampler) GetRate() float64 {
+	return float64(s.rate.Load()) / (1 << 63)
+}
+
+// SetTarget 设置每秒目标采样数，在下一个子窗口结束时生效
+func (s *AdaptiveSampler) SetTarget(target float64) {
+	s.target.Store(math.Float64bits(math.Max(target, 0)))
+}
+
+// Target 返回每秒目标采样数
+func (s *AdaptiveSampler) Target() float64 {
+	return math.Float64frombits(s.target.Load())
+}
+
+// rateToFixed 将 [0, 1] 的采样率转换为与 RateSampler 相同的定点数表示
+func rateToFixed(rate float64) uint64 {
+	return uint64(rate * (1 << 63))
+}
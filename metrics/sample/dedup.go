@@ -0,0 +1,202 @@
+package sample
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DedupSamplerType 表示限流去重采样器
+const DedupSamplerType SamplerType = "dedup"
+
+// dedupBuckets 槽位数量，键按哈希分配到槽位上；每个槽位可容纳 dedupWays 个键
+const (
+	dedupBuckets = 1024
+	dedupWays    = 4
+)
+
+// maxEvictedKeys 两次汇报之间最多保留的被挤出槽位的键数，超出部分的丢弃数量不再汇报
+const maxEvictedKeys = dedupBuckets * dedupWays
+
+// dedupEntry 是槽位中单个键的计数
+type dedupEntry struct {
+	fingerprint uint64 // 键的 64 位哈希，用于在比较键之前快速排除
+	key         string // 用于汇报的键
+	resetAt     int64  // 当前周期结束的时间（纳秒）
+	count       uint64 // 当前周期内的计数
+	dropped     uint64 // 自上次汇报以来丢弃的数量
+	used        uint64 // 最近一次使用的序号，用于淘汰最久未使用的键
+	inUse       bool
+}
+
+// dedupBucket 是一组哈希到同一位置的键，槽位已满时淘汰最久未使用的键
+type dedupBucket struct {
+	mu      sync.Mutex
+	entries [dedupWays]dedupEntry
+	tick    uint64
+}
+
+// DedupSampler 实现限流去重采样
+// 与 zap 的采样策略相同：每个周期内，相同键的前 First 条全部保留，之后每 Thereafter 条保留一条。
+// 被丢弃的数量按键累计，可通过 Suppressed 取出用于输出汇总日志。
+// 哈希到同一槽位的不同键各自计数，槽位中的键超过 dedupWays 个时淘汰最久未使用的键，
+// 被淘汰的键已丢弃的数量留待汇报
+type DedupSampler struct {
+	first      uint64
+	thereafter atomic.Uint64
+	interval   time.Duration
+	buckets    [dedupBuckets]dedupBucket
+	opts       options
+
+	mu      sync.Mutex
+	evicted map[string]uint64 // 被淘汰的键自上次汇报以来丢弃的数量
+}
+
+// NewDedupSampler 创建一个新的 DedupSampler
+// thereafter 为 0 表示超过 first 后全部丢弃；interval 不大于 0 时使用 1 秒。
+// 计数周期使用 WithClock 设置的时钟，该采样器不使用随机数
+func NewDedupSampler(first, thereafter int, interval time.Duration, opts ...Option) *DedupSampler {
+	if first < 0 {
+		first = 0
+	}
+	if thereafter < 0 {
+		thereafter = 0
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	s := &DedupSampler{
+		first:    uint64(first),
+		interval: interval,
+		opts:     newOptions(opts),
+	}
+	s.thereafter.Store(uint64(thereafter))
+	return s
+}
+
+// Sample 不区分键的采样，所有记录共享同一个计数
+func (s *DedupSampler) Sample() bool {
+	return s.SampleKey("")
+}
+
+// SampleKey 对指定键做限流去重判断
+func (s *DedupSampler) SampleKey(key string) bool {
+	h := s.opts.hash(key)
+	now := s.opts.now().UnixNano()
+	b := &s.buckets[h%dedupBuckets]
+	b.mu.Lock()
+	e := s.entry(b, key, h)
+	if e.resetAt <= now {
+		e.resetAt = now + s.interval.Nanoseconds()
+		e.count = 0
+	}
+	e.count++
+	n := e.count
+	keep := n <= s.first
+	if !keep {
+		if thereafter := s.thereafter.Load(); thereafter > 0 && (n-s.first)%thereafter == 0 {
+			keep = true
+		}
+	}
+	if !keep {
+		e.dropped++
+	}
+	b.mu.Unlock()
+	return keep
+}
+
+// entry 返回 key 在槽位中的计数，不存在时占用空位或淘汰最久未使用的键，调用方需持有 b.mu
+func (s *DedupSampler) entry(b *dedupBucket, key string, h uint64) *dedupEntry {
+	b.tick++
+	victim := &b.entries[0]
+	for i := range b.entries {
+		e := &b.entries[i]
+		if e.inUse && e.fingerprint == h && e.key == key {
+			e.used = b.tick
+			return e
+		}
+		if !victim.inUse {
+			continue
+		}
+		if !e.inUse || e.used < victim.used {
+			victim = e
+		}
+	}
+	if victim.inUse && victim.dropped > 0 {
+		s.addEvicted(victim.key, victim.dropped)
+	}
+	*victim = dedupEntry{fingerprint: h, key: key, used: b.tick, inUse: true}
+	return victim
+}
+
+// addEvicted 累计被挤出槽位的键丢弃的数量
+func (s *DedupSampler) addEvicted(key string, n uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.evicted == nil {
+		s.evicted = make(map[string]uint64)
+	}
+	if _, ok := s.evicted[key]; ok || len(s.evicted) < maxEvictedKeys {
+		s.evicted[key] += n
+	}
+}
+
+// Suppressed 返回自上次调用以来被丢弃的记录统计，并清零计数
+func (s *DedupSampler) Suppressed() []Suppression {
+	var out []Suppression
+	for i := range s.buckets {
+		b := &s.buckets[i]
+		b.mu.Lock()
+		for j := range b.entries {
+			if e := &b.entries[j]; e.inUse && e.dropped > 0 {
+				out = append(out, Suppression{Key: e.key, Count: e.dropped})
+				e.dropped = 0
+			}
+		}
+		b.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	evicted := s.evicted
+	s.evicted = nil
+	s.mu.Unlock()
+	for i := range out {
+		if n, ok := evicted[out[i].Key]; ok {
+			out[i].Count += n
+			delete(evicted, out[i].Key)
+		}
+	}
+	for key, n := range evicted {
+		out = append(out, Suppression{Key: key, Count: n})
+	}
+	return out
+}
+
+// SetRate 设置超过 First 之后的采样率，即每 1/rate 条保留一条
+func (s *DedupSampler) SetRate(rate float64) {
+	if rate <= 0 {
+		s.thereafter.Store(0)
+		return
+	}
+	s.thereafter.Store(uint64(math.Max(1, math.Round(1/math.Min(rate, 1)))))
+}
+
+// GetRate 获取超过 First 之后的采样率
+func (s *DedupSampler) GetRate() float64 {
+	thereafter := s.thereafter.Load()
+	if thereafter == 0 {
+		return 0
+	}
+	return 1 / float64(thereafter)
+}
+
+// hashKey 计算键的 64 位 FNV-1a 哈希，避免使用 hash.Hash 带来的内存分配
+func hashKey(key string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return h
+}
@@ -0,0 +1,91 @@
+package sample
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// Source 是采样器使用的随机数来源，实现必须是并发安全的
+type Source interface {
+	Uint64() uint64
+}
+
+// globalSource 使用 math/rand 的全局随机数来源
+type globalSource struct{}
+
+func (globalSource) Uint64() uint64 {
+	return rand.Uint64()
+}
+
+// SeededSource 是由种子决定的并发安全随机数来源，基于 SplitMix64 与原子计数，不需要加锁
+// 相同种子下生成的序列相同，用于测试和仿真中复现采样结果
+type SeededSource struct {
+	state atomic.Uint64
+}
+
+// NewSeededSource 使用指定种子创建随机数来源
+func NewSeededSource(seed uint64) *SeededSource {
+	s := &SeededSource{}
+	s.state.Store(seed)
+	return s
+}
+
+// Uint64 返回下一个随机数
+func (s *SeededSource) Uint64() uint64 {
+	z := s.state.Add(0x9e3779b97f4a7c15)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Option 定义采样器的可选配置
+type Option func(*options)
+
+// options 保存采样器共用的随机数来源、时钟与键哈希函数
+type options struct {
+	source Source
+	now    func() time.Time
+	hash   func(string) uint64
+}
+
+// WithSource 设置随机数来源，默认使用 math/rand 的全局来源
+func WithSource(source Source) Option {
+	return func(o *options) {
+		if source != nil {
+			o.source = source
+		}
+	}
+}
+
+// WithClock 设置时钟，默认使用 time.Now
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		if now != nil {
+			o.now = now
+		}
+	}
+}
+
+// WithKeyHash 设置限流去重采样器计算键哈希的函数，默认使用 64 位 FNV-1a，用于测试哈希冲突
+func WithKeyHash(hash func(string) uint64) Option {
+	return func(o *options) {
+		if hash != nil {
+			o.hash = hash
+		}
+	}
+}
+
+// newOptions 应用可选配置
+func newOptions(opts []Option) options {
+	o := options{source: globalSource{}, now: time.Now, hash: hashKey}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// below 判断 [0, 2^63) 区间内的随机数是否小于定点数表示的采样率
+func (o *options) below(rate uint64) bool {
+	return o.source.Uint64()>>1 < rate
+}
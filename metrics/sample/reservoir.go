@@ -0,0 +1,221 @@
+package sample
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+)
+
+// Reservoir 使用蓄水池抽样保留固定数量的代表性样本，每条记录被保留的概率相同
+// 前 size 条记录直接放入（即 Algorithm R 的填充阶段），之后使用 Algorithm L 计算需要跳过的记录数，
+// 只在被选中的记录上生成随机数，适合高频记录的场景。所有方法都是并发安全的
+type Reservoir[T any] struct {
+	mu    sync.Mutex
+	size  int
+	items []T
+	seen  uint64  // 已经提供的记录数
+	next  uint64  // 下一条被选中的记录序号（从 1 开始）
+	w     float64 // Algorithm L 的状态
+	opts  options
+}
+
+// NewReservoir 创建容量为 size 的蓄水池，size 不大于 0 时不保留任何记录
+func NewReservoir[T any](size int, opts ...Option) *Reservoir[T] {
+	if size < 0 {
+		size = 0
+	}
+	return &Reservoir[T]{
+		size:  size,
+		items: make([]T, 0, size),
+		opts:  newOptions(opts),
+	}
+}
+
+// Offer 提供一条记录，返回该记录是否被放入蓄水池
+func (r *Reservoir[T]) Offer(item T) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen++
+	if r.size == 0 {
+		return false
+	}
+	if len(r.items) < r.size {
+		r.items = append(r.items, item)
+		if len(r.items) == r.size {
+			r.w = math.Exp(math.Log(openFloat64(r.opts.source)) / float64(r.size))
+			r.advance()
+		}
+		return true
+	}
+	if r.seen != r.next {
+		return false
+	}
+	r.items[int(r.opts.source.Uint64()%uint64(r.size))] = item
+	r.w *= math.Exp(math.Log(openFloat64(r.opts.source)) / float64(r.size))
+	r.advance()
+	return true
+}
+
+// advance 计算下一条被选中的记录序号
+func (r *Reservoir[T]) advance() {
+	skip := math.Floor(math.Log(openFloat64(r.opts.source)) / math.Log1p(-r.w))
+	if skip >= math.MaxUint64/2 || math.IsNaN(skip) {
+		r.next = math.MaxUint64
+		return
+	}
+	r.next = r.seen + uint64(skip) + 1
+}
+
+// Snapshot 返回当前样本的副本
+func (r *Reservoir[T]) Snapshot() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]T, len(r.items))
+	copy(out, r.items)
+	return out
+}
+
+// Seen 返回已经提供的记录数
+func (r *Reservoir[T]) Seen() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seen
+}
+
+// Reset 清空样本，返回清空前的样本副本和记录数，便于按周期输出统计
+func (r *Reservoir[T]) Reset() ([]T, uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]T, len(r.items))
+	copy(out, r.items)
+	seen := r.seen
+	clear(r.items)
+	r.items = r.items[:0]
+	r.seen, r.next, r.w = 0, 0, 0
+	return out, seen
+}
+
+// Weighted 是优先级抽样保留的样本
+type Weighted[T any] struct {
+	Item     T
+	Weight   float64 // 记录的原始权重
+	Estimate float64 // 权重的无偏估计，所有样本的 Estimate 之和是总权重的无偏估计
+}
+
+// PrioritySampler 使用优先级抽样（Duffield 等人提出的 priority sampling）按权重保留固定数量的样本
+// 权重越大的记录越容易被保留，例如以耗时为权重时会优先保留慢查询；
+// 每条记录的优先级为 weight/u（u 为 (0, 1] 上的随机数），保留优先级最高的 size 条。所有方法都是并发安全的
+type PrioritySampler[T any] struct {
+	mu    sync.Mutex
+	size  int
+	items priorityHeap[T] // 最小堆，最多保存 size+1 条，堆顶是用于估计权重的阈值
+	seen  uint64
+	total float64 // 已提供记录的权重之和
+	opts  options
+}
+
+// NewPrioritySampler 创建容量为 size 的优先级抽样器，size 不大于 0 时不保留任何记录
+func NewPrioritySampler[T any](size int, opts ...Option) *PrioritySampler[T] {
+	if size < 0 {
+		size = 0
+	}
+	return &PrioritySampler[T]{
+		size:  size,
+		items: make(priorityHeap[T], 0, size+1),
+		opts:  newOptions(opts),
+	}
+}
+
+// Offer 以指定权重提供一条记录，权重不大于 0 的记录被忽略
+func (p *PrioritySampler[T]) Offer(item T, weight float64) {
+	if !(weight > 0) || math.IsInf(weight, 1) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seen++
+	p.total += weight
+	if p.size == 0 {
+		return
+	}
+	priority := weight / openFloat64(p.opts.source)
+	if len(p.items) <= p.size {
+		heap.Push(&p.items, prioritized[T]{item: item, weight: weight, priority: priority})
+		return
+	}
+	if priority > p.items[0].priority {
+		p.items[0] = prioritized[T]{item: item, weight: weight, priority: priority}
+		heap.Fix(&p.items, 0)
+	}
+}
+
+// Snapshot 返回按优先级从高到低排列的样本副本
+func (p *PrioritySampler[T]) Snapshot() []Weighted[T] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.snapshotLocked()
+}
+
+// snapshotLocked 生成样本副本，调用方需持有锁
+// 记录数超过容量时，第 size+1 高的优先级作为阈值 τ，样本的估计权重为 max(weight, τ)
+func (p *PrioritySampler[T]) snapshotLocked() []Weighted[T] {
+	sorted := make(priorityHeap[T], len(p.items))
+	copy(sorted, p.items)
+	var threshold float64
+	if len(sorted) > p.size {
+		threshold = heap.Pop(&sorted).(prioritized[T]).priority
+	}
+	out := make([]Weighted[T], len(sorted))
+	for i := len(out) - 1; i >= 0; i-- {
+		e := heap.Pop(&sorted).(prioritized[T])
+		out[i] = Weighted[T]{Item: e.item, Weight: e.weight, Estimate: math.Max(e.weight, threshold)}
+	}
+	return out
+}
+
+// Seen 返回已经提供的记录数与权重之和
+func (p *PrioritySampler[T]) Seen() (uint64, float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.seen, p.total
+}
+
+// Reset 清空样本，返回清空前的样本副本
+func (p *PrioritySampler[T]) Reset() []Weighted[T] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := p.snapshotLocked()
+	clear(p.items)
+	p.items = p.items[:0]
+	p.seen, p.total = 0, 0
+	return out
+}
+
+// prioritized 是带优先级的记录
+type prioritized[T any] struct {
+	item     T
+	weight   float64
+	priority float64
+}
+
+// priorityHeap 是按优先级排序的最小堆
+type priorityHeap[T any] []prioritized[T]
+
+func (h priorityHeap[T]) Len() int           { return len(h) }
+func (h priorityHeap[T]) Less(i, j int) bool { return h[i].priority < h[j].priority }
+func (h priorityHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *priorityHeap[T]) Push(x any)        { *h = append(*h, x.(prioritized[T])) }
+func (h *priorityHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	var zero prioritized[T]
+	old[n-1] = zero
+	*h = old[:n-1]
+	return x
+}
+
+// openFloat64 返回 (0, 1) 区间的随机浮点数，可以安全地取对数或作除数
+func openFloat64(source Source) float64 {
+	return (float64(source.Uint64()>>11) + 0.5) / (1 << 53)
+}
@@ -1,7 +1,8 @@
 package sample
 
 import (
-	"math/rand"
+	"math"
+	"strconv"
 	"sync/atomic"
 	"time"
 )
@@ -14,6 +15,8 @@ const (
 	RateSamplerType SamplerType = "rate"
 	// JitterSamplerType 表示基于抖动的采样器
 	JitterSamplerType SamplerType = "jitter"
+	// TraceSamplerType 表示基于 trace ID 的一致性采样器
+	TraceSamplerType SamplerType = "trace"
 )
 
 // Sampler 定义采样器接口
@@ -23,6 +26,15 @@ type Sampler interface {
 	GetRate() float64
 }
 
+// TraceAwareSampler 定义基于 trace 的采样接口
+// 同一个 trace 的所有采样判断结果一致，从而保证一条请求链路的日志要么全部保留，要么全部丢弃
+type TraceAwareSampler interface {
+	Sampler
+	// SampleTrace 根据十六进制的 trace ID 做采样判断，sampled 表示上游 span 是否已被采样
+	// traceID 为空或非法时退化为 Sample
+	SampleTrace(traceID string, sampled bool) bool
+}
+
 // RateSampler 实现基于比率的采样
 type RateSampler struct {
 	// 高效地实现浮点数采样率
@@ -30,18 +42,21 @@ type RateSampler struct {
 	// 这样可以将 0.0 到 1.0 的范围映射到 0 到 2^63 的整数范围。
 	// 好处是使用整数可以利用原子操作,也更快一些
 	rate uint64
+	opts options
 }
 
 // NewRateSampler 创建一个新的 RateSampler
-func NewRateSampler(rate float64) *RateSampler {
+func NewRateSampler(rate float64, opts ...Option) *RateSampler {
 	return &RateSampler{
 		rate: uint64(rate * (1 << 63)),
+		opts: newOptions(opts),
 	}
 }
 
 // Sample 根据给定的比率进行采样
+// 随机数取 [0, 2^63) 区间，与 rate 的映射范围一致，采样率为 1 时始终采样
 func (s *RateSampler) Sample() bool {
-	return rand.Uint64() < atomic.LoadUint64(&s.rate)
+	return s.opts.below(atomic.LoadUint64(&s.rate))
 }
 
 // SetRate 设置新的采样率
@@ -55,40 +70,114 @@ func (s *RateSampler) GetRate() float64 {
 }
 
 // JitterSampler 实现基于抖动的采样
+// 采样率与上一次采样时间都使用原子变量保存，并发调用时同一个抖动间隔内只有一个调用会被采样
 type JitterSampler struct {
-	rate       atomic.Value  // 存储 float64 类型的采样率
+	rate       atomic.Uint64 // float64 类型采样率的位表示
 	jitter     time.Duration // 定义两次采样之间的最小时间间隔
-	lastSample time.Time     // 记录上一次采样成功的时间
+	lastSample atomic.Int64  // 记录上一次采样成功的时间（纳秒），0 表示尚未采样
+	opts       options
 }
 
 // NewJitterSampler 创建一个新的 JitterSampler
-func NewJitterSampler(rate float64, jitter time.Duration) *JitterSampler {
+func NewJitterSampler(rate float64, jitter time.Duration, opts ...Option) *JitterSampler {
 	s := &JitterSampler{
 		jitter: jitter,
+		opts:   newOptions(opts),
 	}
-	s.rate.Store(rate)
+	s.SetRate(rate)
 	return s
 }
 
 // Sample 根据给定的抖动进行采样
 func (s *JitterSampler) Sample() bool {
-	now := time.Now()
-	if now.Sub(s.lastSample) < s.jitter {
+	now := s.opts.now().UnixNano()
+	last := s.lastSample.Load()
+	if last != 0 && now-last < int64(s.jitter) {
 		return false
 	}
-	if rand.Float64() < s.rate.Load().(float64) {
-		s.lastSample = now
-		return true
+	if randFloat64(s.opts.source) >= s.GetRate() {
+		return false
 	}
-	return false
+	// 并发调用时只有一个调用能更新采样时间，其余调用视为落在同一个抖动间隔内
+	return s.lastSample.CompareAndSwap(last, now)
 }
 
 // SetRate 设置新的采样率
 func (s *JitterSampler) SetRate(rate float64) {
-	s.rate.Store(rate)
+	s.rate.Store(math.Float64bits(rate))
 }
 
 // GetRate 获取当前采样率
 func (s *JitterSampler) GetRate() float64 {
-	return s.rate.Load().(float64)
+	return math.Float64frombits(s.rate.Load())
+}
+
+// randFloat64 返回 [0, 1) 区间的随机浮点数
+func randFloat64(source Source) float64 {
+	return float64(source.Uint64()>>11) / (1 << 53)
+}
+
+// TraceSampler 实现基于 trace ID 的一致性采样
+// 判断方式与 OpenTelemetry 的 TraceIDRatioBased 相同：取 trace ID 低 8 字节右移一位后与采样阈值比较，
+// 因此相同采样率下日志与链路的采样结果一致；上游已采样的 span 始终保留
+type TraceSampler struct {
+	rate uint64 // 与 RateSampler 相同的定点数表示
+	opts options
+}
+
+// NewTraceSampler 创建一个新的 TraceSampler
+func NewTraceSampler(rate float64, opts ...Option) *TraceSampler {
+	return &TraceSampler{
+		rate: uint64(rate * (1 << 63)),
+		opts: newOptions(opts),
+	}
+}
+
+// Sample 没有 trace 信息时按比率随机采样
+func (s *TraceSampler) Sample() bool {
+	return s.opts.below(atomic.LoadUint64(&s.rate))
+}
+
+// SampleTrace 根据 trace ID 做确定性的采样判断
+func (s *TraceSampler) SampleTrace(traceID string, sampled bool) bool {
+	if sampled {
+		return true
+	}
+	if len(traceID) != 32 {
+		return s.Sample()
+	}
+	low, err := strconv.ParseUint(traceID[16:], 16, 64)
+	if err != nil {
+		return s.Sample()
+	}
+	return low>>1 < atomic.LoadUint64(&s.rate)
+}
+
+// SetRate 设置新的采样率
+func (s *TraceSampler) SetRate(rate float64) {
+	atomic.StoreUint64(&s.rate, uint64(rate*(1<<63)))
+}
+
+// GetRate 获取当前采样率
+func (s *TraceSampler) GetRate() float64 {
+	return float64(atomic.LoadUint64(&s.rate)) / (1 << 63)
+}
+
+// KeyedSampler 定义按键采样的接口，相同键的记录共享计数
+type KeyedSampler interface {
+	Sampler
+	// SampleKey 对指定键做采样判断
+	SampleKey(key string) bool
+}
+
+// Suppression 记录某个键在上一个汇总周期内被丢弃的次数
+type Suppression struct {
+	Key   string
+	Count uint64
+}
+
+// SuppressionReporter 定义汇报被丢弃记录的接口
+type SuppressionReporter interface {
+	// Suppressed 返回自上次调用以来被丢弃的记录统计，并清零计数
+	Suppressed() []Suppression
 }
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/metrics/sample"
+)
+
+// 编译期检查 AdaptiveSampler 实现了 Sampler 接口
+var _ sample.Sampler = (*sample.AdaptiveSampler)(nil)
+
+// simulate 以每秒 perSecond 次的速度调用 Sample，持续 d 时间，返回保留数
+func simulate(s sample.Sampler, clock *fakeClock, perSecond int, d time.Duration) int {
+	step := time.Second / time.Duration(perSecond)
+	kept := 0
+	for elapsed := time.Duration(0); elapsed < d; elapsed += step {
+		if s.Sample() {
+			kept++
+		}
+		clock.Advance(step)
+	}
+	return kept
+}
+
+func TestAdaptiveSamplerTargetsThroughput(t *testing.T) {
+	clock := newFakeClock()
+	s := sample.NewAdaptiveSampler(100, time.Second, 0, 1,
+		sample.WithClock(clock.Now), sample.WithSource(sample.NewSeededSource(1)))
+	if s.GetRate() != 1 {
+		t.Fatalf("initial rate = %v, want 1", s.GetRate())
+	}
+	// 低于目标时全量保留
+	if kept := simulate(s, clock, 50, 2*time.Second); kept != 100 {
+		t.Errorf("kept %d at low traffic, want all 100", kept)
+	}
+	// 流量升到 10000/s，经过一个窗口后每秒保留数接近目标
+	simulate(s, clock, 10000, 1100*time.Millisecond)
+	if rate := s.GetRate(); rate < 0.009 || rate > 0.011 {
+		t.Errorf("rate at peak = %v, want about 0.01", rate)
+	}
+	if kept := simulate(s, clock, 10000, 5*time.Second); kept < 400 || kept > 600 {
+		t.Errorf("kept %d in 5s at peak, want about 500", kept)
+	}
+}
+
+func TestAdaptiveSamplerBounds(t *testing.T) {
+	clock := newFakeClock()
+	s := sample.NewAdaptiveSampler(1, 100*time.Millisecond, 0.2, 0.8, sample.WithClock(clock.Now))
+	if s.GetRate() != 0.8 {
+		t.Errorf("initial rate = %v, want max 0.8", s.GetRate())
+	}
+	simulate(s, clock, 1000, 200*time.Millisecond)
+	if rate := s.GetRate(); rate != 0.2 {
+		t.Errorf("rate under heavy load = %v, want min 0.2", rate)
+	}
+
+	// 流量消失一个窗口后恢复到上限
+	clock.Advance(200 * time.Millisecond)
+	s.Sample()
+	if rate := s.GetRate(); rate != 0.8 {
+		t.Errorf("rate after idle window = %v, want max 0.8", rate)
+	}
+
+	s.SetRate(0.01)
+	if rate := s.GetRate(); rate != 0.2 {
+		t.Errorf("SetRate should clamp to bounds, got %v", rate)
+	}
+}
+
+func TestAdaptiveSamplerSetTarget(t *testing.T) {
+	clock := newFakeClock()
+	s := sample.NewAdaptiveSampler(100, time.Second, 0, 0, sample.WithClock(clock.Now))
+	if s.GetRate() != 1 {
+		t.Errorf("maxRate 0 should default to 1, got %v", s.GetRate())
+	}
+	s.SetTarget(500)
+	if s.Target() != 500 {
+		t.Errorf("Target() = %v", s.Target())
+	}
+	simulate(s, clock, 1000, 2*time.Second)
+	if rate := s.GetRate(); rate < 0.49 || rate > 0.51 {
+		t.Errorf("rate = %v, want about 0.5", rate)
+	}
+}
+
+func TestAdaptiveSamplerConcurrent(t *testing.T) {
+	s := sample.NewAdaptiveSampler(1000, 50*time.Millisecond, 0.01, 1)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deadline := time.Now().Add(100 * time.Millisecond)
+			for time.Now().Before(deadline) {
+				s.Sample()
+			}
+		}()
+	}
+	wg.Wait()
+	if rate := s.GetRate(); rate < 0.01 || rate > 1 {
+		t.Errorf("rate = %v out of bounds", rate)
+	}
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/metrics/sample"
+)
+
+func TestDedupSamplerFirstThereafter(t *testing.T) {
+	s := sample.NewDedupSampler(3, 10, time.Hour)
+	kept := 0
+	for i := 0; i < 103; i++ {
+		if s.SampleKey("ERROR|mongo down") {
+			kept++
+		}
+	}
+	// 前 3 条全部保留，之后 100 条中每 10 条保留一条
+	if kept != 13 {
+		t.Errorf("kept = %d, want 13", kept)
+	}
+	if !s.SampleKey("WARN|other") {
+		t.Error("different keys should be counted separately")
+	}
+
+	suppressed := s.Suppressed()
+	if len(suppressed) != 1 || suppressed[0].Key != "ERROR|mongo down" || suppressed[0].Count != 90 {
+		t.Errorf("Suppressed() = %+v", suppressed)
+	}
+	if again := s.Suppressed(); len(again) != 0 {
+		t.Errorf("Suppressed() should reset counts, got %+v", again)
+	}
+}
+
+func TestDedupSamplerInterval(t *testing.T) {
+	s := sample.NewDedupSampler(1, 0, 20*time.Millisecond)
+	if !s.SampleKey("k") || s.SampleKey("k") {
+		t.Fatal("expected first kept and second dropped")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !s.SampleKey("k") {
+		t.Error("counter should reset after interval")
+	}
+}
+
+func TestDedupSamplerConcurrent(t *testing.T) {
+	s := sample.NewDedupSampler(10, 0, time.Hour)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	kept := 0
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if s.SampleKey("storm") {
+					mu.Lock()
+					kept++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if kept != 10 {
+		t.Errorf("kept = %d, want 10", kept)
+	}
+	if got := s.Suppressed(); len(got) != 1 || got[0].Count != 790 {
+		t.Errorf("Suppressed() = %+v", got)
+	}
+}
+
+func TestDedupSamplerRate(t *testing.T) {
+	s := sample.NewDedupSampler(0, 4, time.Second)
+	if got := s.GetRate(); got != 0.25 {
+		t.Errorf("GetRate() = %v, want 0.25", got)
+	}
+	s.SetRate(0.1)
+	if got := s.GetRate(); got != 0.1 {
+		t.Errorf("GetRate() = %v, want 0.1", got)
+	}
+	s.SetRate(0)
+	if s.Sample() {
+		t.Error("rate 0 with first 0 should drop everything")
+	}
+}
+
+func TestDedupSamplerHashCollision(t *testing.T) {
+	// 两个键落在同一个槽位上，但 64 位哈希不同
+	hash := func(key string) uint64 {
+		if key == "ERROR|disk full" {
+			return 7 + 4096
+		}
+		return 7
+	}
+	s := sample.NewDedupSampler(1, 0, time.Hour, sample.WithKeyHash(hash))
+	if !s.SampleKey("ERROR|mongo down") || s.SampleKey("ERROR|mongo down") {
+		t.Fatal("expected first kept and second dropped")
+	}
+	if !s.SampleKey("ERROR|disk full") {
+		t.Fatal("a colliding key should not share the rate limit of another key")
+	}
+	s.SampleKey("ERROR|disk full")
+	s.SampleKey("ERROR|disk full")
+
+	got := map[string]uint64{}
+	for _, sup := range s.Suppressed() {
+		got[sup.Key] += sup.Count
+	}
+	if len(got) != 2 || got["ERROR|mongo down"] != 1 || got["ERROR|disk full"] != 2 {
+		t.Errorf("Suppressed() = %v, want drops credited to each key", got)
+	}
+	if again := s.Suppressed(); len(again) != 0 {
+		t.Errorf("Suppressed() should reset counts, got %+v", again)
+	}
+}
+
+func TestDedupSamplerAlternatingCollision(t *testing.T) {
+	// 同一槽位上的两个键交替出现时，不应互相清零计数
+	hash := func(key string) uint64 {
+		if key == "b" {
+			return 7 + 4096
+		}
+		return 7
+	}
+	s := sample.NewDedupSampler(1, 0, time.Hour, sample.WithKeyHash(hash))
+	kept := map[string]int{}
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"a", "b"} {
+			if s.SampleKey(key) {
+				kept[key]++
+			}
+		}
+	}
+	if kept["a"] != 1 || kept["b"] != 1 {
+		t.Errorf("kept = %v, want each key kept once", kept)
+	}
+
+	got := map[string]uint64{}
+	for _, sup := range s.Suppressed() {
+		got[sup.Key] += sup.Count
+	}
+	if got["a"] != 99 || got["b"] != 99 {
+		t.Errorf("Suppressed() = %v, want 99 drops per key", got)
+	}
+}
+
+func TestDedupSamplerEvictionKeepsDrops(t *testing.T) {
+	// 所有键落在同一槽位，超出容量时淘汰最久未使用的键，其丢弃数量仍需汇报
+	s := sample.NewDedupSampler(1, 0, time.Hour, sample.WithKeyHash(func(string) uint64 { return 7 }))
+	keys := []string{"k0", "k1", "k2", "k3", "k4", "k5"}
+	for _, key := range keys {
+		s.SampleKey(key)
+		s.SampleKey(key)
+	}
+
+	got := map[string]uint64{}
+	for _, sup := range s.Suppressed() {
+		got[sup.Key] += sup.Count
+	}
+	for _, key := range keys {
+		if got[key] != 1 {
+			t.Errorf("Suppressed()[%q] = %d, want 1 (all: %v)", key, got[key], got)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/metrics/sample"
+)
+
+// fakeClock 是可以手动推进的并发安全时钟
+type fakeClock struct {
+	nanos atomic.Int64
+}
+
+func newFakeClock() *fakeClock {
+	c := &fakeClock{}
+	c.nanos.Store(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	return time.Unix(0, c.nanos.Load())
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.nanos.Add(int64(d))
+}
+
+// decisions 记录 n 次采样结果
+func decisions(s sample.Sampler, n int) []bool {
+	out := make([]bool, n)
+	for i := range out {
+		out[i] = s.Sample()
+	}
+	return out
+}
+
+func TestSeededSamplersReproducible(t *testing.T) {
+	samplers := map[string]func(seed uint64) sample.Sampler{
+		"rate": func(seed uint64) sample.Sampler {
+			return sample.NewRateSampler(0.3, sample.WithSource(sample.NewSeededSource(seed)))
+		},
+		"trace": func(seed uint64) sample.Sampler {
+			return sample.NewTraceSampler(0.3, sample.WithSource(sample.NewSeededSource(seed)))
+		},
+		"jitter": func(seed uint64) sample.Sampler {
+			clock := newFakeClock()
+			return sample.NewJitterSampler(0.5, 0, sample.WithSource(sample.NewSeededSource(seed)), sample.WithClock(clock.Now))
+		},
+	}
+	for name, newSampler := range samplers {
+		t.Run(name, func(t *testing.T) {
+			a := decisions(newSampler(42), 200)
+			b := decisions(newSampler(42), 200)
+			c := decisions(newSampler(7), 200)
+			same, kept := true, 0
+			for i := range a {
+				if a[i] != b[i] {
+					t.Fatalf("decision %d differs for the same seed", i)
+				}
+				if a[i] != c[i] {
+					same = false
+				}
+				if a[i] {
+					kept++
+				}
+			}
+			if same {
+				t.Error("different seeds produced identical decisions")
+			}
+			if kept == 0 || kept == len(a) {
+				t.Errorf("kept %d of %d", kept, len(a))
+			}
+		})
+	}
+}
+
+func TestJitterSamplerClock(t *testing.T) {
+	clock := newFakeClock()
+	s := sample.NewJitterSampler(1, time.Second, sample.WithClock(clock.Now))
+	if !s.Sample() {
+		t.Fatal("first sample should be kept")
+	}
+	clock.Advance(999 * time.Millisecond)
+	if s.Sample() {
+		t.Error("sample within jitter should be dropped")
+	}
+	clock.Advance(time.Millisecond)
+	if !s.Sample() {
+		t.Error("sample after jitter should be kept")
+	}
+	s.SetRate(0)
+	clock.Advance(time.Hour)
+	if s.Sample() || s.GetRate() != 0 {
+		t.Error("rate 0 should drop everything")
+	}
+}
+
+func TestJitterSamplerConcurrent(t *testing.T) {
+	clock := newFakeClock()
+	s := sample.NewJitterSampler(1, time.Second, sample.WithClock(clock.Now))
+	var kept atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if s.Sample() {
+					kept.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// 时钟没有推进，整个过程处于同一个抖动间隔内
+	if got := kept.Load(); got != 1 {
+		t.Errorf("kept %d samples within one jitter interval, want 1", got)
+	}
+}
+
+func TestDedupSamplerClock(t *testing.T) {
+	clock := newFakeClock()
+	s := sample.NewDedupSampler(1, 0, time.Minute, sample.WithClock(clock.Now))
+	if !s.SampleKey("k") || s.SampleKey("k") {
+		t.Fatal("expected first kept and second dropped")
+	}
+	clock.Advance(time.Minute)
+	if !s.SampleKey("k") {
+		t.Error("counter should reset after interval")
+	}
+}
+
+func TestSeededSourceConcurrent(t *testing.T) {
+	src := sample.NewSeededSource(1)
+	seen := sync.Map{}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if _, dup := seen.LoadOrStore(src.Uint64(), true); dup {
+					t.Error("duplicate value from seeded source")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,164 @@
+package test
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/omeyang/gokit/metrics/sample"
+)
+
+func TestReservoirFillsThenSamples(t *testing.T) {
+	r := sample.NewReservoir[int](5, sample.WithSource(sample.NewSeededSource(1)))
+	for i := 0; i < 5; i++ {
+		if !r.Offer(i) {
+			t.Fatalf("item %d should be kept while filling", i)
+		}
+	}
+	for i := 5; i < 10000; i++ {
+		r.Offer(i)
+	}
+	snap := r.Snapshot()
+	if len(snap) != 5 || r.Seen() != 10000 {
+		t.Fatalf("snapshot = %v, seen = %d", snap, r.Seen())
+	}
+	late := 0
+	for _, v := range snap {
+		if v >= 5 {
+			late++
+		}
+	}
+	if late == 0 {
+		t.Error("no later items replaced the initial fill")
+	}
+
+	items, seen := r.Reset()
+	if len(items) != 5 || seen != 10000 || len(r.Snapshot()) != 0 || r.Seen() != 0 {
+		t.Errorf("Reset() = %v, %d", items, seen)
+	}
+	r.Offer(42)
+	if snap := r.Snapshot(); len(snap) != 1 || snap[0] != 42 {
+		t.Errorf("after reset snapshot = %v", snap)
+	}
+}
+
+func TestReservoirUniform(t *testing.T) {
+	const (
+		n      = 100
+		size   = 10
+		trials = 4000
+	)
+	src := sample.NewSeededSource(7)
+	counts := make([]int, n)
+	for trial := 0; trial < trials; trial++ {
+		r := sample.NewReservoir[int](size, sample.WithSource(src))
+		for i := 0; i < n; i++ {
+			r.Offer(i)
+		}
+		for _, v := range r.Snapshot() {
+			counts[v]++
+		}
+	}
+	// 每条记录被保留的概率为 size/n
+	want := float64(trials) * size / n
+	for i, c := range counts {
+		if math.Abs(float64(c)-want) > want*0.25 {
+			t.Errorf("item %d kept %d times, want about %.0f", i, c, want)
+		}
+	}
+}
+
+func TestReservoirZeroSize(t *testing.T) {
+	r := sample.NewReservoir[string](0)
+	if r.Offer("x") || len(r.Snapshot()) != 0 || r.Seen() != 1 {
+		t.Error("zero-size reservoir should keep nothing")
+	}
+}
+
+func TestPrioritySamplerKeepsHeavyItems(t *testing.T) {
+	p := sample.NewPrioritySampler[string](3, sample.WithSource(sample.NewSeededSource(3)))
+	for i := 0; i < 1000; i++ {
+		p.Offer("fast", 1)
+	}
+	p.Offer("slow", 1e6)
+	p.Offer("ignored", 0)
+	snap := p.Snapshot()
+	if len(snap) != 3 || snap[0].Item != "slow" || snap[0].Estimate != 1e6 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	for i := 1; i < len(snap); i++ {
+		if snap[i].Estimate < snap[i].Weight {
+			t.Errorf("estimate %v below weight %v", snap[i].Estimate, snap[i].Weight)
+		}
+	}
+	if seen, total := p.Seen(); seen != 1001 || total != 1e6+1000 {
+		t.Errorf("Seen() = %d, %v", seen, total)
+	}
+}
+
+func TestPrioritySamplerUnbiased(t *testing.T) {
+	const trials = 2000
+	src := sample.NewSeededSource(11)
+	var sum, total float64
+	for trial := 0; trial < trials; trial++ {
+		p := sample.NewPrioritySampler[int](20, sample.WithSource(src))
+		for i := 1; i <= 200; i++ {
+			p.Offer(i, float64(i%17+1))
+		}
+		for _, w := range p.Snapshot() {
+			sum += w.Estimate
+		}
+		_, total = p.Seen()
+	}
+	if mean := sum / trials; math.Abs(mean-total)/total > 0.05 {
+		t.Errorf("mean estimate %.1f, want about %.1f", mean, total)
+	}
+}
+
+func TestPrioritySamplerFewItemsExact(t *testing.T) {
+	p := sample.NewPrioritySampler[int](10)
+	p.Offer(1, 2.5)
+	p.Offer(2, 4)
+	snap := p.Reset()
+	// 记录数不超过容量时全部保留，估计值等于原始权重
+	if len(snap) != 2 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	for _, w := range snap {
+		if w.Estimate != w.Weight {
+			t.Errorf("estimate %v != weight %v", w.Estimate, w.Weight)
+		}
+	}
+	if len(p.Snapshot()) != 0 {
+		t.Error("Reset() should clear samples")
+	}
+}
+
+func TestSamplersConcurrentSnapshot(t *testing.T) {
+	r := sample.NewReservoir[int](16)
+	p := sample.NewPrioritySampler[int](16)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				r.Offer(g*10000 + i)
+				p.Offer(i, float64(i+1))
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if len(r.Snapshot()) > 16 || len(p.Snapshot()) > 16 {
+					t.Error("snapshot exceeds capacity")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if r.Seen() != 8000 {
+		t.Errorf("Seen() = %d", r.Seen())
+	}
+}
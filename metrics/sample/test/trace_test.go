@@ -0,0 +1,64 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/omeyang/gokit/metrics/sample"
+)
+
+// traceID 生成一个固定的 32 位十六进制 trace ID
+func traceID(i int) string {
+	return fmt.Sprintf("%016x%016x", i, uint64(i)*0x9e3779b97f4a7c15)
+}
+
+func TestTraceSamplerDeterministic(t *testing.T) {
+	s := sample.NewTraceSampler(0.3)
+	for i := 0; i < 100; i++ {
+		id := traceID(i)
+		first := s.SampleTrace(id, false)
+		for j := 0; j < 10; j++ {
+			if s.SampleTrace(id, false) != first {
+				t.Fatalf("trace %s sampled inconsistently", id)
+			}
+		}
+	}
+}
+
+func TestTraceSamplerRate(t *testing.T) {
+	s := sample.NewTraceSampler(0.25)
+	kept := 0
+	const n = 20000
+	for i := 0; i < n; i++ {
+		if s.SampleTrace(traceID(i), false) {
+			kept++
+		}
+	}
+	if ratio := float64(kept) / n; ratio < 0.22 || ratio > 0.28 {
+		t.Errorf("kept ratio = %.3f, want about 0.25", ratio)
+	}
+}
+
+func TestTraceSamplerBounds(t *testing.T) {
+	none := sample.NewTraceSampler(0)
+	all := sample.NewTraceSampler(1)
+	for i := 0; i < 100; i++ {
+		if none.SampleTrace(traceID(i), false) {
+			t.Fatal("rate 0 should drop unsampled traces")
+		}
+		if !none.SampleTrace(traceID(i), true) {
+			t.Fatal("sampled spans should always be kept")
+		}
+		if !all.SampleTrace(traceID(i), false) {
+			t.Fatal("rate 1 should keep every trace")
+		}
+	}
+	if none.SampleTrace("", false) || none.SampleTrace("not-a-trace-id-not-a-trace-idxx", false) {
+		t.Error("invalid trace IDs should fall back to rate sampling")
+	}
+
+	none.SetRate(0.5)
+	if got := none.GetRate(); got != 0.5 {
+		t.Errorf("GetRate() = %v, want 0.5", got)
+	}
+}
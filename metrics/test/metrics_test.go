@@ -0,0 +1,252 @@
+package test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/metrics"
+)
+
+// text 返回 Registry 的 Prometheus 文本输出
+func text(t *testing.T, r *metrics.Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	return b.String()
+}
+
+// expectLines 检查输出中包含所有指定的行
+func expectLines(t *testing.T, out string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("missing line %q in output:\n%s", line, out)
+		}
+	}
+}
+
+func TestCounterAndGauge(t *testing.T) {
+	r := metrics.NewRegistry()
+	requests := r.Counter(metrics.Opts{Name: "http_requests_total", Help: "Total requests.", Labels: []string{"method", "code"}})
+	requests.With("GET", "200").Inc()
+	requests.With("GET", "200").Add(2)
+	requests.With("POST", "500").Inc()
+
+	inflight := r.Gauge(metrics.Opts{Name: "inflight", Help: "In-flight requests.\nSecond line"})
+	inflight.Set(5)
+	inflight.Inc()
+	inflight.Dec()
+	inflight.Add(-2.5)
+
+	// 重复创建同名指标返回同一个指标
+	r.Counter(metrics.Opts{Name: "http_requests_total", Labels: []string{"method", "code"}}).With("GET", "200").Inc()
+
+	expectLines(t, text(t, r),
+		"# HELP http_requests_total Total requests.",
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",code="200"} 4`,
+		`http_requests_total{method="POST",code="500"} 1`,
+		`# HELP inflight In-flight requests.\nSecond line`,
+		"# TYPE inflight gauge",
+		"inflight 2.5",
+	)
+}
+
+func TestHistogram(t *testing.T) {
+	r := metrics.NewRegistry()
+	h := r.Histogram(metrics.HistogramOpts{
+		Opts:    metrics.Opts{Name: "latency_seconds", Labels: []string{"op"}},
+		Buckets: []float64{0.1, 0.5, 1},
+	})
+	for _, v := range []float64{0.05, 0.1, 0.3, 0.7, 3} {
+		h.With("read").Observe(v)
+	}
+	expectLines(t, text(t, r),
+		"# TYPE latency_seconds histogram",
+		`latency_seconds_bucket{op="read",le="0.1"} 2`,
+		`latency_seconds_bucket{op="read",le="0.5"} 3`,
+		`latency_seconds_bucket{op="read",le="1"} 4`,
+		`latency_seconds_bucket{op="read",le="+Inf"} 5`,
+		`latency_seconds_sum{op="read"} 4.15`,
+		`latency_seconds_count{op="read"} 5`,
+	)
+}
+
+func TestSummary(t *testing.T) {
+	var now atomic.Int64
+	now.Store(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	clock := func() time.Time { return time.Unix(0, now.Load()) }
+
+	r := metrics.NewRegistry(metrics.WithClock(clock))
+	s := r.Summary(metrics.SummaryOpts{
+		Opts:      metrics.Opts{Name: "payload_bytes"},
+		Quantiles: []float64{0.5, 0.99},
+		MaxAge:    time.Minute,
+	})
+	for i := 1; i <= 100; i++ {
+		s.Observe(float64(i))
+	}
+	expectLines(t, text(t, r),
+		"# TYPE payload_bytes summary",
+		`payload_bytes{quantile="0.5"} 51`,
+		`payload_bytes{quantile="0.99"} 99`,
+		"payload_bytes_sum 5050",
+		"payload_bytes_count 100",
+	)
+
+	// 超过滑动窗口后分位数为 NaN，累计值保留
+	now.Add(int64(2 * time.Minute))
+	expectLines(t, text(t, r),
+		`payload_bytes{quantile="0.5"} NaN`,
+		"payload_bytes_count 100",
+	)
+}
+
+func TestLabelEscapingAndHandler(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Counter(metrics.Opts{Name: "events_total", Labels: []string{"path"}}).With("a\"b\\c\nd").Inc()
+	r.Counter(metrics.Opts{Name: "unused_total", Labels: []string{"x"}})
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	if ct := rec.Header().Get("Content-Type"); ct != metrics.ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	expectLines(t, string(body), `events_total{path="a\"b\\c\nd"} 1`)
+	if strings.Contains(string(body), "unused_total") {
+		t.Error("metrics without series should not be written")
+	}
+}
+
+func TestRegistrationConflicts(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Counter(metrics.Opts{Name: "dup"})
+	r.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "dup_seconds"}, Buckets: []float64{1, 2}})
+	r.Summary(metrics.SummaryOpts{Opts: metrics.Opts{Name: "dup_bytes"}, Quantiles: []float64{0.5}})
+	cases := map[string]func(){
+		"kind":         func() { r.Gauge(metrics.Opts{Name: "dup"}) },
+		"labels":       func() { r.Counter(metrics.Opts{Name: "dup", Labels: []string{"a"}}) },
+		"name":         func() { r.Counter(metrics.Opts{Name: "bad-name"}) },
+		"le":           func() { r.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "h", Labels: []string{"le"}}}) },
+		"arity":        func() { r.Counter(metrics.Opts{Name: "c", Labels: []string{"a"}}).With("x", "y") },
+		"needs with":   func() { r.Counter(metrics.Opts{Name: "c2", Labels: []string{"a"}}).Inc() },
+		"negative":     func() { r.Counter(metrics.Opts{Name: "c3"}).Add(-1) },
+		"unsorted":     func() { r.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "h2"}, Buckets: []float64{2, 1}}) },
+		"bad quantile": func() { r.Summary(metrics.SummaryOpts{Opts: metrics.Opts{Name: "s"}, Quantiles: []float64{2}}) },
+		"buckets": func() {
+			r.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "dup_seconds"}, Buckets: []float64{1, 3}})
+		},
+		"quantiles": func() {
+			r.Summary(metrics.SummaryOpts{Opts: metrics.Opts{Name: "dup_bytes"}, Quantiles: []float64{0.9}})
+		},
+	}
+	for name, fn := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
+
+func TestReregisterSameShape(t *testing.T) {
+	r := metrics.NewRegistry()
+	opts := metrics.HistogramOpts{Opts: metrics.Opts{Name: "same_seconds"}, Buckets: []float64{1, 2}}
+	r.Histogram(opts).Observe(1)
+	r.Histogram(opts).Observe(3)
+	expectLines(t, text(t, r), `same_seconds_bucket{le="+Inf"} 2`, "same_seconds_count 2")
+}
+
+func TestHistogramScrapeConsistent(t *testing.T) {
+	r := metrics.NewRegistry()
+	h := r.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "scrape_seconds"}, Buckets: []float64{1}})
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					h.Observe(2)
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	for i := 0; i < 200; i++ {
+		var inf, count string
+		for _, line := range strings.Split(text(t, r), "\n") {
+			if v, ok := strings.CutPrefix(line, `scrape_seconds_bucket{le="+Inf"} `); ok {
+				inf = v
+			}
+			if v, ok := strings.CutPrefix(line, "scrape_seconds_count "); ok {
+				count = v
+			}
+		}
+		if inf != count {
+			t.Fatalf("+Inf bucket %s != count %s", inf, count)
+		}
+	}
+}
+
+func TestDefaultProvider(t *testing.T) {
+	if metrics.Default() != metrics.Provider(metrics.DefaultRegistry()) {
+		t.Fatal("default provider should be the default registry")
+	}
+	metrics.SetDefault(metrics.Nop)
+	defer metrics.SetDefault(nil)
+	c := metrics.Default().Counter(metrics.Opts{Name: "nop_total", Labels: []string{"a"}})
+	c.With("x").Inc()
+	metrics.Default().Gauge(metrics.Opts{Name: "nop"}).Set(1)
+	metrics.Default().Histogram(metrics.HistogramOpts{}).With().Observe(1)
+	metrics.Default().Summary(metrics.SummaryOpts{}).Observe(1)
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.Counter(metrics.Opts{Name: "ops_total", Labels: []string{"worker"}})
+	h := r.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "op_seconds"}})
+	s := r.Summary(metrics.SummaryOpts{Opts: metrics.Opts{Name: "op_size"}})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			worker := c.With(string(rune('a' + g%2)))
+			for i := 0; i < 1000; i++ {
+				worker.Inc()
+				h.Observe(0.01)
+				s.Observe(float64(i))
+				if i%100 == 0 {
+					text(t, r)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	expectLines(t, text(t, r),
+		`ops_total{worker="a"} 4000`,
+		`ops_total{worker="b"} 4000`,
+		"op_seconds_count 8000",
+		"op_size_count 8000",
+	)
+}
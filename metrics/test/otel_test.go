@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/omeyang/gokit/metrics"
+)
+
+// collect 从 ManualReader 读取指标，按名称返回
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Metrics {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	out := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		if sm.Scope.Name != "github.com/omeyang/gokit/metrics" {
+			t.Errorf("scope = %q", sm.Scope.Name)
+		}
+		for _, m := range sm.Metrics {
+			out[m.Name] = m
+		}
+	}
+	return out
+}
+
+func newOTelProvider() (*metrics.OTelProvider, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	return metrics.NewOTelProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))), reader
+}
+
+func TestOTelCounterAndGauge(t *testing.T) {
+	p, reader := newOTelProvider()
+	c := p.Counter(metrics.Opts{Name: "requests_total", Help: "Requests.", Labels: []string{"code"}})
+	c.With("200").Add(2)
+	c.With("500").Inc()
+	p.Counter(metrics.Opts{Name: "requests_total", Labels: []string{"code"}}).With("200").Inc()
+
+	g := p.Gauge(metrics.Opts{Name: "temperature"})
+	g.Set(20)
+	g.Inc()
+	g.Dec()
+	g.Add(-5)
+
+	got := collect(t, reader)
+	sum, ok := got["requests_total"].Data.(metricdata.Sum[float64])
+	if !ok || !sum.IsMonotonic {
+		t.Fatalf("requests_total data = %#v, want monotonic sum", got["requests_total"].Data)
+	}
+	if got["requests_total"].Description != "Requests." {
+		t.Errorf("description = %q", got["requests_total"].Description)
+	}
+	want := map[string]float64{"200": 3, "500": 1}
+	for _, dp := range sum.DataPoints {
+		code, _ := dp.Attributes.Value(attribute.Key("code"))
+		if dp.Value != want[code.AsString()] {
+			t.Errorf("requests_total{code=%q} = %v, want %v", code.AsString(), dp.Value, want[code.AsString()])
+		}
+		delete(want, code.AsString())
+	}
+	if len(want) != 0 {
+		t.Errorf("missing data points %v", want)
+	}
+
+	gauge, ok := got["temperature"].Data.(metricdata.Gauge[float64])
+	if !ok || len(gauge.DataPoints) != 1 || gauge.DataPoints[0].Value != 15 {
+		t.Errorf("temperature data = %#v, want 15", got["temperature"].Data)
+	}
+}
+
+func TestOTelHistogramAndSummary(t *testing.T) {
+	p, reader := newOTelProvider()
+	h := p.Histogram(metrics.HistogramOpts{
+		Opts:    metrics.Opts{Name: "latency_seconds", Labels: []string{"op"}},
+		Buckets: []float64{0.1, 1},
+	})
+	for _, v := range []float64{0.05, 0.5, 2} {
+		h.With("get").Observe(v)
+	}
+	s := p.Summary(metrics.SummaryOpts{Opts: metrics.Opts{Name: "size_bytes"}})
+	s.Observe(10)
+	s.Observe(30)
+
+	got := collect(t, reader)
+	hist, ok := got["latency_seconds"].Data.(metricdata.Histogram[float64])
+	if !ok || len(hist.DataPoints) != 1 {
+		t.Fatalf("latency_seconds data = %#v", got["latency_seconds"].Data)
+	}
+	dp := hist.DataPoints[0]
+	if dp.Count != 3 || dp.Sum != 2.55 {
+		t.Errorf("count = %d, sum = %v, want 3, 2.55", dp.Count, dp.Sum)
+	}
+	if len(dp.Bounds) != 2 || dp.Bounds[0] != 0.1 || dp.Bounds[1] != 1 {
+		t.Errorf("bounds = %v, want [0.1 1]", dp.Bounds)
+	}
+	if want := []uint64{1, 1, 1}; len(dp.BucketCounts) != 3 || dp.BucketCounts[0] != want[0] ||
+		dp.BucketCounts[1] != want[1] || dp.BucketCounts[2] != want[2] {
+		t.Errorf("bucket counts = %v, want %v", dp.BucketCounts, want)
+	}
+
+	summary, ok := got["size_bytes"].Data.(metricdata.Histogram[float64])
+	if !ok || len(summary.DataPoints) != 1 || summary.DataPoints[0].Count != 2 || summary.DataPoints[0].Sum != 40 {
+		t.Errorf("size_bytes data = %#v, want histogram with count 2 sum 40", got["size_bytes"].Data)
+	}
+}
+
+func TestOTelDefaultProvider(t *testing.T) {
+	p, reader := newOTelProvider()
+	metrics.SetDefault(p)
+	defer metrics.SetDefault(nil)
+
+	metrics.Default().Counter(metrics.Opts{Name: "gokit_test_total"}).Inc()
+	if _, ok := collect(t, reader)["gokit_test_total"]; !ok {
+		t.Error("counter created through Default was not exported")
+	}
+}
+
+func TestOTelRegistrationConflicts(t *testing.T) {
+	p, _ := newOTelProvider()
+	p.Counter(metrics.Opts{Name: "dup_total", Labels: []string{"a"}})
+	p.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "dup_seconds"}, Buckets: []float64{1, 2}})
+	for name, fn := range map[string]func(){
+		"kind":       func() { p.Gauge(metrics.Opts{Name: "dup_total", Labels: []string{"a"}}) },
+		"labels":     func() { p.Counter(metrics.Opts{Name: "dup_total", Labels: []string{"b"}}) },
+		"unbound":    func() { p.Counter(metrics.Opts{Name: "dup_total", Labels: []string{"a"}}).Inc() },
+		"arity":      func() { p.Counter(metrics.Opts{Name: "dup_total", Labels: []string{"a"}}).With("x", "y") },
+		"negative":   func() { p.Counter(metrics.Opts{Name: "neg_total"}).Add(-1) },
+		"gauge":      func() { p.Gauge(metrics.Opts{Name: "g", Labels: []string{"a"}}).Set(1) },
+		"bad name":   func() { p.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "1bad"}}) },
+		"bad bucket": func() { p.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "h"}, Buckets: []float64{2, 1}}) },
+		"buckets": func() {
+			p.Histogram(metrics.HistogramOpts{Opts: metrics.Opts{Name: "dup_seconds"}, Buckets: []float64{1, 3}})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/metrics"
+)
+
+// value 返回 Prometheus 文本输出中指定序列的值
+func value(t *testing.T, out, series string) float64 {
+	t.Helper()
+	for _, line := range strings.Split(out, "\n") {
+		if v, ok := strings.CutPrefix(line, series+" "); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				t.Fatalf("parse %q: %v", line, err)
+			}
+			return f
+		}
+	}
+	t.Fatalf("series %s not found in output:\n%s", series, out)
+	return 0
+}
+
+func TestRuntimeCollector(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := metrics.NewRuntimeCollector(r)
+	if err := c.Collect(); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	runtime.GC()
+	if err := c.Collect(); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	out := text(t, r)
+	if v := value(t, out, "go_goroutines"); v < 1 {
+		t.Errorf("go_goroutines = %v", v)
+	}
+	if v := value(t, out, "go_gc_cycles_total"); v < 1 {
+		t.Errorf("go_gc_cycles_total = %v, want at least one cycle", v)
+	}
+	if v := value(t, out, "go_heap_objects_bytes"); v <= 0 {
+		t.Errorf("go_heap_objects_bytes = %v", v)
+	}
+	if v := value(t, out, "go_gc_pauses_total"); v < 1 {
+		t.Errorf("go_gc_pauses_total = %v", v)
+	}
+	value(t, out, `go_sched_latency_seconds{quantile="0.99"}`)
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	if v := value(t, out, "process_resident_memory_bytes"); v <= 0 {
+		t.Errorf("process_resident_memory_bytes = %v", v)
+	}
+	if v := value(t, out, "process_open_fds"); v < 1 {
+		t.Errorf("process_open_fds = %v", v)
+	}
+	if v := value(t, out, "process_cpu_seconds_total"); v < 0 {
+		t.Errorf("process_cpu_seconds_total = %v", v)
+	}
+	start := value(t, out, "process_start_time_seconds")
+	if now := float64(time.Now().Unix()); start <= 0 || start > now+1 {
+		t.Errorf("process_start_time_seconds = %v, now %v", start, now)
+	}
+}
+
+func TestRuntimeCollectorStartStop(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := metrics.NewRuntimeCollector(r)
+	c.Start(context.Background(), time.Millisecond)
+	// Start 会立即采集一次
+	if v := value(t, text(t, r), "go_goroutines"); v < 1 {
+		t.Errorf("go_goroutines = %v", v)
+	}
+	time.Sleep(10 * time.Millisecond)
+	c.Stop()
+	c.Stop()
+}
+
+func TestRuntimeCollectorStartTwice(t *testing.T) {
+	c := metrics.NewRuntimeCollector(metrics.NewRegistry())
+	before := runtime.NumGoroutine()
+	c.Start(context.Background(), time.Hour)
+	c.Start(context.Background(), time.Hour)
+	if n := runtime.NumGoroutine() - before; n != 1 {
+		t.Errorf("Start twice started %d goroutines, want 1", n)
+	}
+	c.Stop()
+}
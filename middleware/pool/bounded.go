@@ -0,0 +1,342 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/omeyang/gokit/metrics"
+)
+
+var (
+	// ErrPoolExhausted 对象总数已达上限且未开启等待
+	ErrPoolExhausted = errors.New("pool: exhausted")
+	// ErrPoolClosed 对象池已关闭
+	ErrPoolClosed = errors.New("pool: closed")
+)
+
+// BoundedPoolStats 是 BoundedPool 的统计信息
+type BoundedPoolStats struct {
+	Hits      uint64 // 从空闲对象中获取的次数
+	Misses    uint64 // 获取时没有空闲对象的次数
+	Created   uint64 // 新建对象的次数
+	Evicted   uint64 // 因空闲超时或空闲数超过上限被丢弃的对象数
+	Discarded uint64 // 未通过 ManagedFactory 校验或通过 Discard 丢弃的对象数
+	Idle      int    // 当前空闲对象数
+	Active    int    // 当前借出的对象数
+	Waiting   int    // 当前等待获取对象的调用数
+}
+
+// BoundedOption 定义 BoundedPool 的可选配置
+type BoundedOption func(*boundedConfig)
+
+type boundedConfig struct {
+	maxTotal    int
+	block       bool
+	idleTimeout time.Duration
+	now         func() time.Time
+}
+
+// WithMaxTotal 设置对象总数（空闲与借出之和）的上限，不大于 0 时不限制
+func WithMaxTotal(n int) BoundedOption {
+	return func(c *boundedConfig) {
+		c.maxTotal = n
+	}
+}
+
+// WithBlock 设置对象总数达到上限时 Get 等待归还，直到 ctx 结束；默认立即返回 ErrPoolExhausted
+func WithBlock() BoundedOption {
+	return func(c *boundedConfig) {
+		c.block = true
+	}
+}
+
+// WithIdleTimeout 设置空闲超时，空闲超过 d 的对象会被丢弃；不大于 0 时不过期
+func WithIdleTimeout(d time.Duration) BoundedOption {
+	return func(c *boundedConfig) {
+		c.idleTimeout = d
+	}
+}
+
+// WithClock 设置判断空闲超时使用的时钟，默认使用 time.Now
+func WithClock(now func() time.Time) BoundedOption {
+	return func(c *boundedConfig) {
+		if now != nil {
+			c.now = now
+		}
+	}
+}
+
+// idleItem 是空闲对象及其归还时间
+type idleItem[T any] struct {
+	item  T
+	since time.Time
+}
+
+// waitResult 是等待者收到的结果：转交的对象、创建新对象的名额或关闭错误
+type waitResult[T any] struct {
+	item   T
+	handed bool // 为 true 时 item 是归还的对象，否则获得了创建新对象的名额
+	err    error
+}
+
+// BoundedPool 是限制空闲数与总数的泛型对象池
+// 与基于 sync.Pool 的 BatchPool 不同，空闲对象不会被 GC 回收，超过 maxIdle 的归还对象与空闲超时的对象被丢弃；
+// 设置 WithMaxTotal 后借出与空闲对象的总数不会超过上限，配合 WithBlock 时 Get 按先来先得的顺序等待归还。
+// 工厂实现了 ManagedFactory 时，未通过校验的归还对象不会放回池中，所有被丢弃的对象都会调用 Destroy
+type BoundedPool[T any] struct {
+	factory ObjectFactory[T]
+	maxIdle int
+	cfg     boundedConfig
+
+	mu      sync.Mutex
+	idle    []idleItem[T] // 按归还时间排序，最早归还的在前，获取时取最近归还的
+	total   int
+	waiters []chan waitResult[T]
+	closed  bool
+	stats   BoundedPoolStats
+
+	stopCh chan struct{}
+	gets   metrics.Counter
+	misses metrics.Counter
+}
+
+// NewBoundedPool 创建最多保留 maxIdle 个空闲对象的对象池，maxIdle 小于 0 时按 0 处理
+// 设置了空闲超时时会启动后台 goroutine 定期清理，需要调用 Close 停止
+func NewBoundedPool[T any](factory ObjectFactory[T], maxIdle int, opts ...BoundedOption) *BoundedPool[T] {
+	cfg := boundedConfig{now: time.Now}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	p := &BoundedPool[T]{
+		factory: factory,
+		maxIdle: max(maxIdle, 0),
+		cfg:     cfg,
+		stopCh:  make(chan struct{}),
+	}
+	p.gets, p.misses = poolCounters[T]()
+	if cfg.idleTimeout > 0 {
+		go p.evictLoop()
+	}
+	return p
+}
+
+// Get 获取对象，优先使用最近归还的空闲对象，没有空闲对象且未达到总数上限时新建
+func (p *BoundedPool[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	p.gets.Inc()
+	p.Evict()
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return zero, ErrPoolClosed
+	}
+	if n := len(p.idle); n > 0 {
+		item := p.idle[n-1].item
+		p.idle[n-1] = idleItem[T]{}
+		p.idle = p.idle[:n-1]
+		p.stats.Hits++
+		p.mu.Unlock()
+		return item, nil
+	}
+	p.stats.Misses++
+	if p.cfg.maxTotal <= 0 || p.total < p.cfg.maxTotal {
+		p.total++
+		return p.createLocked(), nil
+	}
+	if !p.cfg.block {
+		p.mu.Unlock()
+		return zero, ErrPoolExhausted
+	}
+
+	ch := make(chan waitResult[T], 1)
+	p.waiters = append(p.waiters, ch)
+	p.mu.Unlock()
+	select {
+	case r := <-ch:
+		return p.received(r)
+	case <-ctx.Done():
+		p.mu.Lock()
+		if p.removeWaiterLocked(ch) {
+			p.mu.Unlock()
+			return zero, ctx.Err()
+		}
+		p.mu.Unlock()
+		// 取消与转交同时发生，把收到的对象或名额还给对象池
+		if r := <-ch; r.err == nil {
+			if r.handed {
+				p.Put(r.item)
+			} else {
+				p.mu.Lock()
+				p.releaseLocked()
+				p.mu.Unlock()
+			}
+		}
+		return zero, ctx.Err()
+	}
+}
+
+// received 处理等待者收到的结果
+func (p *BoundedPool[T]) received(r waitResult[T]) (T, error) {
+	if r.err != nil || r.handed {
+		return r.item, r.err
+	}
+	p.mu.Lock()
+	return p.createLocked(), nil
+}
+
+// createLocked 新建对象，调用方需持有锁并已计入总数，返回前释放锁
+func (p *BoundedPool[T]) createLocked() T {
+	p.stats.Created++
+	p.mu.Unlock()
+	p.misses.Inc()
+	return p.factory.New()
+}
+
+// Put 重置并归还对象，有等待者时直接转交，空闲对象数已达上限或对象池已关闭时丢弃
+func (p *BoundedPool[T]) Put(item T) {
+	if !validate(p.factory, item) {
+		p.Discard(item)
+		return
+	}
+	p.factory.Reset(item)
+	p.mu.Lock()
+	if p.closed {
+		p.total--
+		p.mu.Unlock()
+		destroy(p.factory, item)
+		return
+	}
+	if len(p.waiters) > 0 {
+		p.popWaiterLocked() <- waitResult[T]{item: item, handed: true}
+		p.mu.Unlock()
+		return
+	}
+	if len(p.idle) >= p.maxIdle {
+		p.stats.Evicted++
+		p.releaseLocked()
+		p.mu.Unlock()
+		destroy(p.factory, item)
+		return
+	}
+	p.idle = append(p.idle, idleItem[T]{item: item, since: p.cfg.now()})
+	p.mu.Unlock()
+}
+
+// Discard 销毁借出的对象而不归还，例如对象已经损坏，释放出的名额可供新建对象
+func (p *BoundedPool[T]) Discard(item T) {
+	p.mu.Lock()
+	p.stats.Discarded++
+	p.releaseLocked()
+	p.mu.Unlock()
+	destroy(p.factory, item)
+}
+
+// Evict 立即丢弃空闲超时的对象，返回丢弃的数量
+func (p *BoundedPool[T]) Evict() int {
+	p.mu.Lock()
+	expired := p.evictLocked(p.cfg.now())
+	p.mu.Unlock()
+	for _, item := range expired {
+		destroy(p.factory, item.item)
+	}
+	return len(expired)
+}
+
+// Stats 返回统计信息
+func (p *BoundedPool[T]) Stats() BoundedPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Idle = len(p.idle)
+	stats.Active = p.total - len(p.idle)
+	stats.Waiting = len(p.waiters)
+	return stats
+}
+
+// Close 关闭对象池，丢弃空闲对象，正在等待的 Get 返回 ErrPoolClosed；之后归还的对象直接丢弃
+func (p *BoundedPool[T]) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stopCh)
+	idle := p.idle
+	p.total -= len(idle)
+	p.idle = nil
+	for len(p.waiters) > 0 {
+		p.popWaiterLocked() <- waitResult[T]{err: ErrPoolClosed}
+	}
+	p.mu.Unlock()
+	for _, item := range idle {
+		destroy(p.factory, item.item)
+	}
+}
+
+// evictLoop 定期清理空闲超时的对象
+func (p *BoundedPool[T]) evictLoop() {
+	ticker := time.NewTicker(max(p.cfg.idleTimeout/2, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			p.Evict()
+		}
+	}
+}
+
+// evictLocked 从空闲对象中移除空闲超时的对象并返回，由调用方在释放锁后销毁，调用方需持有锁
+func (p *BoundedPool[T]) evictLocked(now time.Time) []idleItem[T] {
+	if p.cfg.idleTimeout <= 0 || p.closed {
+		return nil
+	}
+	n := 0
+	for n < len(p.idle) && now.Sub(p.idle[n].since) >= p.cfg.idleTimeout {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	expired := slices.Clone(p.idle[:n])
+	clear(p.idle[:n])
+	p.idle = p.idle[n:]
+	p.stats.Evicted += uint64(n)
+	for i := 0; i < n; i++ {
+		p.releaseLocked()
+	}
+	return expired
+}
+
+// releaseLocked 减少对象总数，有等待者时把空出的名额交给最早的等待者，调用方需持有锁
+func (p *BoundedPool[T]) releaseLocked() {
+	p.total--
+	if len(p.waiters) > 0 && !p.closed {
+		p.total++
+		p.popWaiterLocked() <- waitResult[T]{}
+	}
+}
+
+// popWaiterLocked 取出最早的等待者，调用方需持有锁
+func (p *BoundedPool[T]) popWaiterLocked() chan waitResult[T] {
+	ch := p.waiters[0]
+	p.waiters[0] = nil
+	p.waiters = p.waiters[1:]
+	return ch
+}
+
+// removeWaiterLocked 移除等待者，返回是否仍在等待队列中，调用方需持有锁
+func (p *BoundedPool[T]) removeWaiterLocked(ch chan waitResult[T]) bool {
+	for i, w := range p.waiters {
+		if w == ch {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package pool
+
+import (
+	"io"
+	"math/bits"
+	"slices"
+	"sync"
+	"unicode/utf8"
+)
+
+// 默认的字节缓冲区大小范围
+const (
+	DefaultMinBufferSize = 64
+	DefaultMaxBufferSize = 16 << 20
+)
+
+// DefaultByteBufferPool 是 gokit 各模块共用的字节缓冲池，大小范围为 [DefaultMinBufferSize, DefaultMaxBufferSize]
+var DefaultByteBufferPool = NewByteBufferPool(DefaultMinBufferSize, DefaultMaxBufferSize)
+
+// ByteBufferPool 是按 2 的幂划分大小级别的字节缓冲池
+// Get(n) 从不小于 n 的最小级别中取出缓冲区，归还时按容量放回对应级别；
+// 超过最大级别的请求直接分配且不回收，容量不是 2 的幂的缓冲区也不回收，使池中单个缓冲区的大小有上限。
+// 为了避免清零大缓冲区的开销，取出的缓冲区可能含有之前的数据，保存敏感数据的缓冲区应使用 PutZeroed 归还
+type ByteBufferPool struct {
+	minShift int
+	maxShift int
+	classes  []sync.Pool // 第 i 级保存容量为 1<<(minShift+i) 的 *[]byte
+	holders  sync.Pool   // 复用 *[]byte，使归还缓冲区不产生分配
+	buffers  sync.Pool   // 复用 *ByteBuffer
+}
+
+// NewByteBufferPool 创建字节缓冲池，minSize 与 maxSize 向上取整为 2 的幂，minSize 不大于 0 时使用 DefaultMinBufferSize
+func NewByteBufferPool(minSize, maxSize int) *ByteBufferPool {
+	if minSize <= 0 {
+		minSize = DefaultMinBufferSize
+	}
+	maxSize = max(maxSize, minSize)
+	p := &ByteBufferPool{
+		minShift: ceilShift(minSize),
+		maxShift: ceilShift(maxSize),
+	}
+	p.classes = make([]sync.Pool, p.maxShift-p.minShift+1)
+	return p
+}
+
+// Get 返回长度为 n、容量为不小于 n 的 2 的幂的缓冲区，内容未清零
+func (p *ByteBufferPool) Get(n int) []byte {
+	n = max(n, 0)
+	shift := max(ceilShift(n), p.minShift)
+	if shift > p.maxShift {
+		return make([]byte, n)
+	}
+	if h, ok := p.classes[shift-p.minShift].Get().(*[]byte); ok {
+		b := *h
+		*h = nil
+		p.holders.Put(h)
+		return b[:n]
+	}
+	return make([]byte, n, 1<<shift)
+}
+
+// Put 归还缓冲区，容量不属于任何级别的缓冲区被丢弃；归还后不能再使用 b
+func (p *ByteBufferPool) Put(b []byte) {
+	c := cap(b)
+	if c == 0 || c&(c-1) != 0 {
+		return
+	}
+	shift := bits.TrailingZeros(uint(c))
+	if shift < p.minShift || shift > p.maxShift {
+		return
+	}
+	h, ok := p.holders.Get().(*[]byte)
+	if !ok {
+		h = new([]byte)
+	}
+	*h = b[:c]
+	p.classes[shift-p.minShift].Put(h)
+}
+
+// PutZeroed 清零整个缓冲区后归还，用于保存了密钥等敏感数据的缓冲区
+func (p *ByteBufferPool) PutZeroed(b []byte) {
+	clear(b[:cap(b)])
+	p.Put(b)
+}
+
+// GetBuffer 返回一个空的 ByteBuffer，使用完后调用 Release 归还
+func (p *ByteBufferPool) GetBuffer() *ByteBuffer {
+	if b, ok := p.buffers.Get().(*ByteBuffer); ok {
+		return b
+	}
+	return &ByteBuffer{pool: p}
+}
+
+// ceilShift 返回不小于 n 的最小的 2 的幂的指数
+func ceilShift(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len(uint(n - 1))
+}
+
+// ByteBuffer 是从 ByteBufferPool 分配内存的可增长缓冲区，实现了 io.Writer、io.ByteWriter、io.StringWriter 与 io.WriterTo
+// 扩容时从缓冲池取出更大级别的缓冲区并归还旧的缓冲区。ByteBuffer 不是并发安全的。
+// 零值的 ByteBuffer 可以直接使用，此时不经过缓冲池分配内存，Release 不做任何事
+type ByteBuffer struct {
+	buf  []byte
+	pool *ByteBufferPool
+}
+
+// Write 追加 p，总是返回 len(p) 与 nil
+func (b *ByteBuffer) Write(p []byte) (int, error) {
+	b.grow(len(p))
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// WriteString 追加 s，总是返回 len(s) 与 nil
+func (b *ByteBuffer) WriteString(s string) (int, error) {
+	b.grow(len(s))
+	b.buf = append(b.buf, s...)
+	return len(s), nil
+}
+
+// WriteByte 追加一个字节，总是返回 nil
+func (b *ByteBuffer) WriteByte(c byte) error {
+	b.grow(1)
+	b.buf = append(b.buf, c)
+	return nil
+}
+
+// WriteRune 追加 r 的 UTF-8 编码，总是返回 nil 错误
+func (b *ByteBuffer) WriteRune(r rune) (int, error) {
+	b.grow(utf8.UTFMax)
+	n := len(b.buf)
+	b.buf = utf8.AppendRune(b.buf, r)
+	return len(b.buf) - n, nil
+}
+
+// WriteTo 将内容写入 w 并清空缓冲区
+func (b *ByteBuffer) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(b.buf)
+	if err == nil && n < len(b.buf) {
+		err = io.ErrShortWrite
+	}
+	b.buf = append(b.buf[:0], b.buf[n:]...)
+	return int64(n), err
+}
+
+// Grow 确保还能写入 n 个字节而不再扩容
+func (b *ByteBuffer) Grow(n int) {
+	b.grow(max(n, 0))
+}
+
+// grow 在剩余容量不足 n 时从缓冲池换用更大的缓冲区
+func (b *ByteBuffer) grow(n int) {
+	if cap(b.buf)-len(b.buf) >= n {
+		return
+	}
+	if b.pool == nil {
+		b.buf = slices.Grow(b.buf, max(n, cap(b.buf)))
+		return
+	}
+	need := len(b.buf) + n
+	buf := b.pool.Get(max(need, 2*cap(b.buf)))[:len(b.buf)]
+	copy(buf, b.buf)
+	b.pool.Put(b.buf)
+	b.buf = buf
+}
+
+// Bytes 返回缓冲区内容，在下一次修改或 Release 之前有效
+func (b *ByteBuffer) Bytes() []byte {
+	return b.buf
+}
+
+// String 返回缓冲区内容的副本
+func (b *ByteBuffer) String() string {
+	return string(b.buf)
+}
+
+// Len 返回已写入的字节数
+func (b *ByteBuffer) Len() int {
+	return len(b.buf)
+}
+
+// Cap 返回当前容量
+func (b *ByteBuffer) Cap() int {
+	return cap(b.buf)
+}
+
+// Reset 清空内容并保留容量
+func (b *ByteBuffer) Reset() {
+	b.buf = b.buf[:0]
+}
+
+// Release 归还底层缓冲区与 ByteBuffer 本身，之后不能再使用 b；零值的 ByteBuffer 调用时不做任何事
+func (b *ByteBuffer) Release() {
+	if b.pool == nil {
+		return
+	}
+	b.pool.Put(b.buf)
+	b.buf = nil
+	b.pool.buffers.Put(b)
+}
+
+// ReleaseZeroed 清零底层缓冲区后归还，用于保存了敏感数据的缓冲区
+func (b *ByteBuffer) ReleaseZeroed() {
+	clear(b.buf[:cap(b.buf)])
+	b.Release()
+}
@@ -1,9 +1,12 @@
 package pool
 
 import (
+	"reflect"
 	"sync"
 
 	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/omeyang/gokit/metrics"
 )
 
 // ObjectFactory 定义对象创建和重置方法的接口
@@ -12,21 +15,52 @@ type ObjectFactory[T any] interface {
 	Reset(T)
 }
 
+// ManagedFactory 在 ObjectFactory 的基础上增加校验与销毁钩子
+// 对象池在归还对象时先调用 Validate，未通过校验的对象（例如已损坏或容量过大）调用 Destroy 后丢弃，不再放回池中
+type ManagedFactory[T any] interface {
+	ObjectFactory[T]
+	// Validate 判断归还的对象能否复用
+	Validate(T) bool
+	// Destroy 释放被丢弃的对象持有的资源
+	Destroy(T)
+}
+
+// validate 对实现了 ManagedFactory 的工厂校验对象，其他工厂总是返回 true
+func validate[T any](factory ObjectFactory[T], item T) bool {
+	if m, ok := factory.(ManagedFactory[T]); ok {
+		return m.Validate(item)
+	}
+	return true
+}
+
+// destroy 对实现了 ManagedFactory 的工厂销毁对象
+func destroy[T any](factory ObjectFactory[T], item T) {
+	if m, ok := factory.(ManagedFactory[T]); ok {
+		m.Destroy(item)
+	}
+}
+
 // BatchPool 泛型对象池
+// 基于 sync.Pool，对象可能随 GC 被回收，PoolSize 只决定预先填充的数量，不限制对象总数；
+// 需要限制空闲与总对象数时使用 BoundedPool。
+// 被 GC 回收的空闲对象不会调用 ManagedFactory 的 Destroy，持有外部资源的对象应使用 BoundedPool
 type BatchPool[T any] struct {
 	Pool     sync.Pool
 	PoolSize int
+
+	factory ObjectFactory[T]
+	gets    metrics.Counter // Get 调用次数
+	misses  metrics.Counter // 池中没有可用对象而新建的次数
 }
 
 // NewBatchPool 初始化一个泛型对象池
+// 获取次数与未命中次数以对象类型为 pool 标签记录在 gokit_pool_gets_total 与 gokit_pool_misses_total 中
 func NewBatchPool[T any](factory ObjectFactory[T], poolSize int) *BatchPool[T] {
-	bp := &BatchPool[T]{
-		PoolSize: poolSize,
-		Pool: sync.Pool{
-			New: func() any {
-				return factory.New()
-			},
-		},
+	bp := &BatchPool[T]{PoolSize: poolSize, factory: factory}
+	bp.gets, bp.misses = poolCounters[T]()
+	bp.Pool.New = func() any {
+		bp.misses.Inc()
+		return factory.New()
 	}
 	// 预先填充对象池
 	for i := 0; i < poolSize; i++ {
@@ -35,22 +69,75 @@ func NewBatchPool[T any](factory ObjectFactory[T], poolSize int) *BatchPool[T] {
 	return bp
 }
 
+// poolCounters 返回以对象类型为 pool 标签的获取次数与未命中次数计数器
+func poolCounters[T any]() (gets, misses metrics.Counter) {
+	name := reflect.TypeOf((*T)(nil)).Elem().String()
+	provider := metrics.Default()
+	gets = provider.Counter(metrics.Opts{
+		Name:   "gokit_pool_gets_total",
+		Help:   "Number of objects taken from gokit pools.",
+		Labels: []string{"pool"},
+	}).With(name)
+	misses = provider.Counter(metrics.Opts{
+		Name:   "gokit_pool_misses_total",
+		Help:   "Number of pool gets that had to create a new object.",
+		Labels: []string{"pool"},
+	}).With(name)
+	return gets, misses
+}
+
 // Get 获取对象
+// 零值或以结构体字面量构造、且没有设置 Pool.New 的对象池为空时返回 T 的零值
 func (bp *BatchPool[T]) Get() T {
-	return bp.Pool.Get().(T)
+	if bp.gets != nil {
+		bp.gets.Inc()
+	}
+	item, _ := bp.Pool.Get().(T)
+	return item
 }
 
-// Put 放置对象
-func (bp *BatchPool[T]) Put(item T, factory ObjectFactory[T]) {
-	factory.Reset(item)
+// Put 使用创建对象池时的工厂重置并放回对象，未通过 ManagedFactory 校验的对象被销毁而不放回
+// factory 参数仅为兼容旧的 Put(item, factory) 调用保留，只在对象池不是由 NewBatchPool 创建时使用；
+// 既没有创建时的工厂也没有传入工厂时，对象不经重置直接放回
+func (bp *BatchPool[T]) Put(item T, factory ...ObjectFactory[T]) {
+	f := bp.factory
+	if f == nil && len(factory) > 0 {
+		f = factory[0]
+	}
+	if f == nil {
+		bp.Pool.Put(item)
+		return
+	}
+	if !validate(f, item) {
+		destroy(f, item)
+		return
+	}
+	f.Reset(item)
 	bp.Pool.Put(item)
 }
 
-// SetPoolSize 更新池子的大小
+// Resize 更新池子的大小，并使用创建对象池时的工厂预先填充对象；没有工厂时只更新 PoolSize
+func (bp *BatchPool[T]) Resize(poolSize int) {
+	bp.fill(bp.factory, poolSize)
+}
+
+// SetPoolSize 更新池子的大小并预先填充对象
+//
+// Deprecated: 使用 Resize，对象池由 NewBatchPool 创建时 factory 参数被忽略
 func (bp *BatchPool[T]) SetPoolSize(factory ObjectFactory[T], poolSize int) {
-	// 更新池子大小
+	f := bp.factory
+	if f == nil {
+		f = factory
+	}
+	bp.fill(f, poolSize)
+}
+
+// fill 更新 PoolSize 并使用 factory 预先填充对象，factory 为 nil 时不填充
+func (bp *BatchPool[T]) fill(factory ObjectFactory[T], poolSize int) {
 	bp.PoolSize = poolSize
-	// 预先填充对象池
+	if factory == nil {
+		return
+	}
 	for i := 0; i < poolSize; i++ {
 		bp.Pool.Put(factory.New())
 	}
@@ -59,9 +146,10 @@ func (bp *BatchPool[T]) SetPoolSize(factory ObjectFactory[T], poolSize int) {
 // BSONBatch 是 *[]bson.M 类型的别名
 type BSONBatch *[]bson.M
 
-// BSONFactory 实现 ObjectFactory 接口，用于创建和重置 BSONBatch 对象
+// BSONFactory 实现 ManagedFactory 接口，用于创建和重置 BSONBatch 对象
 type BSONFactory struct {
 	PoolSize int
+	MaxCap   int // 归还时容量超过 MaxCap 的批次被丢弃，避免偶发的大批次长期占用内存；为 0 时不限制
 }
 
 // New 创建新的 BSON 对象
@@ -75,7 +163,16 @@ func (f *BSONFactory) Reset(batch BSONBatch) {
 	*batch = (*batch)[:0] // 重置切片，避免持有过多内存
 }
 
-// BufferFactory 实现 ObjectFactory 接口，用于创建和重置缓冲区
+// Validate 判断批次能否复用
+func (f *BSONFactory) Validate(batch BSONBatch) bool {
+	return batch != nil && (f.MaxCap <= 0 || cap(*batch) <= f.MaxCap)
+}
+
+// Destroy 丢弃批次，没有需要释放的资源
+func (f *BSONFactory) Destroy(BSONBatch) {}
+
+// BufferFactory 实现 ManagedFactory 接口，用于创建和重置缓冲区
+// 缓冲区大小固定且每次归还都会清零，需要不同大小的缓冲区或避免清零大缓冲区的开销时使用 ByteBufferPool
 type BufferFactory struct {
 	BufferSize int
 }
@@ -94,6 +191,15 @@ func (f *BufferFactory) Reset(buffer []byte) {
 	}
 }
 
+// Validate 只复用长度与容量都等于 BufferSize 的缓冲区，被截短或扩容后的缓冲区被丢弃，
+// 保证从对象池获取的缓冲区长度不变，占用的内存有上限
+func (f *BufferFactory) Validate(buffer []byte) bool {
+	return len(buffer) == f.BufferSize && cap(buffer) == f.BufferSize
+}
+
+// Destroy 丢弃缓冲区，没有需要释放的资源
+func (f *BufferFactory) Destroy([]byte) {}
+
 // NewBSONFactory 构造函数
 func NewBSONFactory(poolSize int) *BSONFactory {
 	return &BSONFactory{PoolSize: poolSize}
@@ -0,0 +1,448 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/omeyang/gokit/metrics"
+)
+
+// ResourceFactory 创建与管理资源，例如 TCP 连接或 RPC 客户端
+// 与 ObjectFactory 相比，创建可能失败且需要 context，资源在丢弃时需要显式关闭
+type ResourceFactory[T any] interface {
+	// New 创建资源，ctx 结束时应尽快返回
+	New(ctx context.Context) (T, error)
+	// Check 在借出空闲资源前检查其是否可用，返回错误的资源被关闭，Get 继续尝试下一个资源
+	Check(ctx context.Context, resource T) error
+	// Close 关闭资源
+	Close(resource T) error
+}
+
+// ResourcePoolStats 是 ResourcePool 的统计信息
+type ResourcePoolStats struct {
+	Created      uint64 // 成功创建的资源数
+	CreateFailed uint64 // 创建失败的次数
+	CheckFailed  uint64 // 借出前检查失败被关闭的资源数
+	Expired      uint64 // 超过最长存活时间或空闲超时被关闭的资源数
+	WaitCount    uint64 // 需要等待的 Get 次数
+	Open         int    // 当前打开的资源数（空闲与借出之和，包括正在创建的）
+	Idle         int    // 当前空闲资源数
+	Waiting      int    // 当前等待的 Get 数
+}
+
+// ResourceOption 定义 ResourcePool 的可选配置
+type ResourceOption func(*resourceConfig)
+
+type resourceConfig struct {
+	maxOpen     int
+	maxIdle     int
+	maxLifetime time.Duration
+	idleTimeout time.Duration
+	now         func() time.Time
+}
+
+// WithMaxOpen 设置打开的资源数上限，达到上限时 Get 排队等待归还，不大于 0 时不限制
+func WithMaxOpen(n int) ResourceOption {
+	return func(c *resourceConfig) {
+		c.maxOpen = n
+	}
+}
+
+// WithMaxIdle 设置保留的空闲资源数上限，默认为 2，小于 0 时不保留空闲资源
+func WithMaxIdle(n int) ResourceOption {
+	return func(c *resourceConfig) {
+		c.maxIdle = max(n, 0)
+	}
+}
+
+// WithMaxLifetime 设置资源的最长存活时间，超过后在归还或借出时关闭；不大于 0 时不限制
+func WithMaxLifetime(d time.Duration) ResourceOption {
+	return func(c *resourceConfig) {
+		c.maxLifetime = d
+	}
+}
+
+// WithMaxIdleTime 设置空闲超时，空闲超过 d 的资源被关闭；不大于 0 时不过期
+func WithMaxIdleTime(d time.Duration) ResourceOption {
+	return func(c *resourceConfig) {
+		c.idleTimeout = d
+	}
+}
+
+// WithResourceClock 设置判断存活时间与空闲超时使用的时钟，默认使用 time.Now
+func WithResourceClock(now func() time.Time) ResourceOption {
+	return func(c *resourceConfig) {
+		if now != nil {
+			c.now = now
+		}
+	}
+}
+
+// defaultMaxIdleResources 默认保留的空闲资源数，与 database/sql 一致
+const defaultMaxIdleResources = 2
+
+// Resource 是从 ResourcePool 借出的资源，使用完后必须调用 Release 或 Discard 之一
+// 每次借出都返回新的 Resource，归还后再次调用 Release 或 Discard 不做任何事
+type Resource[T any] struct {
+	Value T
+
+	pool      *ResourcePool[T]
+	createdAt time.Time
+	idleSince time.Time
+	released  bool // 是否已经归还或丢弃，由 pool.mu 保护
+}
+
+// Release 将资源归还对象池
+func (r *Resource[T]) Release() {
+	if r.markReleased() {
+		r.pool.put(r)
+	}
+}
+
+// Discard 关闭资源而不归还，例如连接已经出错，释放出的名额可供创建新资源
+func (r *Resource[T]) Discard() {
+	if r.markReleased() {
+		r.pool.discard(r)
+	}
+}
+
+// markReleased 将资源标记为已归还，返回此前是否仍被借出
+func (r *Resource[T]) markReleased() bool {
+	r.pool.mu.Lock()
+	defer r.pool.mu.Unlock()
+	if r.released {
+		return false
+	}
+	r.released = true
+	return true
+}
+
+// resourceWait 是等待者收到的结果：转交的资源、创建新资源的名额或关闭错误
+type resourceWait[T any] struct {
+	res *Resource[T] // 为 nil 且 err 为 nil 时获得了创建新资源的名额
+	err error
+}
+
+// ResourcePool 是管理可能创建失败的资源的连接池
+// 借出空闲资源前调用 ResourceFactory.Check 检查可用性，超过最长存活时间或空闲超时的资源被关闭；
+// 打开的资源数达到上限时 Get 按先来先得的顺序等待，直到有资源归还或 ctx 结束。
+// 设置了存活时间或空闲超时时会启动后台 goroutine 定期清理，调用 Close 停止
+type ResourcePool[T any] struct {
+	factory ResourceFactory[T]
+	cfg     resourceConfig
+
+	mu      sync.Mutex
+	idle    []*Resource[T] // 按归还时间排序，最早归还的在前，借出时取最近归还的
+	open    int
+	waiters []chan resourceWait[T]
+	closed  bool
+	drained chan struct{} // 关闭后所有资源都已关闭时被关闭
+	stats   ResourcePoolStats
+
+	stopCh chan struct{}
+	gets   metrics.Counter
+	misses metrics.Counter
+}
+
+// NewResourcePool 创建资源池
+func NewResourcePool[T any](factory ResourceFactory[T], opts ...ResourceOption) *ResourcePool[T] {
+	cfg := resourceConfig{maxIdle: defaultMaxIdleResources, now: time.Now}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	p := &ResourcePool[T]{
+		factory: factory,
+		cfg:     cfg,
+		drained: make(chan struct{}),
+		stopCh:  make(chan struct{}),
+	}
+	p.gets, p.misses = poolCounters[T]()
+	if interval := cleanupInterval(cfg.maxLifetime, cfg.idleTimeout); interval > 0 {
+		go p.cleanupLoop(interval)
+	}
+	return p
+}
+
+// Get 借出资源：优先使用通过检查的空闲资源，没有空闲资源且未达到上限时创建新资源，否则等待
+// 创建失败时返回 ResourceFactory.New 的错误，ctx 结束时返回 ctx.Err()，对象池关闭后返回 ErrPoolClosed
+func (p *ResourcePool[T]) Get(ctx context.Context) (*Resource[T], error) {
+	p.gets.Inc()
+	waited := false
+	for {
+		// ctx 已经结束时不借出空闲资源，避免 Check 因 ctx 失败而关闭健康的资源
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+		if n := len(p.idle); n > 0 {
+			r := p.idle[n-1]
+			p.idle[n-1] = nil
+			p.idle = p.idle[:n-1]
+			p.mu.Unlock()
+			if r, err := p.borrow(ctx, r); r != nil || err != nil {
+				return r, err
+			}
+			continue
+		}
+		if p.cfg.maxOpen <= 0 || p.open < p.cfg.maxOpen {
+			p.open++
+			p.mu.Unlock()
+			return p.create(ctx)
+		}
+
+		if !waited {
+			p.stats.WaitCount++
+			waited = true
+		}
+		ch := make(chan resourceWait[T], 1)
+		p.waiters = append(p.waiters, ch)
+		p.mu.Unlock()
+
+		var w resourceWait[T]
+		select {
+		case w = <-ch:
+		case <-ctx.Done():
+			p.mu.Lock()
+			if p.removeWaiterLocked(ch) {
+				p.mu.Unlock()
+				return nil, ctx.Err()
+			}
+			p.mu.Unlock()
+			// 取消与转交同时发生，把收到的资源或名额还给对象池
+			if w = <-ch; w.err == nil {
+				if w.res != nil {
+					p.put(w.res)
+				} else {
+					p.mu.Lock()
+					p.releaseLocked()
+					p.mu.Unlock()
+				}
+			}
+			return nil, ctx.Err()
+		}
+		if w.err != nil {
+			return nil, w.err
+		}
+		if w.res == nil {
+			return p.create(ctx)
+		}
+		if r, err := p.borrow(ctx, w.res); r != nil || err != nil {
+			return r, err
+		}
+	}
+}
+
+// borrow 检查资源是否过期与可用，不可用的资源被关闭并释放名额，此时返回 nil, nil 由调用方继续尝试
+// Check 因 ctx 结束而失败时资源并没有问题，放回对象池并返回 ctx 的错误
+func (p *ResourcePool[T]) borrow(ctx context.Context, r *Resource[T]) (*Resource[T], error) {
+	if p.expired(r, p.cfg.now()) {
+		p.mu.Lock()
+		p.stats.Expired++
+		p.mu.Unlock()
+		p.discard(r)
+		return nil, nil
+	}
+	if err := p.factory.Check(ctx, r.Value); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			p.put(r)
+			return nil, ctxErr
+		}
+		p.mu.Lock()
+		p.stats.CheckFailed++
+		p.mu.Unlock()
+		p.discard(r)
+		return nil, nil
+	}
+	// 使用新的 Resource 借出，之前的借用者持有的 Resource 不能再归还这个资源
+	return &Resource[T]{Value: r.Value, pool: p, createdAt: r.createdAt}, nil
+}
+
+// create 使用已占用的名额创建资源，失败时释放名额
+func (p *ResourcePool[T]) create(ctx context.Context) (*Resource[T], error) {
+	p.misses.Inc()
+	value, err := p.factory.New(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.stats.CreateFailed++
+		p.releaseLocked()
+		return nil, err
+	}
+	p.stats.Created++
+	return &Resource[T]{Value: value, pool: p, createdAt: p.cfg.now()}, nil
+}
+
+// put 归还资源，有等待者时直接转交，过期、空闲数已达上限或对象池已关闭时关闭资源
+func (p *ResourcePool[T]) put(r *Resource[T]) {
+	now := p.cfg.now()
+	p.mu.Lock()
+	switch {
+	case p.closed || p.expired(r, now) || (len(p.waiters) == 0 && len(p.idle) >= p.cfg.maxIdle):
+		if !p.closed && p.expired(r, now) {
+			p.stats.Expired++
+		}
+		p.mu.Unlock()
+		p.discard(r)
+	case len(p.waiters) > 0:
+		p.popWaiterLocked() <- resourceWait[T]{res: r}
+		p.mu.Unlock()
+	default:
+		r.idleSince = now
+		p.idle = append(p.idle, r)
+		p.mu.Unlock()
+	}
+}
+
+// expired 判断资源是否超过最长存活时间或空闲超时
+func (p *ResourcePool[T]) expired(r *Resource[T], now time.Time) bool {
+	if p.cfg.maxLifetime > 0 && now.Sub(r.createdAt) >= p.cfg.maxLifetime {
+		return true
+	}
+	return p.cfg.idleTimeout > 0 && !r.idleSince.IsZero() && now.Sub(r.idleSince) >= p.cfg.idleTimeout
+}
+
+// discard 关闭资源并释放名额，关闭时的错误被忽略
+func (p *ResourcePool[T]) discard(r *Resource[T]) {
+	_ = p.factory.Close(r.Value)
+	p.mu.Lock()
+	p.releaseLocked()
+	p.mu.Unlock()
+}
+
+// Cleanup 立即关闭过期的空闲资源，返回关闭的数量
+func (p *ResourcePool[T]) Cleanup() int {
+	now := p.cfg.now()
+	p.mu.Lock()
+	var expired []*Resource[T]
+	kept := p.idle[:0]
+	for _, r := range p.idle {
+		if p.expired(r, now) {
+			expired = append(expired, r)
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	clear(p.idle[len(kept):])
+	p.idle = kept
+	p.stats.Expired += uint64(len(expired))
+	p.mu.Unlock()
+	for _, r := range expired {
+		p.discard(r)
+	}
+	return len(expired)
+}
+
+// Stats 返回统计信息
+func (p *ResourcePool[T]) Stats() ResourcePoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Open = p.open
+	stats.Idle = len(p.idle)
+	stats.Waiting = len(p.waiters)
+	return stats
+}
+
+// Close 关闭资源池：正在等待的 Get 返回 ErrPoolClosed，空闲资源立即关闭，
+// 并等待借出的资源归还后关闭，直到所有资源关闭或 ctx 结束；返回关闭空闲资源的错误或 ctx.Err()
+func (p *ResourcePool[T]) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.stopCh)
+		for len(p.waiters) > 0 {
+			p.popWaiterLocked() <- resourceWait[T]{err: ErrPoolClosed}
+		}
+	}
+	idle := p.idle
+	p.idle = nil
+	p.open -= len(idle)
+	p.signalDrainedLocked()
+	p.mu.Unlock()
+
+	var errs []error
+	for _, r := range idle {
+		if err := p.factory.Close(r.Value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	select {
+	case <-p.drained:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+	return errors.Join(errs...)
+}
+
+// cleanupLoop 定期关闭过期的空闲资源
+func (p *ResourcePool[T]) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			p.Cleanup()
+		}
+	}
+}
+
+// cleanupInterval 返回后台清理的间隔，为较短的时限的一半，都未设置时返回 0
+func cleanupInterval(lifetime, idleTimeout time.Duration) time.Duration {
+	d := lifetime
+	if d <= 0 || (idleTimeout > 0 && idleTimeout < d) {
+		d = idleTimeout
+	}
+	if d <= 0 {
+		return 0
+	}
+	return max(d/2, time.Millisecond)
+}
+
+// releaseLocked 减少打开的资源数，有等待者时把空出的名额交给最早的等待者，调用方需持有锁
+func (p *ResourcePool[T]) releaseLocked() {
+	p.open--
+	if len(p.waiters) > 0 && !p.closed {
+		p.open++
+		p.popWaiterLocked() <- resourceWait[T]{}
+		return
+	}
+	p.signalDrainedLocked()
+}
+
+// signalDrainedLocked 在关闭后所有资源都已关闭时通知 Close，调用方需持有锁
+func (p *ResourcePool[T]) signalDrainedLocked() {
+	if p.closed && p.open == 0 {
+		select {
+		case <-p.drained:
+		default:
+			close(p.drained)
+		}
+	}
+}
+
+// popWaiterLocked 取出最早的等待者，调用方需持有锁
+func (p *ResourcePool[T]) popWaiterLocked() chan resourceWait[T] {
+	ch := p.waiters[0]
+	p.waiters[0] = nil
+	p.waiters = p.waiters[1:]
+	return ch
+}
+
+// removeWaiterLocked 移除等待者，返回是否仍在等待队列中，调用方需持有锁
+func (p *ResourcePool[T]) removeWaiterLocked(ch chan resourceWait[T]) bool {
+	for i, w := range p.waiters {
+		if w == ch {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/middleware/pool"
+)
+
+// counterFactory 创建递增编号的对象，并记录重置次数
+type counterFactory struct {
+	created atomic.Int64
+	resets  atomic.Int64
+}
+
+func (f *counterFactory) New() *int {
+	n := int(f.created.Add(1))
+	return &n
+}
+
+func (f *counterFactory) Reset(*int) {
+	f.resets.Add(1)
+}
+
+func TestBoundedPoolHitsAndIdleLimit(t *testing.T) {
+	factory := &counterFactory{}
+	p := pool.NewBoundedPool[*int](factory, 1)
+	ctx := context.Background()
+
+	a, _ := p.Get(ctx)
+	b, _ := p.Get(ctx)
+	p.Put(a)
+	p.Put(b) // 超过 maxIdle，被丢弃
+	c, _ := p.Get(ctx)
+	if c != a {
+		t.Errorf("Get() = %d, want the idle object %d", *c, *a)
+	}
+
+	stats := p.Stats()
+	want := pool.BoundedPoolStats{Hits: 1, Misses: 2, Created: 2, Evicted: 1, Idle: 0, Active: 1}
+	if stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+	if factory.resets.Load() != 2 {
+		t.Errorf("resets = %d, want 2", factory.resets.Load())
+	}
+}
+
+func TestBoundedPoolMaxTotal(t *testing.T) {
+	p := pool.NewBoundedPool[*int](&counterFactory{}, 2, pool.WithMaxTotal(2))
+	ctx := context.Background()
+
+	a, _ := p.Get(ctx)
+	if _, err := p.Get(ctx); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := p.Get(ctx); !errors.Is(err, pool.ErrPoolExhausted) {
+		t.Fatalf("Get() error = %v, want ErrPoolExhausted", err)
+	}
+	p.Discard(a)
+	if _, err := p.Get(ctx); err != nil {
+		t.Errorf("Get() after Discard error = %v", err)
+	}
+	if stats := p.Stats(); stats.Created != 3 || stats.Active != 2 {
+		t.Errorf("Stats() = %+v, want 3 created and 2 active", stats)
+	}
+}
+
+func TestBoundedPoolBlockingGet(t *testing.T) {
+	p := pool.NewBoundedPool[*int](&counterFactory{}, 1, pool.WithMaxTotal(1), pool.WithBlock())
+	ctx := context.Background()
+	a, _ := p.Get(ctx)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get() error = %v, want DeadlineExceeded", err)
+	}
+
+	got := make(chan *int)
+	go func() {
+		item, err := p.Get(ctx)
+		if err != nil {
+			t.Errorf("Get() error = %v", err)
+		}
+		got <- item
+	}()
+	for p.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Put(a)
+	if item := <-got; item != a {
+		t.Errorf("waiter got %v, want the returned object", item)
+	}
+
+	// 丢弃对象后等待者获得新建对象的名额
+	go func() {
+		item, err := p.Get(ctx)
+		if err != nil {
+			t.Errorf("Get() error = %v", err)
+		}
+		got <- item
+	}()
+	for p.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Discard(a)
+	if item := <-got; item == nil || item == a {
+		t.Errorf("waiter got %v, want a new object", item)
+	}
+	if stats := p.Stats(); stats.Created != 2 || stats.Active != 1 {
+		t.Errorf("Stats() = %+v, want 2 created and 1 active", stats)
+	}
+}
+
+func TestBoundedPoolIdleTimeout(t *testing.T) {
+	now := time.Unix(0, 0)
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+
+	p := pool.NewBoundedPool[*int](&counterFactory{}, 2, pool.WithIdleTimeout(time.Minute), pool.WithClock(clock))
+	defer p.Close()
+	ctx := context.Background()
+	a, _ := p.Get(ctx)
+	b, _ := p.Get(ctx)
+	p.Put(a)
+	advance(30 * time.Second)
+	p.Put(b)
+	advance(45 * time.Second)
+
+	if n := p.Evict(); n != 1 {
+		t.Errorf("Evict() = %d, want 1", n)
+	}
+	if item, _ := p.Get(ctx); item != b {
+		t.Error("expected the object that has not expired")
+	}
+	if stats := p.Stats(); stats.Evicted != 1 || stats.Idle != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestBoundedPoolClose(t *testing.T) {
+	p := pool.NewBoundedPool[*int](&counterFactory{}, 1, pool.WithMaxTotal(1), pool.WithBlock())
+	ctx := context.Background()
+	a, _ := p.Get(ctx)
+
+	errCh := make(chan error)
+	go func() {
+		_, err := p.Get(ctx)
+		errCh <- err
+	}()
+	for p.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Close()
+	if err := <-errCh; !errors.Is(err, pool.ErrPoolClosed) {
+		t.Errorf("waiting Get() error = %v, want ErrPoolClosed", err)
+	}
+	if _, err := p.Get(ctx); !errors.Is(err, pool.ErrPoolClosed) {
+		t.Errorf("Get() after Close error = %v, want ErrPoolClosed", err)
+	}
+	p.Put(a)
+	if stats := p.Stats(); stats.Active != 0 || stats.Idle != 0 {
+		t.Errorf("Stats() after Close = %+v", stats)
+	}
+}
+
+func TestBoundedPoolConcurrent(t *testing.T) {
+	const maxTotal = 4
+	p := pool.NewBoundedPool[*int](&counterFactory{}, 2, pool.WithMaxTotal(maxTotal), pool.WithBlock())
+	var active, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i%3)*time.Millisecond+time.Millisecond)
+				item, err := p.Get(ctx)
+				cancel()
+				if err != nil {
+					continue
+				}
+				if n := active.Add(1); n > peak.Load() {
+					peak.Store(n)
+				}
+				active.Add(-1)
+				if j%10 == 0 {
+					p.Discard(item)
+				} else {
+					p.Put(item)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if peak.Load() > maxTotal {
+		t.Errorf("peak active = %d, want at most %d", peak.Load(), maxTotal)
+	}
+	if stats := p.Stats(); stats.Active != 0 || stats.Idle > 2 || stats.Waiting != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+// managedFactory 拒绝负数对象并记录被销毁的对象
+type managedFactory struct {
+	counterFactory
+	mu        sync.Mutex
+	destroyed []int
+}
+
+func (f *managedFactory) Validate(item *int) bool {
+	return *item >= 0
+}
+
+func (f *managedFactory) Destroy(item *int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.destroyed = append(f.destroyed, *item)
+}
+
+func (f *managedFactory) destroyedItems() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.destroyed...)
+}
+
+func TestBoundedPoolManagedFactory(t *testing.T) {
+	factory := &managedFactory{}
+	p := pool.NewBoundedPool[*int](factory, 1, pool.WithMaxTotal(3))
+	ctx := context.Background()
+
+	a, _ := p.Get(ctx)
+	b, _ := p.Get(ctx)
+	c, _ := p.Get(ctx)
+	*a = -1
+	p.Put(a) // 未通过校验，销毁后释放名额
+	p.Put(b)
+	p.Put(c) // 超过 maxIdle，销毁
+	if got := factory.destroyedItems(); len(got) != 2 || got[0] != -1 || got[1] != 3 {
+		t.Errorf("destroyed = %v, want [-1 3]", got)
+	}
+	if factory.resets.Load() != 2 {
+		t.Errorf("resets = %d, want 2, invalid objects should not be reset", factory.resets.Load())
+	}
+	stats := p.Stats()
+	if stats.Discarded != 1 || stats.Evicted != 1 || stats.Idle != 1 || stats.Active != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	p.Close()
+	if got := factory.destroyedItems(); len(got) != 3 || got[2] != 2 {
+		t.Errorf("destroyed after Close = %v, want idle object 2 destroyed", got)
+	}
+}
@@ -0,0 +1,125 @@
+package pool_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/omeyang/gokit/middleware/pool"
+)
+
+func TestByteBufferPoolSizeClasses(t *testing.T) {
+	p := pool.NewByteBufferPool(64, 4096)
+	for _, tt := range []struct {
+		n, cap int
+	}{
+		{0, 64},
+		{1, 64},
+		{64, 64},
+		{65, 128},
+		{1000, 1024},
+		{4096, 4096},
+		{4097, 4097}, // 超过最大级别，直接分配
+	} {
+		buf := p.Get(tt.n)
+		if len(buf) != tt.n || cap(buf) != tt.cap {
+			t.Errorf("Get(%d) len = %d cap = %d, want len %d cap %d", tt.n, len(buf), cap(buf), tt.n, tt.cap)
+		}
+		p.Put(buf)
+	}
+	// 容量不属于任何级别的缓冲区被忽略
+	p.Put(make([]byte, 100))
+	p.Put(nil)
+}
+
+func TestByteBufferPoolPutZeroed(t *testing.T) {
+	p := pool.NewByteBufferPool(64, 64)
+	buf := p.Get(16)
+	copy(buf, "secret")
+	p.PutZeroed(buf[:3])
+	if !bytes.Equal(buf[:16], make([]byte, 16)) {
+		t.Errorf("PutZeroed should clear the whole buffer, got %q", buf[:16])
+	}
+}
+
+func TestByteBuffer(t *testing.T) {
+	p := pool.NewByteBufferPool(16, 1024)
+	b := p.GetBuffer()
+	b.WriteString("hello")
+	b.WriteByte(' ')
+	b.WriteRune('世')
+	fmt.Fprintf(b, " %d", 42)
+	if got := b.String(); got != "hello 世 42" {
+		t.Errorf("String() = %q", got)
+	}
+
+	long := strings.Repeat("x", 100)
+	b.Write([]byte(long))
+	if b.Len() != len("hello 世 42")+100 || b.Cap() != 128 {
+		t.Errorf("Len() = %d, Cap() = %d, want cap 128", b.Len(), b.Cap())
+	}
+
+	var out bytes.Buffer
+	n, err := b.WriteTo(&out)
+	if err != nil || int(n) != out.Len() || b.Len() != 0 {
+		t.Errorf("WriteTo() = %d, %v, remaining %d", n, err, b.Len())
+	}
+	if !strings.HasSuffix(out.String(), long) {
+		t.Errorf("WriteTo() wrote %q", out.String())
+	}
+
+	b.Grow(2000) // 超过最大级别
+	if b.Cap() < 2000 {
+		t.Errorf("Cap() after Grow = %d", b.Cap())
+	}
+	b.Reset()
+	if b.Len() != 0 {
+		t.Errorf("Len() after Reset = %d", b.Len())
+	}
+	b.Release()
+
+	s := p.GetBuffer()
+	s.WriteString("password")
+	data := s.Bytes()
+	s.ReleaseZeroed()
+	if !bytes.Equal(data[:cap(data)][:8], make([]byte, 8)) {
+		t.Errorf("ReleaseZeroed should clear the buffer, got %q", data[:8])
+	}
+}
+
+func TestByteBufferZeroValue(t *testing.T) {
+	var b pool.ByteBuffer
+	b.WriteString("hello")
+	b.Write([]byte(strings.Repeat("x", 100)))
+	if b.Len() != 105 || !strings.HasPrefix(b.String(), "hello") {
+		t.Errorf("zero value buffer = %q", b.String())
+	}
+	b.Release()
+	b.ReleaseZeroed()
+}
+
+func BenchmarkByteBufferPool(b *testing.B) {
+	p := pool.NewByteBufferPool(64, 1<<20)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := p.Get(32 << 10)
+			buf[0] = 1
+			p.Put(buf)
+		}
+	})
+}
+
+func BenchmarkBufferFactoryReset(b *testing.B) {
+	factory := pool.NewBufferFactory(32 << 10)
+	bp := pool.NewBatchPool[[]byte](factory, 0)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := bp.Get()
+			buf[0] = 1
+			bp.Put(buf)
+		}
+	})
+}
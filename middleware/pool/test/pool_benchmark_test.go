@@ -27,6 +27,6 @@ func BenchmarkBatchPool(b *testing.B) {
 	bp := pool.NewBatchPool[pool.BSONBatch](factory, 5)
 	for i := 0; i < b.N; i++ {
 		item := bp.Get()
-		bp.Put(item, factory)
+		bp.Put(item)
 	}
 }
@@ -42,6 +42,6 @@ func FuzzBatchPool(f *testing.F) {
 		if err := bson.Unmarshal(data, &doc); err == nil {
 			*item = append(*item, doc)
 		}
-		bp.Put(item, factory)
+		bp.Put(item)
 	})
 }
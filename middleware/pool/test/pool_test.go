@@ -1,9 +1,14 @@
 package pool_test
 
 import (
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/omeyang/gokit/metrics"
 	"github.com/omeyang/gokit/middleware/pool"
+
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 func TestBSONFactory(t *testing.T) {
@@ -40,13 +45,89 @@ func TestBatchPool(t *testing.T) {
 		t.Errorf("Expected capacity 10, got %d", cap(*item))
 	}
 
-	bp.Put(item, factory)
+	bp.Put(item)
 	if len(*item) != 0 {
 		t.Errorf("Expected length 0 after reset, got %d", len(*item))
 	}
 
-	bp.SetPoolSize(factory, 10)
-	if bp.Get() == nil {
+	bp.Resize(10)
+	if bp.PoolSize != 10 || bp.Get() == nil {
 		t.Errorf("Expected non-nil item from pool")
 	}
 }
+
+func TestBatchPoolWithoutFactory(t *testing.T) {
+	var zero pool.BatchPool[[]byte]
+	if got := zero.Get(); got != nil {
+		t.Errorf("Get() on empty zero value pool = %v, want nil", got)
+	}
+	zero.Put(make([]byte, 4))
+	zero.Resize(3)
+	if zero.PoolSize != 3 {
+		t.Errorf("PoolSize = %d, want 3", zero.PoolSize)
+	}
+
+	// 旧的调用方式：字面量构造并在 Put、SetPoolSize 时传入工厂
+	factory := pool.NewBSONFactory(4)
+	bp := &pool.BatchPool[pool.BSONBatch]{
+		Pool: sync.Pool{New: func() any { return factory.New() }},
+	}
+	item := bp.Get()
+	*item = append(*item, bson.M{"a": 1})
+	bp.Put(item, factory)
+	if len(*item) != 0 {
+		t.Errorf("Put(item, factory) should reset the item, len = %d", len(*item))
+	}
+	bp.SetPoolSize(factory, 2)
+	if bp.PoolSize != 2 || bp.Get() == nil {
+		t.Error("SetPoolSize(factory, n) should prefill the pool")
+	}
+}
+
+func TestBatchPoolMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.SetDefault(registry)
+	defer metrics.SetDefault(nil)
+
+	factory := pool.NewBufferFactory(16)
+	bp := pool.NewBatchPool[[]byte](factory, 0)
+	bp.Get()
+	bp.Get()
+
+	var b strings.Builder
+	if err := registry.WriteText(&b); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	for _, want := range []string{
+		`gokit_pool_gets_total{pool="[]uint8"} 2`,
+		`gokit_pool_misses_total{pool="[]uint8"} 2`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics output missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestBatchPoolValidate(t *testing.T) {
+	factory := pool.NewBufferFactory(8)
+	bp := pool.NewBatchPool[[]byte](factory, 0)
+
+	buf := bp.Get()
+	copy(buf, "secret")
+	bp.Put(buf[:4]) // 截短的缓冲区不会放回
+	for i := 0; i < 10; i++ {
+		if got := bp.Get(); len(got) != 8 {
+			t.Fatalf("Get() len = %d, want 8", len(got))
+		}
+	}
+
+	bsonFactory := &pool.BSONFactory{PoolSize: 2, MaxCap: 4}
+	batch := bsonFactory.New()
+	if !bsonFactory.Validate(batch) {
+		t.Error("new batch should be valid")
+	}
+	*batch = append(*batch, bson.M{}, bson.M{}, bson.M{}, bson.M{}, bson.M{})
+	if bsonFactory.Validate(batch) {
+		t.Errorf("batch with cap %d should exceed MaxCap", cap(*batch))
+	}
+}
@@ -0,0 +1,425 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/middleware/pool"
+)
+
+// fakeConn 是假的网络连接
+type fakeConn struct {
+	id      int
+	healthy atomic.Bool
+	closed  atomic.Bool
+}
+
+// fakeDialer 是创建 fakeConn 的 ResourceFactory，可以模拟拨号失败
+type fakeDialer struct {
+	mu         sync.Mutex
+	conns      []*fakeConn
+	fail       error         // 不为 nil 时拨号失败
+	checkDelay time.Duration // 模拟检查连接时的网络往返
+}
+
+func (d *fakeDialer) New(ctx context.Context) (*fakeConn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.fail != nil {
+		return nil, d.fail
+	}
+	c := &fakeConn{id: len(d.conns) + 1}
+	c.healthy.Store(true)
+	d.conns = append(d.conns, c)
+	return c, nil
+}
+
+func (d *fakeDialer) Check(ctx context.Context, c *fakeConn) error {
+	d.mu.Lock()
+	delay := d.checkDelay
+	d.mu.Unlock()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+	if !c.healthy.Load() {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (d *fakeDialer) Close(c *fakeConn) error {
+	if c.closed.Swap(true) {
+		return errors.New("already closed")
+	}
+	return nil
+}
+
+func (d *fakeDialer) setFail(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fail = err
+}
+
+// openConns 返回未关闭的连接数
+func (d *fakeDialer) openConns() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for _, c := range d.conns {
+		if !c.closed.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// fakeClock 是可以手动推进的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// waitFor 等待条件成立
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestResourcePoolReuseAndCheck(t *testing.T) {
+	dialer := &fakeDialer{}
+	p := pool.NewResourcePool[*fakeConn](dialer)
+	ctx := context.Background()
+
+	r, err := p.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	first := r.Value
+	r.Release()
+	if r, _ = p.Get(ctx); r.Value != first {
+		t.Errorf("Get() = conn %d, want reused conn %d", r.Value.id, first.id)
+	}
+
+	// 归还后连接断开，借出前检查失败，关闭后创建新连接
+	first.healthy.Store(false)
+	r.Release()
+	r, err = p.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if r.Value == first || !first.closed.Load() {
+		t.Error("unhealthy connection should be closed and replaced")
+	}
+	if stats := p.Stats(); stats.Created != 2 || stats.CheckFailed != 1 || stats.Open != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	r.Discard()
+	if !r.Value.closed.Load() || p.Stats().Open != 0 {
+		t.Error("Discard should close the connection and free its slot")
+	}
+}
+
+func TestResourcePoolCreateFailure(t *testing.T) {
+	dialer := &fakeDialer{}
+	p := pool.NewResourcePool[*fakeConn](dialer, pool.WithMaxOpen(1))
+	ctx := context.Background()
+
+	errRefused := errors.New("connection refused")
+	dialer.setFail(errRefused)
+	if _, err := p.Get(ctx); !errors.Is(err, errRefused) {
+		t.Fatalf("Get() error = %v, want %v", err, errRefused)
+	}
+	dialer.setFail(nil)
+	r, err := p.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get() after failure error = %v, the slot should be released", err)
+	}
+	r.Release()
+	if stats := p.Stats(); stats.CreateFailed != 1 || stats.Created != 1 || stats.Open != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestResourcePoolCancelledContextKeepsIdle(t *testing.T) {
+	dialer := &fakeDialer{}
+	p := pool.NewResourcePool[*fakeConn](dialer, pool.WithMaxIdle(3))
+	ctx := context.Background()
+	var held []*pool.Resource[*fakeConn]
+	for i := 0; i < 3; i++ {
+		r, _ := p.Get(ctx)
+		held = append(held, r)
+	}
+	for _, r := range held {
+		r.Release()
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := p.Get(cancelled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Get() error = %v, want Canceled", err)
+	}
+
+	// ctx 在检查期间超时，资源本身是健康的
+	dialer.mu.Lock()
+	dialer.checkDelay = time.Second
+	dialer.mu.Unlock()
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get() error = %v, want DeadlineExceeded", err)
+	}
+	if n := dialer.openConns(); n != 3 {
+		t.Errorf("%d connections open, a dead context should not close healthy idle connections", n)
+	}
+	if stats := p.Stats(); stats.Idle != 3 || stats.CheckFailed != 0 || stats.Created != 3 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestResourcePoolDoubleRelease(t *testing.T) {
+	dialer := &fakeDialer{}
+	p := pool.NewResourcePool[*fakeConn](dialer, pool.WithMaxOpen(2))
+	ctx := context.Background()
+
+	r, _ := p.Get(ctx)
+	r.Release()
+	r.Release()
+	r.Discard()
+	if stats := p.Stats(); stats.Idle != 1 || stats.Open != 1 || r.Value.closed.Load() {
+		t.Fatalf("Stats() = %+v, repeated Release and Discard should be no-ops", stats)
+	}
+
+	// 重新借出后，之前的 Resource 不能归还或关闭别人正在使用的连接
+	a, _ := p.Get(ctx)
+	r.Release()
+	r.Discard()
+	b, _ := p.Get(ctx)
+	if a.Value == b.Value {
+		t.Fatal("two borrowers share one connection")
+	}
+	if a.Value.closed.Load() {
+		t.Fatal("stale Discard closed a borrowed connection")
+	}
+	if stats := p.Stats(); stats.Open != 2 || stats.Created != 2 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestResourcePoolReleaseAfterDiscard(t *testing.T) {
+	dialer := &fakeDialer{}
+	p := pool.NewResourcePool[*fakeConn](dialer, pool.WithMaxOpen(1))
+	ctx := context.Background()
+
+	r, _ := p.Get(ctx)
+	r.Discard()
+	r.Release()
+	if stats := p.Stats(); stats.Idle != 0 || stats.Open != 0 {
+		t.Fatalf("Stats() = %+v, Release after Discard should be a no-op", stats)
+	}
+	next, err := p.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if next.Value == r.Value {
+		t.Error("discarded connection was handed out again")
+	}
+	next.Release()
+	if stats := p.Stats(); stats.Open != 1 {
+		t.Errorf("Stats() = %+v, want at most one open connection", stats)
+	}
+}
+
+func TestResourcePoolLifetimeAndIdleTimeout(t *testing.T) {
+	dialer := &fakeDialer{}
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	p := pool.NewResourcePool[*fakeConn](dialer,
+		pool.WithMaxIdle(2),
+		pool.WithMaxLifetime(time.Hour),
+		pool.WithMaxIdleTime(time.Minute),
+		pool.WithResourceClock(clock.Now),
+	)
+	defer p.Close(context.Background())
+	ctx := context.Background()
+
+	a, _ := p.Get(ctx)
+	b, _ := p.Get(ctx)
+	a.Release()
+	clock.Advance(50 * time.Second)
+	b.Release()
+	clock.Advance(20 * time.Second)
+
+	// a 空闲超过一分钟
+	if n := p.Cleanup(); n != 1 || !a.Value.closed.Load() {
+		t.Fatalf("Cleanup() = %d, want the idle connection closed", n)
+	}
+	r, _ := p.Get(ctx)
+	if r.Value != b.Value {
+		t.Fatal("expected the connection that has not expired")
+	}
+
+	// 借出期间超过最长存活时间，归还时关闭
+	clock.Advance(time.Hour)
+	r.Release()
+	if !b.Value.closed.Load() || p.Stats().Idle != 0 {
+		t.Error("connection past its max lifetime should be closed on release")
+	}
+	if stats := p.Stats(); stats.Expired != 2 || stats.Open != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestResourcePoolWaitQueue(t *testing.T) {
+	dialer := &fakeDialer{}
+	p := pool.NewResourcePool[*fakeConn](dialer, pool.WithMaxOpen(1))
+	ctx := context.Background()
+	held, _ := p.Get(ctx)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get() error = %v, want DeadlineExceeded", err)
+	}
+
+	// 等待者按先来先得的顺序获得归还的连接
+	order := make(chan int, 2)
+	var wg sync.WaitGroup
+	for i := 1; i <= 2; i++ {
+		waitFor(t, func() bool { return p.Stats().Waiting == i-1 })
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := p.Get(ctx)
+			if err != nil {
+				t.Errorf("Get() error = %v", err)
+				return
+			}
+			order <- i
+			r.Release()
+		}(i)
+	}
+	waitFor(t, func() bool { return p.Stats().Waiting == 2 })
+	held.Release()
+	wg.Wait()
+	if first, second := <-order, <-order; first != 1 || second != 2 {
+		t.Errorf("waiters served in order %d, %d", first, second)
+	}
+	if stats := p.Stats(); stats.Created != 1 || stats.WaitCount != 3 {
+		t.Errorf("Stats() = %+v, want one connection shared by all waiters", stats)
+	}
+}
+
+func TestResourcePoolCloseDrains(t *testing.T) {
+	dialer := &fakeDialer{}
+	p := pool.NewResourcePool[*fakeConn](dialer, pool.WithMaxOpen(2))
+	ctx := context.Background()
+	idle, _ := p.Get(ctx)
+	held, _ := p.Get(ctx)
+	idle.Release()
+
+	waitErr := make(chan error)
+	go func() {
+		// 先借出空闲连接，再等待
+		r, _ := p.Get(ctx)
+		_, err := p.Get(ctx)
+		r.Release()
+		waitErr <- err
+	}()
+	waitFor(t, func() bool { return p.Stats().Waiting == 1 })
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := p.Close(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() error = %v, want DeadlineExceeded while a connection is borrowed", err)
+	}
+	if err := <-waitErr; !errors.Is(err, pool.ErrPoolClosed) {
+		t.Errorf("waiting Get() error = %v, want ErrPoolClosed", err)
+	}
+	if _, err := p.Get(ctx); !errors.Is(err, pool.ErrPoolClosed) {
+		t.Errorf("Get() after Close error = %v, want ErrPoolClosed", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- p.Close(ctx) }()
+	held.Release()
+	if err := <-done; err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if n := dialer.openConns(); n != 0 {
+		t.Errorf("%d connections left open after Close", n)
+	}
+}
+
+func TestResourcePoolConcurrent(t *testing.T) {
+	dialer := &fakeDialer{}
+	const maxOpen = 3
+	p := pool.NewResourcePool[*fakeConn](dialer, pool.WithMaxOpen(maxOpen), pool.WithMaxIdle(1))
+	var inUse, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				r, err := p.Get(ctx)
+				cancel()
+				if err != nil {
+					continue
+				}
+				if n := inUse.Add(1); n > peak.Load() {
+					peak.Store(n)
+				}
+				inUse.Add(-1)
+				switch {
+				case j%7 == 0:
+					r.Value.healthy.Store(false)
+					r.Release()
+				case j%11 == 0:
+					r.Discard()
+				default:
+					r.Release()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if peak.Load() > maxOpen {
+		t.Errorf("peak in use = %d, want at most %d", peak.Load(), maxOpen)
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if n := dialer.openConns(); n != 0 {
+		t.Errorf("%d connections left open after Close", n)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/omeyang/gokit/util/retry"
 
+	"github.com/omeyang/gokit/metrics"
 	"github.com/omeyang/gokit/middleware/pool"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -104,6 +105,7 @@ func newMongoDBImpl(ctx context.Context, retryPolicy retry.RetryPolicy, basic *o
 			if !retryPolicy.ShouldRetry(attempt, err) {
 				return nil, fmt.Errorf("failed to connect to MongoDB after %d attempts: %v", attempt, err)
 			}
+			mongoRetries("connect").Inc()
 			time.Sleep(time.Duration(retryPolicy.WaitDuration(attempt)) * time.Second)
 		}
 	}
@@ -122,8 +124,7 @@ func (m *MongoDBImpl) InitBatchPool(poolSize int) {
 // SetPoolSize 设置连接池大小
 func (m *MongoDBImpl) SetPoolSize(defaultPoolSize int) {
 	if m.batchPool != nil {
-		bsonFactory := pool.NewBSONFactory(defaultPoolSize)
-		m.batchPool.SetPoolSize(bsonFactory, defaultPoolSize)
+		m.batchPool.Resize(defaultPoolSize)
 	}
 }
 
@@ -184,11 +185,21 @@ func (m *MongoDBImpl) BulkWriteWithRetry(ctx context.Context, opts *BulkWriteOpt
 		if !opts.RetryPolicy.ShouldRetry(attempt, err) {
 			break
 		}
+		mongoRetries("bulk_write").Inc()
 		time.Sleep(time.Duration(opts.RetryPolicy.WaitDuration(attempt)) * time.Second)
 	}
 	return fmt.Errorf("bulk write failed: %w", err)
 }
 
+// mongoRetries 返回记录 MongoDB 操作重试次数的计数器
+func mongoRetries(op string) metrics.Counter {
+	return metrics.Default().Counter(metrics.Opts{
+		Name:   "gokit_mongo_retries_total",
+		Help:   "Number of retried MongoDB operations.",
+		Labels: []string{"op"},
+	}).With(op)
+}
+
 // convertToWriteModels 转换模型
 func convertToWriteModels(docs []*bson.M) []mongo.WriteModel {
 	models := make([]mongo.WriteModel, len(docs))
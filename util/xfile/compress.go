@@ -4,11 +4,13 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/omeyang/gokit/middleware/pool"
 
@@ -40,7 +42,7 @@ type CompressParam struct {
 	RenameFunc  func(string, string) string // 重命名函数 防止已经有了同名的目标文件
 	Format      CompressType                // 指定压缩格式，例如 ".zip", ".tar.gz", ".gz"
 	BufferSize  int                         // 缓冲区大小，以字节为单位
-	PoolSize    int                         // 临时对象池大小
+	PoolSize    int                         // 已废弃：缓冲区由 pool.DefaultByteBufferPool 管理，该字段不再生效
 }
 
 // DecompressParam 解压缩参数
@@ -48,14 +50,30 @@ type DecompressParam struct {
 	Source      string
 	Destination string
 	BufferSize  int // 缓冲区大小，以字节为单位
-	PoolSize    int // 临时对象池大小
+	PoolSize    int // 已废弃：缓冲区由 pool.DefaultByteBufferPool 管理，该字段不再生效
+}
+
+// bufferPool 从 pool.DefaultByteBufferPool 获取固定大小的复制缓冲区
+type bufferPool struct {
+	size int
+}
+
+// Get 获取缓冲区
+func (p bufferPool) Get() []byte {
+	return pool.DefaultByteBufferPool.Get(p.size)
+}
+
+// Put 归还缓冲区
+func (p bufferPool) Put(buf []byte) {
+	pool.DefaultByteBufferPool.Put(buf)
 }
 
 // FileCompressor 文件压缩实例
 type FileCompressor struct{}
 
 // Compress 文件压缩方法
-func (fc *FileCompressor) Compress(param *CompressParam) error {
+// 压缩数据写入并同步到磁盘、压缩文件成功关闭后才返回 nil，调用方可以据此安全地删除源文件
+func (fc *FileCompressor) Compress(param *CompressParam) (err error) {
 	if param.RenameFunc == nil {
 		param.RenameFunc = DefaultRenameFunc
 	}
@@ -73,50 +91,57 @@ func (fc *FileCompressor) Compress(param *CompressParam) error {
 	if err != nil {
 		return err
 	}
-	defer compressedFile.Close()
+	defer func() {
+		if closeErr := compressedFile.Close(); err == nil {
+			err = closeErr
+		}
+	}()
 	bufferSize := param.BufferSize
 	if bufferSize <= 0 {
 		bufferSize = 16 * 1024 // 默认16KB
 	}
-	poolSize := param.PoolSize
-	if poolSize <= 0 {
-		poolSize = 10 // 默认临时对象池大小
-	}
-	bufFactory := pool.NewBufferFactory(bufferSize)
-	bufPool := pool.NewBatchPool[[]byte](bufFactory, poolSize) // 显式指定泛型类型
+	bufPool := bufferPool{size: bufferSize}
 	switch ext {
 	case GzCompressType:
-		return compressGz(compressedFile, param.Source, bufPool)
+		err = compressGz(compressedFile, param.Source, bufPool)
 	case TarGzCompressType:
-		return compressTarGz(compressedFile, param.Source, bufPool)
+		err = compressTarGz(compressedFile, param.Source, bufPool)
 	case ZipCompressType:
-		return compressZip(compressedFile, param.Source, bufPool)
+		err = compressZip(compressedFile, param.Source, bufPool)
 	default:
 		return fmt.Errorf("unsupported format: %s", ext)
 	}
+	if err != nil {
+		return err
+	}
+	// 不支持 fsync 的文件（例如字符设备）返回 EINVAL，此时无法进一步保证持久化
+	if err := compressedFile.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err
+	}
+	return nil
 }
 
 // compressGz 压缩为 .gz 格式
-func compressGz(compressedFile *os.File, source string, bufPool *pool.BatchPool[[]byte]) error {
-	writer := gzip.NewWriter(compressedFile)
-	defer writer.Close()
+// gzip.Writer.Close 写入最后的数据块与校验和，其错误意味着压缩文件不完整，必须返回
+func compressGz(compressedFile io.Writer, source string, bufPool bufferPool) error {
 	file, err := os.Open(source)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	writer := gzip.NewWriter(compressedFile)
 	buf := bufPool.Get()
-	defer bufPool.Put(buf, &pool.BufferFactory{BufferSize: len(buf)})
-	_, err = io.CopyBuffer(writer, file, buf)
-	return err
+	defer bufPool.Put(buf)
+	if _, err := io.CopyBuffer(writer, file, buf); err != nil {
+		return err
+	}
+	return writer.Close()
 }
 
 // compressTarGz 压缩为 .tar.gz 格式
-func compressTarGz(compressedFile *os.File, source string, bufPool *pool.BatchPool[[]byte]) error {
+func compressTarGz(compressedFile io.Writer, source string, bufPool bufferPool) error {
 	gw := gzip.NewWriter(compressedFile)
-	defer gw.Close()
 	tw := tar.NewWriter(gw)
-	defer tw.Close()
 	var g errgroup.Group
 	err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -139,7 +164,7 @@ func compressTarGz(compressedFile *os.File, source string, bufPool *pool.BatchPo
 				}
 				defer file.Close()
 				buf := bufPool.Get()
-				defer bufPool.Put(buf, &pool.BufferFactory{BufferSize: len(buf)})
+				defer bufPool.Put(buf)
 				if _, err := io.CopyBuffer(tw, file, buf); err != nil {
 					return err
 				}
@@ -154,13 +179,15 @@ func compressTarGz(compressedFile *os.File, source string, bufPool *pool.BatchPo
 	if err := g.Wait(); err != nil {
 		return err
 	}
-	return nil
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
 }
 
 // compressZip 压缩为 .zip 格式
-func compressZip(compressedFile *os.File, source string, bufPool *pool.BatchPool[[]byte]) error {
+func compressZip(compressedFile io.Writer, source string, bufPool bufferPool) error {
 	zipWriter := zip.NewWriter(compressedFile)
-	defer zipWriter.Close()
 	var g errgroup.Group
 	err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -189,7 +216,7 @@ func compressZip(compressedFile *os.File, source string, bufPool *pool.BatchPool
 				}
 				defer file.Close()
 				buf := bufPool.Get()
-				defer bufPool.Put(buf, &pool.BufferFactory{BufferSize: len(buf)})
+				defer bufPool.Put(buf)
 				if _, err := io.CopyBuffer(writer, file, buf); err != nil {
 					return err
 				}
@@ -204,7 +231,7 @@ func compressZip(compressedFile *os.File, source string, bufPool *pool.BatchPool
 	if err := g.Wait(); err != nil {
 		return err
 	}
-	return nil
+	return zipWriter.Close()
 }
 
 // Decompress 文件解压缩方法
@@ -213,12 +240,7 @@ func (fc *FileCompressor) Decompress(param *DecompressParam) error {
 	if bufferSize <= 0 {
 		bufferSize = 16 * 1024 // 默认16KB
 	}
-	poolSize := param.PoolSize
-	if poolSize <= 0 {
-		poolSize = 10 // 默认临时对象池
-	}
-	bufFactory := pool.NewBufferFactory(bufferSize)
-	bufPool := pool.NewBatchPool[[]byte](bufFactory, poolSize)
+	bufPool := bufferPool{size: bufferSize}
 	if strings.HasSuffix(param.Source, GzCompressType) && !strings.HasSuffix(param.Source, TarGzCompressType) {
 		// 修改解压缩路径为文件路径
 		return decompressGz(param.Source, param.Destination, bufPool)
@@ -232,7 +254,7 @@ func (fc *FileCompressor) Decompress(param *DecompressParam) error {
 }
 
 // decompressGz 解压缩 .gz 格式
-func decompressGz(source, destination string, bufPool *pool.BatchPool[[]byte]) error {
+func decompressGz(source, destination string, bufPool bufferPool) error {
 	file, err := os.Open(source)
 	if err != nil {
 		return err
@@ -252,14 +274,14 @@ func decompressGz(source, destination string, bufPool *pool.BatchPool[[]byte]) e
 	defer outFile.Close()
 
 	buf := bufPool.Get()
-	defer bufPool.Put(buf, &pool.BufferFactory{BufferSize: len(buf)})
+	defer bufPool.Put(buf)
 
 	_, err = io.CopyBuffer(outFile, reader, buf)
 	return err
 }
 
 // decompressTarGz 解压缩 .tar.gz 格式
-func decompressTarGz(source, destination string, bufPool *pool.BatchPool[[]byte]) error {
+func decompressTarGz(source, destination string, bufPool bufferPool) error {
 	file, err := os.Open(source)
 	if err != nil {
 		return err
@@ -300,10 +322,10 @@ func decompressTarGz(source, destination string, bufPool *pool.BatchPool[[]byte]
 			buf := bufPool.Get()
 			if _, err := io.CopyBuffer(outFile, tr, buf); err != nil {
 				outFile.Close()
-				bufPool.Put(buf, pool.NewBufferFactory(len(buf)))
+				bufPool.Put(buf)
 				return err
 			}
-			bufPool.Put(buf, pool.NewBufferFactory(len(buf)))
+			bufPool.Put(buf)
 			outFile.Close()
 		}
 	}
@@ -311,7 +333,7 @@ func decompressTarGz(source, destination string, bufPool *pool.BatchPool[[]byte]
 }
 
 // decompressZip 解压缩 .zip 格式
-func decompressZip(source, destination string, bufPool *pool.BatchPool[[]byte]) error {
+func decompressZip(source, destination string, bufPool bufferPool) error {
 	r, err := zip.OpenReader(source)
 	if err != nil {
 		return err
@@ -348,10 +370,10 @@ func decompressZip(source, destination string, bufPool *pool.BatchPool[[]byte])
 		if _, err := io.CopyBuffer(outFile, rc, buf); err != nil {
 			outFile.Close()
 			rc.Close()
-			bufPool.Put(buf, pool.NewBufferFactory(len(buf)))
+			bufPool.Put(buf)
 			return err
 		}
-		bufPool.Put(buf, pool.NewBufferFactory(len(buf)))
+		bufPool.Put(buf)
 		outFile.Close()
 		rc.Close()
 	}
@@ -1,6 +1,7 @@
 package xfile
 
 import (
+	"fmt"
 	"os"
 )
 
@@ -26,3 +27,15 @@ func EnsureDirExists(dirPath string) error {
 	}
 	return nil
 }
+
+// DefaultRenameFunc 默认的重命名函数，返回 base+ext
+// 目标文件已存在时依次尝试 base-1+ext、base-2+ext，直到找到不存在的文件名
+func DefaultRenameFunc(base, ext string) string {
+	name := base + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
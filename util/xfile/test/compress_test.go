@@ -0,0 +1,50 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/omeyang/gokit/util/xfile"
+)
+
+func TestCompressReportsFailedClose(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	// 空文件在复制阶段不会写入任何数据，gzip 头、数据块和校验和都在 gzip.Writer.Close 时写入
+	source := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(source, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{xfile.GzCompressType, xfile.TarGzCompressType, xfile.ZipCompressType} {
+		err := (&xfile.FileCompressor{}).Compress(&xfile.CompressParam{
+			Source:      source,
+			Destination: filepath.Dir(source),
+			Format:      xfile.CompressType(format),
+			RenameFunc:  func(string, string) string { return "/dev/full" },
+		})
+		if err == nil {
+			t.Errorf("Compress(%s) to a full device should fail", format)
+		}
+	}
+}
+
+func TestCompressGz(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(source, []byte("line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := &xfile.FileCompressor{}
+	if err := c.Compress(&xfile.CompressParam{Source: source, Destination: dir, Format: xfile.GzCompressType}); err != nil {
+		t.Fatalf("Compress() error = %v", err)
+	}
+	out := t.TempDir()
+	if err := c.Decompress(&xfile.DecompressParam{Source: source + xfile.GzCompressType, Destination: filepath.Join(out, "app.log")}); err != nil {
+		t.Fatalf("Decompress() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(out, "app.log")); string(data) != "line\n" {
+		t.Errorf("decompressed = %q", data)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	otellog "go.opentelemetry.io/otel/log"
 	"gopkg.in/yaml.v3"
 
 	"github.com/omeyang/gokit/metrics/sample"
@@ -31,6 +32,9 @@ const (
 type LogConfig struct {
 	// 日志级别
 	Level LogLevel
+	// 按日志器名称覆盖的日志级别，键为名称模式，例如 "storage.*" 或 "storage.mongo"
+	// 多条规则同时命中时，前缀最长的规则生效
+	LevelOverrides map[string]LogLevel
 	// 日志编码器类型
 	Encoder EncoderType
 	// 输出写入器
@@ -43,9 +47,15 @@ type LogConfig struct {
 	EnableCaller bool
 	// 调用栈跳过的帧数
 	CallerSkip int
-	// 是否启用追踪
+	// 是否为 Error 及以上级别的日志附加当前 goroutine 的调用栈，输出在 stacktrace 字段中
+	EnableStacktrace bool
+	// 是否启用追踪，启用后带有 context 的日志会附加 context 中的 trace_id 与 span_id
 	EnableTracing bool
-	// 是否启用 Kubernetes 集成
+	// 是否将 Error 及以上级别的日志记录为当前 span 上的 exception 事件
+	EnableSpanEvents bool
+	// OpenTelemetry 日志桥接使用的 LoggerProvider，设置后日志同时导出为 OpenTelemetry 日志记录
+	LoggerProvider otellog.LoggerProvider
+	// 是否启用 Kubernetes 集成，启用后自动附加 Downward API 提供的 Pod 元数据
 	EnableKubernetes bool
 	// 采样配置
 	Sampling struct {
@@ -55,7 +65,21 @@ type LogConfig struct {
 		Rate float64
 		// 抖动时间（仅用于 JitterSampler）
 		Jitter time.Duration
+		// 每个周期内相同级别和消息的日志先全部记录的条数（仅用于 DedupSampler）
+		First int
+		// 超过 First 后每 Thereafter 条记录一条，0 表示全部丢弃（仅用于 DedupSampler）
+		Thereafter int
+		// 计数周期（仅用于 DedupSampler），或计算请求量的滑动窗口（仅用于 AdaptiveSampler）
+		Interval time.Duration
+		// 每秒目标采样数（仅用于 AdaptiveSampler）
+		Target float64
+		// 采样率下限（仅用于 AdaptiveSampler）
+		MinRate float64
+		// 采样率上限，0 表示 1（仅用于 AdaptiveSampler）
+		MaxRate float64
 	}
+	// 脱敏配置
+	Redaction RedactConfig
 	// 其他特定于实现的配置选项
 	ExtraOptions map[string]any
 }
@@ -63,12 +87,23 @@ type LogConfig struct {
 // LoadConfig 从环境变量和配置文件加载配置
 func LoadConfig(configPath string) (LogConfig, error) {
 	config := LogConfig{
-		Level:           LogLevel(os.Getenv("LOG_LEVEL")),
-		Encoder:         EncoderType(os.Getenv("LOG_ENCODER")),
-		AsyncBufferSize: getEnvInt("LOG_ASYNC_BUFFER_SIZE", 1000),
-		FlushInterval:   time.Duration(getEnvInt("LOG_FLUSH_INTERVAL", 5)) * time.Second,
-		EnableCaller:    getEnvBool("LOG_ENABLE_CALLER", false),
-		EnableTracing:   getEnvBool("LOG_ENABLE_TRACING", false),
+		Level:            LogLevel(os.Getenv("LOG_LEVEL")),
+		Encoder:          EncoderType(os.Getenv("LOG_ENCODER")),
+		AsyncBufferSize:  getEnvInt("LOG_ASYNC_BUFFER_SIZE", 1000),
+		FlushInterval:    time.Duration(getEnvInt("LOG_FLUSH_INTERVAL", 5)) * time.Second,
+		EnableCaller:     getEnvBool("LOG_ENABLE_CALLER", false),
+		EnableStacktrace: getEnvBool("LOG_ENABLE_STACKTRACE", false),
+		EnableTracing:    getEnvBool("LOG_ENABLE_TRACING", false),
+		EnableKubernetes: getEnvBool("LOG_ENABLE_KUBERNETES", false),
+	}
+
+	// 级别覆盖规则，格式为 "storage.*=DEBUG,xlog=WARN"
+	if spec, exists := os.LookupEnv("LOG_LEVEL_OVERRIDES"); exists {
+		overrides, err := ParseLevelOverrides(spec)
+		if err != nil {
+			return config, fmt.Errorf("failed to parse LOG_LEVEL_OVERRIDES: %w", err)
+		}
+		config.LevelOverrides = overrides
 	}
 
 	// 如果提供了配置文件路径，从文件中读取配置
@@ -0,0 +1,140 @@
+package xlog
+
+import (
+	"errors"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// 错误字段与调用栈使用的字段名
+const (
+	// ErrorKey 是 Err 生成的错误字段名
+	ErrorKey = "error"
+	// StacktraceKey 是启用 EnableStacktrace 后附加的 goroutine 调用栈字段名
+	StacktraceKey = "stacktrace"
+)
+
+// maxErrorDepth 展开错误链时的最大深度，防止错误链过长或成环
+const maxErrorDepth = 16
+
+// maxStackFrames 采集 goroutine 调用栈时的最大帧数
+const maxStackFrames = 64
+
+// StackTracer 由在创建时采集了调用栈的错误实现，例如 gokit 的错误包
+// 返回值为 runtime.Callers 采集的程序计数器
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
+// Err 创建字段名为 "error" 的错误字段
+// 输出时展开 errors.Unwrap 错误链与 errors.Join 合并的错误，并附带实现了 StackTracer 的错误采集的调用栈
+func Err(err error) Field {
+	return NamedErr(ErrorKey, err)
+}
+
+// NamedErr 创建指定字段名的错误字段，err 为 nil 时输出 null
+func NamedErr(key string, err error) Field {
+	return Field{Key: key, Value: errorValue{err: err}}
+}
+
+// errorValue 将错误展开为结构化字段
+type errorValue struct {
+	err error
+}
+
+// LogValue 实现 slog.LogValuer
+// 输出 msg、type、stack，错误链中被包装的错误按顺序放在 chain 中，
+// errors.Join 合并的错误放在对应节点的 errors 中
+func (v errorValue) LogValue() slog.Value {
+	if v.err == nil {
+		return slog.AnyValue(nil)
+	}
+	return slog.GroupValue(errorAttrs(v.err, 0)...)
+}
+
+// errorAttrs 展开错误及其错误链
+func errorAttrs(err error, depth int) []slog.Attr {
+	attrs := errorNode(err, depth)
+	var chain []any
+	for cause := errors.Unwrap(err); cause != nil && depth < maxErrorDepth; cause = errors.Unwrap(cause) {
+		depth++
+		chain = append(chain, logValueAny(slog.GroupValue(errorNode(cause, depth)...)))
+	}
+	if len(chain) > 0 {
+		attrs = append(attrs, slog.Any("chain", chain))
+	}
+	return attrs
+}
+
+// errorNode 输出单个错误的消息、类型、调用栈以及 errors.Join 合并的错误
+func errorNode(err error, depth int) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("msg", err.Error()),
+		slog.String("type", reflect.TypeOf(err).String()),
+	}
+	if st, ok := err.(StackTracer); ok {
+		if stack := formatStack(st.StackTrace()); stack != "" {
+			attrs = append(attrs, slog.String("stack", stack))
+		}
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok && depth < maxErrorDepth {
+		var errs []any
+		for _, e := range joined.Unwrap() {
+			if e != nil {
+				errs = append(errs, logValueAny(slog.GroupValue(errorAttrs(e, depth+1)...)))
+			}
+		}
+		attrs = append(attrs, slog.Any("errors", errs))
+	}
+	return attrs
+}
+
+// errorStack 返回错误链中最内层错误采集的调用栈，即错误最初产生的位置
+func errorStack(err error) string {
+	var stack string
+	for depth := 0; err != nil && depth <= maxErrorDepth; depth++ {
+		if st, ok := err.(StackTracer); ok {
+			if s := formatStack(st.StackTrace()); s != "" {
+				stack = s
+			}
+		}
+		err = errors.Unwrap(err)
+	}
+	return stack
+}
+
+// callerStack 采集当前 goroutine 的调用栈，skip 为需要跳过的帧数（不含 callerStack 自身）
+func callerStack(skip int) string {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(skip+2, pcs)
+	return formatStack(pcs[:n])
+}
+
+// formatStack 将程序计数器格式化为与 panic 输出一致的 "函数\n\t文件:行号" 形式
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(frame.Function)
+			b.WriteString("\n\t")
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package xlog
+
+import (
+	"log/slog"
+	"math"
+	"time"
+)
+
+// fieldKind 表示类型化字段保存的值类型
+type fieldKind uint8
+
+const (
+	fieldAny fieldKind = iota // 值保存在 Field.Value 中
+	fieldString
+	fieldInt64
+	fieldUint64
+	fieldFloat64
+	fieldBool
+	fieldDuration
+)
+
+// String 创建字符串字段
+func String(key, value string) Field {
+	return Field{Key: key, kind: fieldString, str: value}
+}
+
+// Int 创建整数字段
+func Int(key string, value int) Field {
+	return Int64(key, int64(value))
+}
+
+// Int64 创建 64 位整数字段
+func Int64(key string, value int64) Field {
+	return Field{Key: key, kind: fieldInt64, num: uint64(value)}
+}
+
+// Uint64 创建 64 位无符号整数字段
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, kind: fieldUint64, num: value}
+}
+
+// Float64 创建浮点数字段
+func Float64(key string, value float64) Field {
+	return Field{Key: key, kind: fieldFloat64, num: math.Float64bits(value)}
+}
+
+// Bool 创建布尔字段
+func Bool(key string, value bool) Field {
+	var n uint64
+	if value {
+		n = 1
+	}
+	return Field{Key: key, kind: fieldBool, num: n}
+}
+
+// Duration 创建时长字段
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, kind: fieldDuration, num: uint64(value)}
+}
+
+// Object 创建对象字段，对象在日志真正输出时才调用 LogValue 展开，
+// 被级别过滤或采样丢弃的日志不会产生展开的开销
+func Object(key string, value slog.LogValuer) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Any 创建任意类型的字段，等价于 Field{Key: key, Value: value}
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Any 返回字段的值，类型化字段会装箱为对应类型的值，Int 返回 int64
+func (f Field) Any() any {
+	if f.kind == fieldAny {
+		return f.Value
+	}
+	return f.slogValue().Any()
+}
+
+// attr 将字段转换为 slog.Attr，类型化字段不会分配内存
+func (f Field) attr() slog.Attr {
+	if f.kind == fieldAny {
+		return slog.Any(f.Key, f.Value)
+	}
+	return slog.Attr{Key: f.Key, Value: f.slogValue()}
+}
+
+// slogValue 将类型化字段的值转换为 slog.Value
+func (f Field) slogValue() slog.Value {
+	switch f.kind {
+	case fieldString:
+		return slog.StringValue(f.str)
+	case fieldInt64:
+		return slog.Int64Value(int64(f.num))
+	case fieldUint64:
+		return slog.Uint64Value(f.num)
+	case fieldFloat64:
+		return slog.Float64Value(math.Float64frombits(f.num))
+	case fieldBool:
+		return slog.BoolValue(f.num == 1)
+	case fieldDuration:
+		return slog.DurationValue(time.Duration(f.num))
+	}
+	return slog.AnyValue(f.Value)
+}
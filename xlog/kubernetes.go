@@ -0,0 +1,164 @@
+package xlog
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Kubernetes Downward API 约定的环境变量名
+const (
+	EnvPodName       = "POD_NAME"
+	EnvPodNamespace  = "POD_NAMESPACE"
+	EnvNodeName      = "NODE_NAME"
+	EnvContainerName = "CONTAINER_NAME"
+	EnvPodIP         = "POD_IP"
+	// envServiceHost 由 kubelet 注入到所有容器，用于判断是否运行在 Kubernetes 中
+	envServiceHost = "KUBERNETES_SERVICE_HOST"
+)
+
+// DefaultPodInfoDir 是 Downward API 卷的默认挂载目录
+// 目录下可包含 name、namespace、labels 文件，labels 的格式为每行一个 key="value"
+const DefaultPodInfoDir = "/etc/podinfo"
+
+// Kubernetes 元数据输出的字段名，遵循 OpenTelemetry 资源语义约定
+const (
+	K8sPodNameKey       = "k8s.pod.name"
+	K8sNamespaceKey     = "k8s.namespace.name"
+	K8sNodeNameKey      = "k8s.node.name"
+	K8sContainerNameKey = "k8s.container.name"
+	K8sPodIPKey         = "k8s.pod.ip"
+	K8sPodLabelsKey     = "k8s.pod.labels"
+)
+
+// KubernetesMetadata 保存当前 Pod 的元数据
+type KubernetesMetadata struct {
+	PodName       string
+	Namespace     string
+	NodeName      string
+	ContainerName string
+	PodIP         string
+	Labels        map[string]string
+}
+
+// kubernetesOptions 定义加载 Kubernetes 元数据的选项
+type kubernetesOptions struct {
+	fsys       fs.FS
+	podInfoDir string
+	getenv     func(string) string
+}
+
+// KubernetesOption 定义了加载 Kubernetes 元数据的可选配置函数
+type KubernetesOption func(*kubernetesOptions)
+
+// WithKubernetesFS 设置读取 Downward API 文件使用的文件系统根，便于测试时使用伪造的文件系统
+func WithKubernetesFS(fsys fs.FS) KubernetesOption {
+	return func(o *kubernetesOptions) {
+		o.fsys = fsys
+	}
+}
+
+// WithPodInfoDir 设置 Downward API 卷的挂载目录
+func WithPodInfoDir(dir string) KubernetesOption {
+	return func(o *kubernetesOptions) {
+		o.podInfoDir = dir
+	}
+}
+
+// WithKubernetesEnv 设置读取环境变量的函数
+func WithKubernetesEnv(getenv func(string) string) KubernetesOption {
+	return func(o *kubernetesOptions) {
+		o.getenv = getenv
+	}
+}
+
+// LoadKubernetesMetadata 从 Downward API 环境变量和卷文件中加载 Pod 元数据
+// 环境变量优先于卷文件；第二个返回值表示是否运行在 Kubernetes 中，不在时返回空元数据
+func LoadKubernetesMetadata(opts ...KubernetesOption) (KubernetesMetadata, bool) {
+	o := &kubernetesOptions{
+		fsys:       os.DirFS("/"),
+		podInfoDir: DefaultPodInfoDir,
+		getenv:     os.Getenv,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	dir := strings.TrimPrefix(path.Clean(o.podInfoDir), "/")
+
+	md := KubernetesMetadata{
+		PodName:       o.getenv(EnvPodName),
+		Namespace:     o.getenv(EnvPodNamespace),
+		NodeName:      o.getenv(EnvNodeName),
+		ContainerName: o.getenv(EnvContainerName),
+		PodIP:         o.getenv(EnvPodIP),
+	}
+	if md.PodName == "" {
+		md.PodName = readPodInfo(o.fsys, dir, "name")
+	}
+	if md.Namespace == "" {
+		md.Namespace = readPodInfo(o.fsys, dir, "namespace")
+	}
+	if data, err := fs.ReadFile(o.fsys, path.Join(dir, "labels")); err == nil {
+		md.Labels = parsePodInfoLabels(data)
+	}
+
+	inCluster := o.getenv(envServiceHost) != "" || md.PodName != "" || md.Namespace != "" || len(md.Labels) > 0
+	if !inCluster {
+		return KubernetesMetadata{}, false
+	}
+	// 未通过 Downward API 注入 Pod 名称时，容器主机名即为 Pod 名称
+	if md.PodName == "" {
+		md.PodName = o.getenv("HOSTNAME")
+	}
+	return md, true
+}
+
+// Fields 将元数据转换为日志字段，空值会被忽略
+func (m KubernetesMetadata) Fields() map[string]any {
+	fields := make(map[string]any, 6)
+	for key, value := range map[string]string{
+		K8sPodNameKey:       m.PodName,
+		K8sNamespaceKey:     m.Namespace,
+		K8sNodeNameKey:      m.NodeName,
+		K8sContainerNameKey: m.ContainerName,
+		K8sPodIPKey:         m.PodIP,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	if len(m.Labels) > 0 {
+		fields[K8sPodLabelsKey] = m.Labels
+	}
+	return fields
+}
+
+// readPodInfo 读取 Downward API 卷中的单值文件
+func readPodInfo(fsys fs.FS, dir, name string) string {
+	data, err := fs.ReadFile(fsys, path.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// parsePodInfoLabels 解析 Downward API 的 labels 文件，每行格式为 key="value"
+func parsePodInfoLabels(data []byte) map[string]string {
+	labels := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || key == "" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		labels[key] = value
+	}
+	return labels
+}
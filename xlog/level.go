@@ -0,0 +1,230 @@
+package xlog
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// IsValid 判断日志级别是否为已定义的级别
+func (l LogLevel) IsValid() bool {
+	_, ok := levelOrder[l]
+	return ok
+}
+
+// levelFromSlog 将写入 slog 记录的级别还原为日志级别，超出范围时取最近的级别
+func levelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level <= slog.Level(levelOrder[Debug]):
+		return Debug
+	case level >= slog.Level(levelOrder[Fatal]):
+		return Fatal
+	}
+	for l, order := range levelOrder {
+		if slog.Level(order) == level {
+			return l
+		}
+	}
+	return Info
+}
+
+// replaceLevelAttr 将处理器输出的级别字段替换为日志级别名称，例如 "INFO" 而不是 "DEBUG+1"
+func replaceLevelAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String(slog.LevelKey, string(levelFromSlog(level)))
+		}
+	}
+	return a
+}
+
+// ParseLevel 解析日志级别字符串，忽略大小写
+func ParseLevel(s string) (LogLevel, error) {
+	level := LogLevel(strings.ToUpper(strings.TrimSpace(s)))
+	if !level.IsValid() {
+		return "", fmt.Errorf("unknown log level: %q", s)
+	}
+	return level, nil
+}
+
+// ParseLevelOverrides 解析形如 "storage.*=DEBUG,xlog=WARN" 的级别覆盖规则
+func ParseLevelOverrides(spec string) (map[string]LogLevel, error) {
+	overrides := make(map[string]LogLevel)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, levelStr, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid level override %q, want pattern=LEVEL", item)
+		}
+		level, err := ParseLevel(levelStr)
+		if err != nil {
+			return nil, err
+		}
+		overrides[strings.TrimSpace(pattern)] = level
+	}
+	return overrides, nil
+}
+
+// levelRule 描述一条按日志器名称覆盖级别的规则
+// 模式支持精确名称（"storage.mongo"）、前缀通配（"storage.*"）以及全局通配（"*"）
+type levelRule struct {
+	pattern  string   // 原始模式
+	prefix   string   // 通配模式去掉 ".*" 后的前缀
+	wildcard bool     // 是否为通配模式
+	level    LogLevel // 命中后使用的级别
+}
+
+// newLevelRule 编译一条级别覆盖规则
+func newLevelRule(pattern string, level LogLevel) (levelRule, error) {
+	if !level.IsValid() {
+		return levelRule{}, fmt.Errorf("invalid level %q for pattern %q", level, pattern)
+	}
+	rule := levelRule{pattern: pattern, level: level}
+	switch {
+	case pattern == "":
+		return levelRule{}, fmt.Errorf("empty level override pattern")
+	case pattern == "*":
+		rule.wildcard = true
+	case strings.HasSuffix(pattern, ".*"):
+		rule.wildcard = true
+		rule.prefix = strings.TrimSuffix(pattern, ".*")
+	case strings.Contains(pattern, "*"):
+		return levelRule{}, fmt.Errorf("unsupported level override pattern %q", pattern)
+	}
+	return rule, nil
+}
+
+// match 判断规则是否命中指定名称
+// "storage.*" 同时命中 "storage" 本身以及其所有子日志器
+func (r levelRule) match(name string) bool {
+	if !r.wildcard {
+		return name == r.pattern
+	}
+	if r.prefix == "" {
+		return true
+	}
+	return name == r.prefix || strings.HasPrefix(name, r.prefix+".")
+}
+
+// moreSpecific 判断规则 r 是否比 other 更具体：前缀越长越具体，同前缀下精确匹配优先
+func (r levelRule) moreSpecific(other levelRule) bool {
+	if len(r.prefixOrName()) != len(other.prefixOrName()) {
+		return len(r.prefixOrName()) > len(other.prefixOrName())
+	}
+	return !r.wildcard && other.wildcard
+}
+
+func (r levelRule) prefixOrName() string {
+	if r.wildcard {
+		return r.prefix
+	}
+	return r.pattern
+}
+
+// levelTable 保存根级别与按名称覆盖的级别，由同一根日志器派生出的所有日志器共享
+type levelTable struct {
+	mu    sync.RWMutex
+	base  LogLevel
+	rules []levelRule // 按具体程度从高到低排序
+	gen   atomic.Uint64
+}
+
+// cachedLevel 缓存某个日志器解析出的级别，gen 变化时失效
+type cachedLevel struct {
+	gen   uint64
+	level LogLevel
+}
+
+// newLevelTable 根据根级别和覆盖规则创建级别表
+func newLevelTable(base LogLevel, overrides map[string]LogLevel) (*levelTable, error) {
+	t := &levelTable{base: base}
+	if err := t.replace(base, overrides); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// resolve 返回指定名称生效的级别，未命中任何规则时使用根级别
+func (t *levelTable) resolve(name string) LogLevel {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, rule := range t.rules {
+		if rule.match(name) {
+			return rule.level
+		}
+	}
+	return t.base
+}
+
+// setBase 设置根级别
+func (t *levelTable) setBase(level LogLevel) {
+	t.mu.Lock()
+	t.base = level
+	t.mu.Unlock()
+	t.gen.Add(1)
+}
+
+// set 新增或替换一条覆盖规则
+func (t *levelTable) set(pattern string, level LogLevel) error {
+	rule, err := newLevelRule(pattern, level)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	rules := make([]levelRule, 0, len(t.rules)+1)
+	for _, r := range t.rules {
+		if r.pattern != pattern {
+			rules = append(rules, r)
+		}
+	}
+	t.rules = sortRules(append(rules, rule))
+	t.mu.Unlock()
+	t.gen.Add(1)
+	return nil
+}
+
+// remove 删除一条覆盖规则
+func (t *levelTable) remove(pattern string) {
+	t.mu.Lock()
+	rules := make([]levelRule, 0, len(t.rules))
+	for _, r := range t.rules {
+		if r.pattern != pattern {
+			rules = append(rules, r)
+		}
+	}
+	t.rules = rules
+	t.mu.Unlock()
+	t.gen.Add(1)
+}
+
+// replace 整体替换根级别与覆盖规则
+func (t *levelTable) replace(base LogLevel, overrides map[string]LogLevel) error {
+	rules := make([]levelRule, 0, len(overrides))
+	for pattern, level := range overrides {
+		rule, err := newLevelRule(pattern, level)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+	t.mu.Lock()
+	t.base = base
+	t.rules = sortRules(rules)
+	t.mu.Unlock()
+	t.gen.Add(1)
+	return nil
+}
+
+// sortRules 将规则按具体程度从高到低排序
+func sortRules(rules []levelRule) []levelRule {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].moreSpecific(rules[j])
+	})
+	return rules
+}
@@ -47,9 +47,15 @@ const (
 )
 
 // Field 定义日志字段
+// 直接构造时值保存在 Value 中；通过 String、Int、Duration 等类型化构造函数创建时，
+// 值保存在未导出的字段中，记录日志时不需要装箱为 any
 type Field struct {
 	Key   string
 	Value any
+
+	kind fieldKind // 类型化字段的值类型，fieldAny 表示使用 Value
+	num  uint64    // 整数、浮点数、布尔值与时长
+	str  string    // 字符串
 }
 
 // Logger 是一个支持结构化日志和上下文的通用日志接口
@@ -88,6 +94,7 @@ type Logger interface {
 // HighPerformanceLogger 定义高性能日志接口，扩展基本 Logger
 type HighPerformanceLogger interface {
 	Logger
+	Named(name string) HighPerformanceLogger                    // 命名子日志器，名称以 "." 分隔层级
 	WithTrace(ctx context.Context) HighPerformanceLogger        // trace追踪
 	WithMetadata(metadata map[string]any) HighPerformanceLogger // 元数据, eg:k8s pod信息
 	Flush() error                                               // 释放资源
@@ -123,8 +130,8 @@ func (e *DefaultContextExtractor) Extract(ctx context.Context) map[string]string
 	// 提取 trace 信息
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
-		info["trace_id"] = span.SpanContext().TraceID().String()
-		info["span_id"] = span.SpanContext().SpanID().String()
+		info[TraceIDKey] = span.SpanContext().TraceID().String()
+		info[SpanIDKey] = span.SpanContext().SpanID().String()
 	}
 
 	// 提取预定义的键值
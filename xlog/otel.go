@@ -0,0 +1,318 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelScopeName 是桥接日志使用的 instrumentation scope 名称
+const otelScopeName = "github.com/omeyang/gokit/xlog"
+
+// 记录为 span 事件时使用的事件名与属性名，遵循 OpenTelemetry 语义约定
+const (
+	exceptionEventName     = "exception"
+	exceptionTypeKey       = "exception.type"
+	exceptionMessageKey    = "exception.message"
+	exceptionStacktraceKey = "exception.stacktrace"
+	logSeverityKey         = "log.severity"
+	logMessageKey          = "log.message"
+)
+
+// recordSpanEvent 将日志记录为 span 上的 exception 事件，attrs[i] 是 fields[i] 脱敏后的字段
+// 字段中的第一个 error 作为异常类型和消息，没有 error 时使用日志消息；错误采集了调用栈时一并记录。
+// 启用脱敏时错误字段已被转换为脱敏后的值，异常类型与调用栈取自原始错误，消息按字段规则脱敏
+func recordSpanEvent(span trace.Span, level LogLevel, msg string, fields []Field, attrs []slog.Attr, redactor *Redactor) {
+	if !span.IsRecording() {
+		return
+	}
+	var err error
+	message := msg
+	kvs := make([]attribute.KeyValue, 0, len(attrs)+5)
+	for i, f := range fields {
+		if err == nil {
+			if err = fieldError(f); err != nil {
+				message = err.Error()
+				if redactor != nil {
+					message = fmt.Sprint(redactor.Redact(f.Key, message))
+				}
+				continue
+			}
+		}
+		kvs = append(kvs, otelAttribute(attrs[i].Key, attrs[i].Value))
+	}
+	if err != nil {
+		kvs = append(kvs, attribute.String(exceptionTypeKey, reflect.TypeOf(err).String()))
+		if stack := errorStack(err); stack != "" {
+			kvs = append(kvs, attribute.String(exceptionStacktraceKey, stack))
+		}
+	}
+	kvs = append(kvs,
+		attribute.String(exceptionMessageKey, message),
+		attribute.String(logSeverityKey, string(level)),
+		attribute.String(logMessageKey, msg),
+	)
+	span.AddEvent(exceptionEventName, trace.WithAttributes(kvs...))
+}
+
+// fieldError 返回字段中的错误，字段不是错误时返回 nil
+func fieldError(f Field) error {
+	value := f.Any()
+	if ev, ok := value.(errorValue); ok {
+		return ev.err
+	}
+	err, _ := slog.AnyValue(value).Resolve().Any().(error)
+	return err
+}
+
+// otelAttribute 将 slog 字段值转换为 span 属性
+func otelAttribute(key string, v slog.Value) attribute.KeyValue {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return attribute.String(key, v.String())
+	case slog.KindInt64:
+		return attribute.Int64(key, v.Int64())
+	case slog.KindUint64:
+		return attribute.Int64(key, int64(v.Uint64()))
+	case slog.KindFloat64:
+		return attribute.Float64(key, v.Float64())
+	case slog.KindBool:
+		return attribute.Bool(key, v.Bool())
+	case slog.KindDuration:
+		return attribute.String(key, v.Duration().String())
+	case slog.KindTime:
+		return attribute.String(key, v.Time().Format(time.RFC3339Nano))
+	default:
+		return attribute.String(key, fmt.Sprintf("%+v", v.Any()))
+	}
+}
+
+// OTelHandler 是将日志记录导出为 OpenTelemetry 日志记录的 slog.Handler
+// 记录中的 trace_id、span_id 字段会还原为日志记录的链路上下文，从而与链路数据关联
+type OTelHandler struct {
+	logger otellog.Logger
+	attrs  []otellog.KeyValue
+	groups []string
+}
+
+// NewOTelHandler 使用指定的 LoggerProvider 创建 OTelHandler
+func NewOTelHandler(provider otellog.LoggerProvider) *OTelHandler {
+	return &OTelHandler{
+		logger: provider.Logger(otelScopeName),
+	}
+}
+
+// Enabled 判断指定级别的日志是否会被导出
+func (h *OTelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	var record otellog.Record
+	record.SetSeverity(otelSeverity(levelFromSlog(level)))
+	return h.logger.Enabled(ctx, record)
+}
+
+// Handle 将 slog 记录转换为 OpenTelemetry 日志记录并导出
+func (h *OTelHandler) Handle(ctx context.Context, r slog.Record) error {
+	level := levelFromSlog(r.Level)
+	var record otellog.Record
+	record.SetTimestamp(r.Time)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(otelSeverity(level))
+	record.SetSeverityText(string(level))
+	record.SetBody(otellog.StringValue(r.Message))
+
+	var traceID, spanID string
+	kvs := make([]otellog.KeyValue, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		switch {
+		case a.Key == TraceIDKey && len(h.groups) == 0:
+			traceID = a.Value.String()
+		case a.Key == SpanIDKey && len(h.groups) == 0:
+			spanID = a.Value.String()
+		default:
+			kvs = append(kvs, otelKeyValue(a))
+		}
+		return true
+	})
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		kvs = []otellog.KeyValue{otellog.Map(h.groups[i], kvs...)}
+	}
+	record.AddAttributes(h.attrs...)
+	record.AddAttributes(kvs...)
+
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = contextWithSpan(ctx, traceID, spanID)
+	}
+	h.logger.Emit(ctx, record)
+	return nil
+}
+
+// WithAttrs 返回附加了字段的新处理器
+func (h *OTelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	kvs := make([]otellog.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		kvs = append(kvs, otelKeyValue(a))
+	}
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		kvs = []otellog.KeyValue{otellog.Map(h.groups[i], kvs...)}
+	}
+	return &OTelHandler{
+		logger: h.logger,
+		attrs:  append(append([]otellog.KeyValue{}, h.attrs...), kvs...),
+		groups: h.groups,
+	}
+}
+
+// WithGroup 返回在指定分组下记录字段的新处理器
+func (h *OTelHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &OTelHandler{
+		logger: h.logger,
+		attrs:  h.attrs,
+		groups: append(append([]string{}, h.groups...), name),
+	}
+}
+
+// contextWithSpan 根据十六进制的 trace ID 和 span ID 构造带链路上下文的 context
+func contextWithSpan(ctx context.Context, traceID, spanID string) context.Context {
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return ctx
+	}
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil {
+		return ctx
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid})
+	return trace.ContextWithSpanContext(ctx, sc)
+}
+
+// otelSeverity 将日志级别映射为 OpenTelemetry 日志严重性
+func otelSeverity(level LogLevel) otellog.Severity {
+	switch level {
+	case Debug:
+		return otellog.SeverityDebug
+	case Info:
+		return otellog.SeverityInfo
+	case Warn:
+		return otellog.SeverityWarn
+	case Error:
+		return otellog.SeverityError
+	case Fatal:
+		return otellog.SeverityFatal
+	default:
+		return otellog.SeverityUndefined
+	}
+}
+
+// otelKeyValue 将 slog 字段转换为 OpenTelemetry 日志字段
+func otelKeyValue(a slog.Attr) otellog.KeyValue {
+	return otellog.KeyValue{Key: a.Key, Value: otelValue(a.Value)}
+}
+
+// otelValue 将 slog 值转换为 OpenTelemetry 日志值
+func otelValue(v slog.Value) otellog.Value {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return otellog.StringValue(v.String())
+	case slog.KindInt64:
+		return otellog.Int64Value(v.Int64())
+	case slog.KindUint64:
+		return otellog.Int64Value(int64(v.Uint64()))
+	case slog.KindFloat64:
+		return otellog.Float64Value(v.Float64())
+	case slog.KindBool:
+		return otellog.BoolValue(v.Bool())
+	case slog.KindDuration:
+		return otellog.Int64Value(int64(v.Duration()))
+	case slog.KindTime:
+		return otellog.Int64Value(v.Time().UnixNano())
+	case slog.KindGroup:
+		attrs := v.Group()
+		kvs := make([]otellog.KeyValue, 0, len(attrs))
+		for _, a := range attrs {
+			kvs = append(kvs, otelKeyValue(a))
+		}
+		return otellog.MapValue(kvs...)
+	}
+	return otelAnyValue(v.Any())
+}
+
+// otelAnyValue 转换 slog.KindAny 中的值
+func otelAnyValue(value any) otellog.Value {
+	switch x := value.(type) {
+	case nil:
+		return otellog.Value{}
+	case []byte:
+		return otellog.BytesValue(x)
+	case error:
+		return otellog.StringValue(x.Error())
+	case fmt.Stringer:
+		return otellog.StringValue(x.String())
+	case map[string]any:
+		kvs := make([]otellog.KeyValue, 0, len(x))
+		for k, item := range x {
+			kvs = append(kvs, otellog.KeyValue{Key: k, Value: otelValue(slog.AnyValue(item))})
+		}
+		return otellog.MapValue(kvs...)
+	case []any:
+		values := make([]otellog.Value, 0, len(x))
+		for _, item := range x {
+			values = append(values, otelValue(slog.AnyValue(item)))
+		}
+		return otellog.SliceValue(values...)
+	default:
+		return otellog.StringValue(fmt.Sprintf("%+v", x))
+	}
+}
+
+// multiHandler 将日志记录分发给多个 slog.Handler
+type multiHandler []slog.Handler
+
+// Enabled 任一处理器启用即返回 true
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle 依次调用所有启用的处理器，合并返回的错误
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs 对所有处理器附加字段
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+// WithGroup 对所有处理器设置分组
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
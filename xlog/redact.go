@@ -0,0 +1,397 @@
+package xlog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaskMode 定义脱敏方式
+type MaskMode string
+
+const (
+	// MaskFull 完全遮盖，不保留长度信息
+	MaskFull MaskMode = "full"
+	// MaskPartial 部分遮盖，保留首尾少量字符便于排查
+	MaskPartial MaskMode = "partial"
+	// MaskHash 输出加盐后的 sha256 摘要前缀，相同的值得到相同的结果，便于关联
+	MaskHash MaskMode = "hash"
+)
+
+// maskedValue 完全遮盖时输出的内容
+const maskedValue = "******"
+
+// maxRedactDepth 遍历嵌套结构的最大深度，防止循环引用
+const maxRedactDepth = 8
+
+// depthExceededValue 超过最大深度的值无法逐层检查，整体替换为该内容
+const depthExceededValue = "[REDACTED: max depth]"
+
+// RedactRule 定义一条脱敏规则
+// Key 与 Pattern 二选一：Key 按字段名匹配（忽略大小写，嵌套字段同样生效），Pattern 按正则匹配字符串内容
+type RedactRule struct {
+	Key     string
+	Pattern string
+	Mode    MaskMode
+}
+
+// RedactConfig 定义日志脱敏配置
+type RedactConfig struct {
+	// 是否启用脱敏
+	Enabled bool
+	// 是否启用内置规则：password、token 等常见字段名，以及邮箱、手机号、JWT、Bearer 令牌检测
+	Builtin bool
+	// 自定义规则
+	Rules []RedactRule
+	// 规则未指定 Mode 时使用的脱敏方式，默认为 MaskFull
+	DefaultMode MaskMode
+	// MaskHash 使用的盐
+	HashSalt string
+}
+
+// RedactValuer 由需要自定义脱敏输出的类型实现，与 slog.LogValuer 类似
+// 脱敏时使用 RedactValue 的返回值代替原值，返回值会继续按规则处理
+type RedactValuer interface {
+	RedactValue() any
+}
+
+// builtinKeys 内置的敏感字段名
+var builtinKeys = []string{
+	"password", "passwd", "pwd", "secret", "token", "access_token", "refresh_token",
+	"authorization", "api_key", "apikey", "private_key", "cookie",
+}
+
+// builtinPatterns 内置的敏感内容检测规则
+var builtinPatterns = []RedactRule{
+	{Pattern: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`, Mode: MaskPartial},   // 邮箱
+	{Pattern: `\b1[3-9]\d{9}\b`, Mode: MaskPartial},                                  // 手机号
+	{Pattern: `\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`, Mode: MaskFull}, // JWT
+	{Pattern: `(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`, Mode: MaskFull},                // Bearer 令牌
+}
+
+// redactDetector 编译后的内容检测规则
+type redactDetector struct {
+	re   *regexp.Regexp
+	mode MaskMode
+}
+
+// Redactor 对日志字段做脱敏处理，可并发使用
+type Redactor struct {
+	keys      map[string]MaskMode
+	detectors []redactDetector
+	salt      string
+}
+
+// NewRedactor 根据配置创建 Redactor
+func NewRedactor(config RedactConfig) (*Redactor, error) {
+	defaultMode := config.DefaultMode
+	if defaultMode == "" {
+		defaultMode = MaskFull
+	}
+	r := &Redactor{
+		keys: make(map[string]MaskMode),
+		salt: config.HashSalt,
+	}
+	rules := config.Rules
+	if config.Builtin {
+		for _, key := range builtinKeys {
+			r.keys[key] = MaskFull
+		}
+		rules = append(append([]RedactRule{}, builtinPatterns...), rules...)
+	}
+	for _, rule := range rules {
+		mode := rule.Mode
+		if mode == "" {
+			mode = defaultMode
+		}
+		if err := mode.validate(); err != nil {
+			return nil, err
+		}
+		switch {
+		case rule.Key != "" && rule.Pattern != "":
+			return nil, fmt.Errorf("redact rule must set either key or pattern, got both")
+		case rule.Key != "":
+			r.keys[strings.ToLower(rule.Key)] = mode
+		case rule.Pattern != "":
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redact pattern %q: %w", rule.Pattern, err)
+			}
+			r.detectors = append(r.detectors, redactDetector{re: re, mode: mode})
+		default:
+			return nil, fmt.Errorf("redact rule must set key or pattern")
+		}
+	}
+	return r, nil
+}
+
+// validate 校验脱敏方式
+func (m MaskMode) validate() error {
+	switch m {
+	case MaskFull, MaskPartial, MaskHash:
+		return nil
+	default:
+		return fmt.Errorf("unknown mask mode: %q", m)
+	}
+}
+
+// RedactField 对单个字段脱敏
+func (r *Redactor) RedactField(f Field) Field {
+	// 类型化的数值字段不会包含敏感内容，只有字段名命中时才需要脱敏
+	if f.kind != fieldAny && f.kind != fieldString {
+		if _, ok := r.keys[strings.ToLower(f.Key)]; !ok {
+			return f
+		}
+	}
+	return Field{Key: f.Key, Value: r.Redact(f.Key, f.Any())}
+}
+
+// Redact 按字段名和内容对值脱敏，嵌套的 map、切片和结构体会被递归处理
+// 结构体字段可以通过 `redact:"full|partial|hash"` 标签强制脱敏
+func (r *Redactor) Redact(key string, value any) any {
+	if mode, ok := r.keys[strings.ToLower(key)]; ok {
+		return r.maskAny(value, mode)
+	}
+	return r.walk(value, 0)
+}
+
+// walk 递归处理值
+func (r *Redactor) walk(value any, depth int) any {
+	if depth > maxRedactDepth {
+		return depthExceededValue
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case json.Number:
+		return v
+	case RedactValuer:
+		return r.walk(v.RedactValue(), depth+1)
+	case slog.LogValuer:
+		return r.walk(logValueAny(v.LogValue().Resolve()), depth+1)
+	case string:
+		return r.scan(v)
+	case error:
+		return r.scan(v.Error())
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = r.walkKey(k, item, depth+1)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = r.walk(item, depth+1)
+		}
+		return out
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	}
+	return r.walkReflect(reflect.ValueOf(value), depth)
+}
+
+// walkKey 处理嵌套结构中带字段名的值
+func (r *Redactor) walkKey(key string, value any, depth int) any {
+	if mode, ok := r.keys[strings.ToLower(key)]; ok {
+		return r.maskAny(value, mode)
+	}
+	return r.walk(value, depth)
+}
+
+// walkReflect 通过反射处理 map、切片、指针和结构体
+func (r *Redactor) walkReflect(rv reflect.Value, depth int) any {
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return r.walk(rv.Elem().Interface(), depth+1)
+	case reflect.Map:
+		out := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := mapKeyString(iter.Key())
+			out[k] = r.walkKey(k, iter.Value().Interface(), depth+1)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Interface() // []byte 原样输出
+		}
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = r.walk(rv.Index(i).Interface(), depth+1)
+		}
+		return out
+	case reflect.Struct:
+		v := rv.Interface()
+		switch v.(type) {
+		// 不会包含敏感内容的常见类型保持原样
+		case time.Time, netip.Addr, netip.AddrPort, netip.Prefix:
+			return v
+		case json.Marshaler, encoding.TextMarshaler, fmt.Stringer:
+			return r.walkRendered(v, depth)
+		}
+		return r.walkStruct(rv, depth)
+	case reflect.String:
+		return r.scan(rv.String())
+	default:
+		return rv.Interface()
+	}
+}
+
+// walkRendered 处理自定义了序列化方式的结构体：按 JSON 处理器的优先级渲染后再脱敏，
+// MarshalJSON 的结果解析后递归处理，文本形式的结果使用内容检测规则处理
+func (r *Redactor) walkRendered(v any, depth int) any {
+	switch m := v.(type) {
+	case json.Marshaler:
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return r.scan(err.Error())
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var decoded any
+		if err := dec.Decode(&decoded); err != nil {
+			return r.scan(string(data))
+		}
+		return r.walk(decoded, depth+1)
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		if err != nil {
+			return r.scan(err.Error())
+		}
+		return r.scan(string(text))
+	default:
+		return r.scan(fmt.Sprint(v))
+	}
+}
+
+// mapKeyString 按 encoding/json 的规则将 map 的键转换为字符串
+func mapKeyString(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if text, err := tm.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(k.Interface())
+}
+
+// walkStruct 将结构体转换为 map 并逐个字段处理，字段名遵循 json 标签
+func (r *Redactor) walkStruct(rv reflect.Value, depth int) any {
+	rt := rv.Type()
+	out := make(map[string]any, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		value := rv.Field(i).Interface()
+		if mode := MaskMode(field.Tag.Get("redact")); mode != "" && mode.validate() == nil {
+			out[name] = r.maskAny(value, mode)
+			continue
+		}
+		out[name] = r.walkKey(name, value, depth+1)
+	}
+	return out
+}
+
+// scan 使用内容检测规则替换字符串中的敏感内容
+func (r *Redactor) scan(s string) string {
+	for _, d := range r.detectors {
+		s = d.re.ReplaceAllStringFunc(s, func(match string) string {
+			return r.mask(match, d.mode)
+		})
+	}
+	return s
+}
+
+// maskAny 按指定方式遮盖任意值
+func (r *Redactor) maskAny(value any, mode MaskMode) any {
+	if value == nil {
+		return nil
+	}
+	if mode == MaskFull {
+		return maskedValue
+	}
+	if s, ok := value.(string); ok {
+		return r.mask(s, mode)
+	}
+	return r.mask(fmt.Sprint(value), mode)
+}
+
+// mask 按指定方式遮盖字符串
+func (r *Redactor) mask(s string, mode MaskMode) string {
+	switch mode {
+	case MaskPartial:
+		return maskPartial(s)
+	case MaskHash:
+		sum := sha256.Sum256([]byte(r.salt + s))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	default:
+		return maskedValue
+	}
+}
+
+// Mask 按指定方式遮盖字符串，供 RedactValuer 的实现使用；MaskHash 不加盐
+func Mask(s string, mode MaskMode) string {
+	return (&Redactor{}).mask(s, mode)
+}
+
+// maskPartial 部分遮盖：邮箱保留本地部分首字符和域名，手机号等长数字保留前 3 位和后 4 位，
+// 其他内容保留首尾各约四分之一
+func maskPartial(s string) string {
+	if local, domain, ok := strings.Cut(s, "@"); ok && local != "" && domain != "" {
+		first, _ := utf8.DecodeRuneInString(local)
+		return string(first) + strings.Repeat("*", utf8.RuneCountInString(local)-1) + "@" + domain
+	}
+	if len(s) >= 8 && strings.Trim(s, "0123456789") == "" {
+		return s[:3] + strings.Repeat("*", len(s)-7) + s[len(s)-4:]
+	}
+	runes := []rune(s)
+	keep := len(runes) / 4
+	if keep > 4 {
+		keep = 4
+	}
+	if keep == 0 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:keep]) + strings.Repeat("*", len(runes)-2*keep) + string(runes[len(runes)-keep:])
+}
+
+// logValueAny 将 slog.Value 转换为普通值，分组转换为 map
+func logValueAny(v slog.Value) any {
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+	attrs := v.Group()
+	out := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		out[a.Key] = logValueAny(a.Value.Resolve())
+	}
+	return out
+}
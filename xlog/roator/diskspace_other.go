@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package roator
+
+import "errors"
+
+// freeSpace 在不支持 syscall.Statfs 的平台上不可用，最小剩余空间检查将被跳过
+func freeSpace(string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd
+
+package roator
+
+import "syscall"
+
+// freeSpace 返回 dir 所在文件系统对非特权用户可用的字节数
+func freeSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
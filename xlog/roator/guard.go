@@ -0,0 +1,115 @@
+package roator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// guardCheckInterval 是两次写入触发的磁盘空间检查之间的最小间隔，轮转时总会检查
+const guardCheckInterval = time.Second
+
+// diskGuard 保存磁盘空间保护的状态
+type diskGuard struct {
+	nextCheck     time.Time // 下一次检查的时间
+	tripped       bool      // 是否处于空间不足状态
+	dropping      bool      // 是否正在丢弃日志
+	dropped       uint64    // 累计丢弃的字节数
+	droppedAtTrip uint64    // 本次进入空间不足状态时已丢弃的字节数
+}
+
+// guardEnabled 判断是否配置了磁盘空间保护
+func (c RotatorConfig) guardEnabled() bool {
+	return c.MaxTotalSize > 0 || c.MinFreeSpace > 0
+}
+
+// freeSpace 返回日志目录所在文件系统的剩余字节数
+func (c RotatorConfig) freeSpace() (uint64, error) {
+	dir := filepath.Dir(c.Filename)
+	if c.FreeSpace != nil {
+		return c.FreeSpace(dir)
+	}
+	return freeSpace(dir)
+}
+
+// DroppedBytes 返回因磁盘空间不足而丢弃的日志字节数
+func (r *NativeRotator) DroppedBytes() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.guard.dropped
+}
+
+// checkDiskLocked 检查磁盘空间，必要时删除最旧的备份，并在状态变化时输出自诊断信息
+// 调用方需持有锁
+func (r *NativeRotator) checkDiskLocked(now time.Time) {
+	if !r.config.guardEnabled() {
+		return
+	}
+	r.guard.nextCheck = now.Add(guardCheckInterval)
+	reason := r.evictLocked()
+	switch {
+	case reason != "" && !r.guard.tripped:
+		r.guard.tripped = true
+		r.guard.dropping = r.config.DropOnDiskFull
+		r.guard.droppedAtTrip = r.guard.dropped
+		action := "continuing to write"
+		if r.guard.dropping {
+			action = "dropping logs until space is available"
+		}
+		r.diagnose(now, "disk guard tripped for %s: %s, %s", r.filename, reason, action)
+	case reason == "" && r.guard.tripped:
+		r.diagnose(now, "disk guard recovered for %s, %d bytes dropped", r.filename, r.guard.dropped-r.guard.droppedAtTrip)
+		r.guard.tripped = false
+		r.guard.dropping = false
+	}
+}
+
+// evictLocked 从最旧的备份开始删除，直到满足总大小上限和最小剩余空间
+// 删除所有备份后仍不满足时返回原因，调用方需持有锁
+func (r *NativeRotator) evictLocked() string {
+	backups, err := r.config.listBackups(r.filename)
+	if err != nil {
+		return ""
+	}
+	maxTotal := int64(r.config.MaxTotalSize) * 1024 * 1024
+	minFree := uint64(r.config.MinFreeSpace) * 1024 * 1024
+	total := r.size
+	for _, b := range backups {
+		total += b.size
+	}
+	free, freeErr := uint64(0), error(nil)
+	if minFree > 0 {
+		free, freeErr = r.config.freeSpace()
+	}
+
+	for {
+		over := maxTotal > 0 && total > maxTotal
+		// 无法获取剩余空间时跳过最小剩余空间检查
+		low := minFree > 0 && freeErr == nil && free < minFree
+		switch {
+		case !over && !low:
+			return ""
+		case len(backups) == 0 && over:
+			return fmt.Sprintf("total size %d bytes exceeds limit %d bytes", total, maxTotal)
+		case len(backups) == 0:
+			return fmt.Sprintf("free space %d bytes below minimum %d bytes", free, minFree)
+		}
+		oldest := backups[len(backups)-1]
+		backups = backups[:len(backups)-1]
+		if err := removeFile(oldest.path); err != nil {
+			continue
+		}
+		total -= oldest.size
+		free += uint64(oldest.size)
+	}
+}
+
+// diagnose 输出轮转器的自诊断信息
+func (r *NativeRotator) diagnose(now time.Time, format string, args ...any) {
+	out := r.config.ErrorOutput
+	if out == nil {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, "%s roator: %s\n", now.Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
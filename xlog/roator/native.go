@@ -0,0 +1,422 @@
+package roator
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omeyang/gokit/metrics"
+	"github.com/omeyang/gokit/util/xfile"
+)
+
+// DefaultSymlinkName 是指向当前日志文件的软链接的默认名称
+const DefaultSymlinkName = "current"
+
+// compressSuffix 是压缩后备份文件的后缀
+const compressSuffix = xfile.GzCompressType
+
+// NativeRotator 是不依赖第三方库的日志轮转器，支持按大小和按时间轮转
+// 所有方法都可以并发调用；备份文件的压缩与清理在后台协程中完成
+type NativeRotator struct {
+	mu       sync.Mutex
+	config   RotatorConfig
+	schedule *Schedule // 按时间轮转的计划
+	file     *os.File  // 当前写入的文件
+	filename string    // 当前写入的文件名
+	size     int64     // 当前文件大小
+	next     time.Time // 下一次按时间轮转的时间
+	retryAt  time.Time // 写入时轮转失败后，下一次重试轮转的时间
+	guard    diskGuard // 磁盘空间保护的状态
+	closed   bool
+
+	droppedStat metrics.Counter // 因磁盘空间不足丢弃的字节数
+
+	millCh chan struct{}  // 通知后台协程处理备份文件
+	wg     sync.WaitGroup // 等待后台协程退出
+}
+
+// rotateRetryInterval 是写入时轮转失败后到下一次重试之间的间隔
+const rotateRetryInterval = time.Second
+
+// NewNativeRotator 创建一个新的原生日志轮转器
+func NewNativeRotator(config RotatorConfig) (LogRotator, error) {
+	schedule, err := parseConfigSchedule(config)
+	if err != nil {
+		return nil, err
+	}
+	r := &NativeRotator{
+		config:   config,
+		schedule: schedule,
+		millCh:   make(chan struct{}, 1),
+		droppedStat: metrics.Default().Counter(metrics.Opts{
+			Name: "gokit_xlog_rotator_dropped_bytes_total",
+			Help: "Bytes of log output dropped by the native rotator because the disk was full.",
+		}),
+	}
+	now := config.now()
+	if err := r.openLocked(now); err != nil {
+		return nil, err
+	}
+	r.checkDiskLocked(now)
+	r.wg.Add(1)
+	go r.millRun()
+	// 处理上次运行遗留的备份文件
+	r.triggerMill()
+	return r, nil
+}
+
+// GetWriter 返回一个 io.Writer，可以用于写入日志
+func (r *NativeRotator) GetWriter() (io.Writer, error) {
+	return r, nil
+}
+
+// Write 写入日志，写入前按时间、写入后按大小检查是否需要轮转
+// 轮转失败时输出自诊断信息并继续写入当前文件，rotateRetryInterval 之后再重试；
+// 磁盘空间不足且配置了 DropOnDiskFull 时，日志被丢弃并视为写入成功
+func (r *NativeRotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	now := r.config.now()
+	if !r.next.IsZero() && !now.Before(r.next) && !now.Before(r.retryAt) {
+		r.tryRotateLocked(now)
+	} else if r.config.guardEnabled() && !now.Before(r.guard.nextCheck) {
+		r.checkDiskLocked(now)
+	}
+	if r.guard.dropping {
+		r.guard.dropped += uint64(len(p))
+		r.droppedStat.Add(float64(len(p)))
+		return len(p), nil
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if r.size > r.config.maxSizeBytes() && !now.Before(r.retryAt) {
+		r.tryRotateLocked(now)
+	}
+	return n, nil
+}
+
+// Rotate 手动触发日志轮转
+func (r *NativeRotator) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	return r.rotateLocked()
+}
+
+// Filename 返回当前写入的文件名
+func (r *NativeRotator) Filename() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.filename
+}
+
+// Close 关闭当前文件，并等待后台的压缩与清理完成
+func (r *NativeRotator) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	err := r.file.Close()
+	close(r.millCh)
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return err
+}
+
+// tryRotateLocked 在写入时轮转，失败时输出自诊断信息并推迟重试，调用方需持有锁
+func (r *NativeRotator) tryRotateLocked(now time.Time) {
+	if err := r.rotateLocked(); err != nil {
+		r.retryAt = now.Add(rotateRetryInterval)
+		r.diagnose(now, "rotate %s failed, continuing to write the current file: %v", r.filename, err)
+	}
+}
+
+// rotateLocked 打开新文件后关闭当前文件，调用方需持有锁
+// 文件名不随时间变化时，当前文件先被重命名为带时间戳的备份；
+// 重命名或打开新文件失败时恢复原文件名，当前文件保持打开，之后的写入不受影响
+func (r *NativeRotator) rotateLocked() error {
+	now := r.config.now()
+	old, oldName := r.file, r.filename
+	backup := ""
+	if r.config.activeFilename(now) == oldName {
+		backup = backupFilename(oldName, now)
+		if err := os.Rename(oldName, backup); err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+			backup = ""
+		}
+	}
+	if err := r.openLocked(now); err != nil {
+		if backup != "" {
+			_ = os.Rename(backup, oldName)
+		}
+		return err
+	}
+	// 新文件已经打开，关闭旧文件的错误不影响之后的写入
+	_ = old.Close()
+	r.retryAt = time.Time{}
+	r.checkDiskLocked(now)
+	r.triggerMill()
+	return nil
+}
+
+// openLocked 打开 now 时刻对应的日志文件，调用方需持有锁
+func (r *NativeRotator) openLocked(now time.Time) error {
+	filename := r.config.activeFilename(now)
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	r.file = file
+	r.filename = filename
+	r.size = info.Size()
+	r.next = nextRotation(r.schedule, now)
+	// 软链接只是便于查看的辅助手段，更新失败不影响日志写入
+	_ = r.updateSymlink(filename)
+	return nil
+}
+
+// updateSymlink 原子地将软链接指向当前日志文件
+func (r *NativeRotator) updateSymlink(filename string) error {
+	link := r.config.symlinkPath()
+	if link == "" || link == filename {
+		return nil
+	}
+	tmp := link + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(filepath.Base(filename), tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, link)
+}
+
+// triggerMill 通知后台协程处理备份文件，已有待处理的通知时直接返回
+func (r *NativeRotator) triggerMill() {
+	select {
+	case r.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// millRun 在后台压缩并清理备份文件，直到 Close 被调用
+func (r *NativeRotator) millRun() {
+	defer r.wg.Done()
+	for range r.millCh {
+		_ = r.millOnce()
+	}
+}
+
+// millOnce 按 MaxBackups 和 MaxAge 删除过期的备份文件，并压缩剩余的未压缩备份
+func (r *NativeRotator) millOnce() error {
+	r.mu.Lock()
+	active := r.filename
+	r.mu.Unlock()
+
+	remaining, err := r.config.removeExpiredBackups(active)
+	if !r.config.Compress {
+		return err
+	}
+	errs := []error{err}
+	for _, b := range remaining {
+		if !b.compressed {
+			errs = append(errs, compressFile(b.path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backupFile 描述一个轮转产生的备份文件
+type backupFile struct {
+	path       string
+	period     time.Time // 设置 FilenamePattern 时文件所属时间段的开始时间
+	timestamp  time.Time // 轮转时间，时间段的日志文件为时间段的开始时间
+	size       int64
+	compressed bool
+	periodFile bool // 是否为按 FilenamePattern 命名的时间段日志文件
+}
+
+// listBackups 列出 Filename 所在目录下的备份文件，按时间从新到旧排序
+// 备份文件包括带时间戳后缀的文件，以及设置 FilenamePattern 时此前时间段的日志文件
+func (c RotatorConfig) listBackups(active string) ([]backupFile, error) {
+	dir := filepath.Dir(c.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []backupFile
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if path == active {
+			continue
+		}
+		name, compressed := strings.CutSuffix(e.Name(), compressSuffix)
+		backup, ok := c.parseBackup(name)
+		if !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backup.path = path
+		backup.size = info.Size()
+		backup.compressed = compressed
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].newerThan(backups[j])
+	})
+	return backups, nil
+}
+
+// LogFiles 按时间从旧到新返回 config 对应的所有日志文件，包括轮转产生的备份（含压缩文件）和当前日志文件
+// 设置 FilenamePattern 时，最新的按模板命名的文件被视为当前日志文件
+func LogFiles(config RotatorConfig) ([]string, error) {
+	backups, err := config.listBackups("")
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(backups)+1)
+	for i := len(backups) - 1; i >= 0; i-- {
+		files = append(files, backups[i].path)
+	}
+	if config.FilenamePattern == "" {
+		if _, err := os.Stat(config.Filename); err == nil {
+			files = append(files, config.Filename)
+		}
+	}
+	return files, nil
+}
+
+// removeExpiredBackups 按 MaxBackups 和 MaxAge 删除过期的备份文件，返回保留下来的备份
+func (c RotatorConfig) removeExpiredBackups(active string) ([]backupFile, error) {
+	backups, err := c.listBackups(active)
+	if err != nil {
+		return nil, err
+	}
+	var cutoff time.Time
+	if c.MaxAge > 0 {
+		cutoff = c.now().Add(-time.Duration(c.MaxAge) * 24 * time.Hour)
+	}
+	var errs []error
+	remaining := backups[:0]
+	for i, b := range backups {
+		expired := !cutoff.IsZero() && b.timestamp.Before(cutoff)
+		if expired || (c.MaxBackups > 0 && i >= c.MaxBackups) {
+			errs = append(errs, removeFile(b.path))
+			continue
+		}
+		remaining = append(remaining, b)
+	}
+	return remaining, errors.Join(errs...)
+}
+
+// parseBackup 从文件名中解析出备份信息，文件名不属于本轮转器时返回 false
+func (c RotatorConfig) parseBackup(name string) (backupFile, bool) {
+	loc := c.location()
+	// 带时间戳后缀的备份：<文件名>.<时间戳>
+	if i := len(name) - len(backupTimeFormat) - 1; i > 0 && name[i] == '.' {
+		prefix, suffix := name[:i], name[i+1:]
+		if t, err := time.ParseInLocation(backupTimeFormat, suffix, loc); err == nil {
+			if c.FilenamePattern == "" && prefix == filepath.Base(c.Filename) {
+				return backupFile{timestamp: t}, true
+			}
+			if period, err := time.ParseInLocation(c.FilenamePattern, prefix, loc); c.FilenamePattern != "" && err == nil {
+				return backupFile{period: period, timestamp: t}, true
+			}
+		}
+	}
+	// 按 FilenamePattern 生成的此前时间段的日志文件
+	if c.FilenamePattern != "" {
+		if period, err := time.ParseInLocation(c.FilenamePattern, name, loc); err == nil {
+			return backupFile{period: period, timestamp: period, periodFile: true}, true
+		}
+	}
+	return backupFile{}, false
+}
+
+// newerThan 判断备份 b 中的日志是否比 other 中的新
+// 同一时间段内，按大小轮转出的备份早于该时间段的日志文件本身
+func (b backupFile) newerThan(other backupFile) bool {
+	if !b.period.Equal(other.period) {
+		return b.period.After(other.period)
+	}
+	if b.periodFile != other.periodFile {
+		return b.periodFile
+	}
+	return b.timestamp.After(other.timestamp)
+}
+
+// location 返回轮转时间使用的时区
+func (c RotatorConfig) location() *time.Location {
+	if c.LocalTime {
+		return time.Local
+	}
+	return time.UTC
+}
+
+// symlinkPath 返回软链接的完整路径，SymlinkName 为 "-" 时不创建软链接
+func (c RotatorConfig) symlinkPath() string {
+	switch c.SymlinkName {
+	case "-":
+		return ""
+	case "":
+		return filepath.Join(filepath.Dir(c.Filename), DefaultSymlinkName)
+	default:
+		return filepath.Join(filepath.Dir(c.Filename), c.SymlinkName)
+	}
+}
+
+// compressFile 使用 gzip 压缩备份文件，成功后删除原文件
+func compressFile(path string) error {
+	compressor := &xfile.FileCompressor{}
+	err := compressor.Compress(&xfile.CompressParam{
+		Source:      path,
+		Destination: filepath.Dir(path),
+		Format:      xfile.GzCompressType,
+		// 上次压缩中断时可能留下不完整的压缩文件，直接覆盖
+		RenameFunc: func(base, ext string) string { return base + ext },
+	})
+	if err != nil {
+		_ = os.Remove(path + compressSuffix)
+		return err
+	}
+	return removeFile(path)
+}
+
+// removeFile 删除文件，文件不存在时不返回错误
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
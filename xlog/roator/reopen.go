@@ -0,0 +1,171 @@
+package roator
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultReopenInterval 是检查日志文件是否被替换的默认间隔
+const defaultReopenInterval = time.Second
+
+// ReopenRotator 是配合系统 logrotate 等外部工具使用的轮转器，自身不做轮转
+// 检测到日志文件被替换（inode 变化或文件被删除）、调用 Reopen 或在启用 ReopenOnSIGHUP 时收到 SIGHUP 时重新打开日志文件，
+// 因此同时适用于 logrotate 的 create 与 copytruncate 模式；所有方法都可以并发调用
+type ReopenRotator struct {
+	mu        sync.Mutex
+	config    RotatorConfig
+	file      *os.File    // 当前写入的文件
+	info      os.FileInfo // 打开文件时的文件信息，用于判断文件是否被替换
+	nextCheck time.Time   // 下一次检查文件是否被替换的时间
+
+	signals chan os.Signal // 未启用 ReopenOnSIGHUP 时为 nil
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewReopenRotator 创建一个在文件被替换时重新打开日志文件的轮转器
+// 只使用配置中的 Filename、ReopenInterval 和 ReopenOnSIGHUP，轮转与清理交由外部工具完成
+func NewReopenRotator(config RotatorConfig) (LogRotator, error) {
+	r := &ReopenRotator{
+		config: config,
+		done:   make(chan struct{}),
+	}
+	file, info, err := r.openFile()
+	if err != nil {
+		return nil, err
+	}
+	r.file = file
+	r.info = info
+	r.nextCheck = config.now().Add(r.reopenInterval())
+	if config.ReopenOnSIGHUP {
+		r.signals = make(chan os.Signal, 1)
+		signal.Notify(r.signals, syscall.SIGHUP)
+		r.wg.Add(1)
+		go r.watchSignals()
+	}
+	return r, nil
+}
+
+// GetWriter 返回一个 io.Writer，可以用于写入日志
+func (r *ReopenRotator) GetWriter() (io.Writer, error) {
+	return r, nil
+}
+
+// Write 写入日志，到达检查间隔时先检查日志文件是否被替换
+// copytruncate 模式下文件被原地截断，由于以追加模式打开，后续写入会从文件开头继续
+func (r *ReopenRotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if now := r.config.now(); !now.Before(r.nextCheck) {
+		r.nextCheck = now.Add(r.reopenInterval())
+		if r.replacedLocked() {
+			if err := r.reopenLocked(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return r.file.Write(p)
+}
+
+// Rotate 重新打开日志文件，文件的重命名由外部工具完成
+func (r *ReopenRotator) Rotate() error {
+	return r.Reopen()
+}
+
+// Reopen 关闭并重新打开日志文件
+func (r *ReopenRotator) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return os.ErrClosed
+	}
+	return r.reopenLocked()
+}
+
+// Close 停止监听信号并关闭当前文件，重复调用是安全的
+func (r *ReopenRotator) Close() error {
+	r.mu.Lock()
+	if r.file == nil {
+		r.mu.Unlock()
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.mu.Unlock()
+
+	if r.signals != nil {
+		signal.Stop(r.signals)
+	}
+	close(r.done)
+	r.wg.Wait()
+	return err
+}
+
+// watchSignals 收到 SIGHUP 时重新打开日志文件
+func (r *ReopenRotator) watchSignals() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.signals:
+			_ = r.Reopen()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// replacedLocked 判断日志文件是否已被重命名、删除或替换，调用方需持有锁
+func (r *ReopenRotator) replacedLocked() bool {
+	info, err := os.Stat(r.config.Filename)
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(info, r.info)
+}
+
+// reopenLocked 重新打开日志文件，调用方需持有锁
+// 新文件打开失败时继续写入原来的文件
+func (r *ReopenRotator) reopenLocked() error {
+	file, info, err := r.openFile()
+	if err != nil {
+		return err
+	}
+	_ = r.file.Close()
+	r.file = file
+	r.info = info
+	r.nextCheck = r.config.now().Add(r.reopenInterval())
+	return nil
+}
+
+// openFile 以追加模式打开日志文件
+func (r *ReopenRotator) openFile() (*os.File, os.FileInfo, error) {
+	if err := os.MkdirAll(filepath.Dir(r.config.Filename), 0o755); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.OpenFile(r.config.Filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// reopenInterval 返回检查日志文件是否被替换的间隔
+func (r *ReopenRotator) reopenInterval() time.Duration {
+	if r.config.ReopenInterval > 0 {
+		return r.config.ReopenInterval
+	}
+	return defaultReopenInterval
+}
@@ -2,6 +2,9 @@ package roator
 
 import (
 	"io"
+	"os"
+	"path/filepath"
+	"time"
 )
 
 // LogRotator 定义日志轮转接口
@@ -31,6 +34,112 @@ type RotatorConfig struct {
 	// Compress 确定是否应该使用 gzip 压缩轮转的日志文件
 	// 默认是不压缩
 	Compress bool
+
+	// Schedule 是按时间轮转的计划，可以是 "hourly"、"daily" 或五段式 cron 表达式（分 时 日 月 周）
+	// 与 MaxSize 同时生效，任一条件满足即轮转；默认不按时间轮转
+	// 基于 lumberjack 的轮转器不支持该选项
+	Schedule string
+
+	// LocalTime 确定轮转计划与文件名中的时间是否使用本地时间
+	// 默认使用 UTC
+	LocalTime bool
+
+	// FilenamePattern 是当前日志文件名的时间模板，使用 Go 时间格式，例如 "app-2006-01-02.log"
+	// 设置后当前文件名按打开文件的时间生成，放在 Filename 所在的目录下；默认直接使用 Filename
+	FilenamePattern string
+
+	// SymlinkName 是指向当前日志文件的软链接名称，放在 Filename 所在的目录下
+	// 默认为 "current"，设置为 "-" 时不创建软链接；仅原生轮转器支持该选项
+	SymlinkName string
+
+	// MaxTotalSize 是当前日志文件与所有备份的总大小上限（以MB为单位）
+	// 超出时从最旧的备份开始删除；默认不限制，仅原生轮转器支持该选项
+	MaxTotalSize int
+
+	// MinFreeSpace 是日志所在文件系统需要保留的最小剩余空间（以MB为单位）
+	// 低于该值时从最旧的备份开始删除；默认不检查，仅原生轮转器支持该选项
+	MinFreeSpace int
+
+	// DropOnDiskFull 确定删除所有备份后仍超出 MaxTotalSize 或低于 MinFreeSpace 时是否丢弃日志
+	// 默认继续写入；空间恢复后自动恢复写入
+	DropOnDiskFull bool
+
+	// FreeSpace 返回目录所在文件系统的剩余字节数，默认使用 syscall.Statfs，便于测试时注入
+	FreeSpace func(dir string) (uint64, error)
+
+	// ErrorOutput 是轮转器输出自诊断信息的位置，默认是 os.Stderr
+	ErrorOutput io.Writer
+
+	// ReopenInterval 是可重新打开的轮转器检查日志文件是否被外部工具替换的间隔
+	// 默认是 1 秒，仅 ReopenRotator 支持该选项
+	ReopenInterval time.Duration
+
+	// ReopenOnSIGHUP 确定可重新打开的轮转器是否在收到 SIGHUP 时重新打开日志文件
+	// 默认不监听信号，避免影响进程中其他 SIGHUP 处理逻辑；也可以自行监听信号后调用 Reopen，
+	// 仅 ReopenRotator 支持该选项
+	ReopenOnSIGHUP bool
+
+	// Now 返回当前时间，默认是 time.Now，便于测试时注入时钟
+	Now func() time.Time
+}
+
+// now 返回配置时区下的当前时间
+func (c RotatorConfig) now() time.Time {
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+	if c.LocalTime {
+		return now().Local()
+	}
+	return now().UTC()
+}
+
+// maxSizeBytes 返回以字节为单位的大小上限，未设置时为 100MB
+func (c RotatorConfig) maxSizeBytes() int64 {
+	if c.MaxSize <= 0 {
+		return 100 * 1024 * 1024
+	}
+	return int64(c.MaxSize) * 1024 * 1024
+}
+
+// activeFilename 返回 t 时刻应当写入的文件名
+func (c RotatorConfig) activeFilename(t time.Time) string {
+	if c.FilenamePattern == "" {
+		return c.Filename
+	}
+	return filepath.Join(filepath.Dir(c.Filename), t.Format(c.FilenamePattern))
+}
+
+// backupFilename 返回轮转后备份文件的文件名
+// 同一毫秒内多次轮转时顺延时间戳，避免覆盖已有的备份；无法判断文件是否存在时由重命名报告错误
+func backupFilename(filename string, t time.Time) string {
+	for {
+		name := filename + "." + t.Format(backupTimeFormat)
+		if _, err := os.Lstat(name); err != nil {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// backupTimeFormat 是备份文件名中的时间格式，精确到毫秒
+const backupTimeFormat = "2006-01-02_15-04-05.000"
+
+// nextRotation 根据轮转计划计算下一次轮转时间，没有计划时返回零值
+func nextRotation(schedule *Schedule, t time.Time) time.Time {
+	if schedule == nil {
+		return time.Time{}
+	}
+	return schedule.Next(t)
+}
+
+// parseConfigSchedule 解析配置中的轮转计划，未配置时返回 nil
+func parseConfigSchedule(config RotatorConfig) (*Schedule, error) {
+	if config.Schedule == "" {
+		return nil, nil
+	}
+	return ParseSchedule(config.Schedule)
 }
 
 // RotatorFactory 定义创建轮转器的工厂函数类型
@@ -0,0 +1,147 @@
+package roator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 预定义的轮转周期
+const (
+	// ScheduleHourly 每小时整点轮转
+	ScheduleHourly = "hourly"
+	// ScheduleDaily 每天零点轮转
+	ScheduleDaily = "daily"
+)
+
+// maxScheduleSearch 计算下一次轮转时间时最多向后查找的时长
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// Schedule 表示按时间轮转的计划，由五段式 cron 表达式（分 时 日 月 周）描述
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // 每一位表示对应的值是否命中
+	domAny, dowAny                bool   // 日和周是否为 "*"
+}
+
+// cronField 描述 cron 表达式中一个字段的取值范围
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// ParseSchedule 解析轮转计划，支持 "hourly"、"daily" 和五段式 cron 表达式
+// cron 字段支持 "*"、数字、列表 "1,15"、范围 "1-5" 以及步长 "*/10"，周日用 0 表示
+func ParseSchedule(spec string) (*Schedule, error) {
+	switch strings.ToLower(strings.TrimSpace(spec)) {
+	case ScheduleHourly:
+		spec = "0 * * * *"
+	case ScheduleDaily:
+		spec = "0 0 * * *"
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid schedule %q: want 5 fields, got %d", spec, len(parts))
+	}
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		bits[i] = b
+	}
+	return &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}, nil
+}
+
+// parseCronField 解析单个 cron 字段
+func parseCronField(expr string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepExpr, field.name)
+			}
+			step = n
+		}
+		lo, hi := field.min, field.max
+		if rangeExpr != "*" {
+			loStr, hiStr, isRange := strings.Cut(rangeExpr, "-")
+			var err error
+			if lo, err = strconv.Atoi(loStr); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s", loStr, field.name)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiStr); err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s", hiStr, field.name)
+				}
+			} else if hasStep {
+				hi = field.max
+			}
+		}
+		if lo < field.min || hi > field.max || lo > hi {
+			return 0, fmt.Errorf("%s out of range: %q", field.name, item)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next 返回严格晚于 t 的下一次轮转时间，使用 t 所在的时区；找不到时返回零值
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxScheduleSearch)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 判断日期是否命中，日和周都受限时任一命中即可，与标准 cron 一致
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/xlog/roator"
+)
+
+// dirSize 返回目录下所有普通文件的总大小
+func dirSize(t *testing.T, dir string) int64 {
+	t.Helper()
+	var total int64
+	for _, name := range listDir(t, dir) {
+		info, err := os.Lstat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+func TestDiskGuardMaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	var diag bytes.Buffer
+	r := newNativeRotator(t, roator.RotatorConfig{
+		Filename:     filepath.Join(dir, "app.log"),
+		MaxSize:      1,
+		MaxTotalSize: 3,
+		SymlinkName:  "-",
+		ErrorOutput:  &diag,
+		Now:          clock.Now,
+	})
+
+	chunk := bytes.Repeat([]byte("x"), 100*1024)
+	for i := 0; i < 80; i++ {
+		clock.Advance(time.Second)
+		if _, err := r.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if size := dirSize(t, dir); size > 3*1024*1024 {
+		t.Errorf("total size = %d, want <= 3MB", size)
+	}
+	names := listDir(t, dir)
+	if len(names) < 2 {
+		t.Fatalf("files = %v, want active file and backups", names)
+	}
+	// 保留下来的应当是最新的备份
+	if last := names[len(names)-1]; last < "app.log.2024-06-01_00-01-00" {
+		t.Errorf("newest backup %s was evicted", last)
+	}
+	if diag.Len() != 0 {
+		t.Errorf("unexpected diagnostic: %s", diag.String())
+	}
+}
+
+func TestDiskGuardMinFreeSpaceDropMode(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	var free atomic.Uint64
+	free.Store(1 << 30)
+	var diag bytes.Buffer
+	r := newNativeRotator(t, roator.RotatorConfig{
+		Filename:       filepath.Join(dir, "app.log"),
+		MinFreeSpace:   100,
+		DropOnDiskFull: true,
+		SymlinkName:    "-",
+		ErrorOutput:    &diag,
+		FreeSpace:      func(string) (uint64, error) { return free.Load(), nil },
+		Now:            clock.Now,
+	})
+
+	write(t, r, "before")
+	if err := r.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "kept")
+
+	// 剩余空间不足：删除所有备份后仍不满足，进入丢弃模式
+	free.Store(10 << 20)
+	clock.Advance(2 * time.Second)
+	write(t, r, "dropped")
+	if got := listDir(t, dir); len(got) != 1 || got[0] != "app.log" {
+		t.Errorf("files = %v, want backups evicted", got)
+	}
+	if got := r.DroppedBytes(); got != uint64(len("dropped\n")) {
+		t.Errorf("DroppedBytes() = %d", got)
+	}
+	if !strings.Contains(diag.String(), "disk guard tripped") || !strings.Contains(diag.String(), "dropping logs") {
+		t.Errorf("diagnostic = %q", diag.String())
+	}
+
+	// 检查间隔内不重复检查，空间恢复后下一次检查时恢复写入
+	free.Store(1 << 30)
+	write(t, r, "still dropped")
+	clock.Advance(2 * time.Second)
+	write(t, r, "resumed")
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "kept\nresumed\n" {
+		t.Errorf("active file = %q", data)
+	}
+	if !strings.Contains(diag.String(), "disk guard recovered") || !strings.Contains(diag.String(), "22 bytes dropped") {
+		t.Errorf("diagnostic = %q", diag.String())
+	}
+}
+
+func TestDiskGuardWithoutDropMode(t *testing.T) {
+	dir := t.TempDir()
+	var diag bytes.Buffer
+	r := newNativeRotator(t, roator.RotatorConfig{
+		Filename:     filepath.Join(dir, "app.log"),
+		MinFreeSpace: 100,
+		SymlinkName:  "-",
+		ErrorOutput:  &diag,
+		FreeSpace:    func(string) (uint64, error) { return 0, nil },
+	})
+	write(t, r, "written anyway")
+	if r.DroppedBytes() != 0 {
+		t.Errorf("DroppedBytes() = %d, want 0", r.DroppedBytes())
+	}
+	if strings.Count(diag.String(), "disk guard tripped") != 1 || !strings.Contains(diag.String(), "continuing to write") {
+		t.Errorf("diagnostic = %q", diag.String())
+	}
+}
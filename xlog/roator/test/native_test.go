@@ -0,0 +1,291 @@
+package test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/xlog/roator"
+)
+
+// newNativeRotator 创建原生轮转器，测试结束时自动关闭
+func newNativeRotator(t *testing.T, config roator.RotatorConfig) *roator.NativeRotator {
+	t.Helper()
+	r, err := roator.NewNativeRotator(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nr := r.(*roator.NativeRotator)
+	t.Cleanup(func() { _ = nr.Close() })
+	return nr
+}
+
+// countLines 统计目录下所有日志文件（包括 .gz）中的行数
+func countLines(t *testing.T, dir string) int {
+	t.Helper()
+	total := 0
+	for _, name := range listDir(t, dir) {
+		path := filepath.Join(dir, name)
+		if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reader io.Reader = f
+		if strings.HasSuffix(name, ".gz") {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			reader = gz
+		}
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			total++
+		}
+		_ = f.Close()
+	}
+	return total
+}
+
+func TestNativeRotatorConcurrentWrites(t *testing.T) {
+	dir := t.TempDir()
+	r := newNativeRotator(t, roator.RotatorConfig{
+		Filename: filepath.Join(dir, "app.log"),
+		MaxSize:  1,
+		Compress: true,
+	})
+
+	const writers, lines = 8, 2000
+	payload := strings.Repeat("x", 200)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < lines; j++ {
+				if _, err := fmt.Fprintf(r, "%d-%d %s\n", id, j, payload); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := countLines(t, dir); got != writers*lines {
+		t.Errorf("lines = %d, want %d", got, writers*lines)
+	}
+	var compressed int
+	for _, name := range listDir(t, dir) {
+		if strings.HasSuffix(name, ".gz") {
+			compressed++
+		} else if strings.HasPrefix(name, "app.log.") {
+			t.Errorf("backup %s was not compressed", name)
+		}
+	}
+	if compressed == 0 {
+		t.Error("expected compressed backups")
+	}
+}
+
+func TestNativeRotatorRetention(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)}
+	// 上次运行遗留的备份，压缩与未压缩的备份按时间统一排序，其他日志器的备份不受影响
+	old := []string{
+		"app.log.2024-06-09_10-00-00.000",
+		"app.log.2024-06-09_09-00-00.000.gz",
+		"app.log.2024-06-08_23-00-00.000",
+		"app.log.2024-05-01_00-00-00.000",
+		"other.log.2024-06-09_10-00-00.000",
+	}
+	for _, name := range old {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("old\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := newNativeRotator(t, roator.RotatorConfig{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxBackups: 3,
+		MaxAge:     7,
+		Now:        clock.Now,
+	})
+	write(t, r, "current")
+	if err := r.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"app.log",
+		"app.log.2024-06-09_09-00-00.000.gz",
+		"app.log.2024-06-09_10-00-00.000",
+		"app.log.2024-06-10_12-00-00.000",
+		"current",
+		"other.log.2024-06-09_10-00-00.000",
+	}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestNativeRotatorSymlink(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 23, 0, 0, 0, time.UTC)}
+	r := newNativeRotator(t, roator.RotatorConfig{
+		Filename:        filepath.Join(dir, "app.log"),
+		FilenamePattern: "app-2006-01-02.log",
+		Schedule:        roator.ScheduleDaily,
+		Now:             clock.Now,
+	})
+	link := filepath.Join(dir, roator.DefaultSymlinkName)
+	if target, err := os.Readlink(link); err != nil || target != "app-2024-06-01.log" {
+		t.Fatalf("symlink = %q, %v", target, err)
+	}
+
+	clock.Advance(2 * time.Hour)
+	write(t, r, "next day")
+	if target, err := os.Readlink(link); err != nil || target != "app-2024-06-02.log" {
+		t.Fatalf("symlink = %q, %v", target, err)
+	}
+	data, err := os.ReadFile(link)
+	if err != nil || string(data) != "next day\n" {
+		t.Errorf("read through symlink = %q, %v", data, err)
+	}
+	if r.Filename() != filepath.Join(dir, "app-2024-06-02.log") {
+		t.Errorf("Filename() = %s", r.Filename())
+	}
+}
+
+func TestNativeRotatorPatternRetention(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+	r := newNativeRotator(t, roator.RotatorConfig{
+		Filename:        filepath.Join(dir, "app.log"),
+		FilenamePattern: "app-2006-01-02.log",
+		Schedule:        roator.ScheduleDaily,
+		SymlinkName:     "-",
+		MaxBackups:      2,
+		Compress:        true,
+		Now:             clock.Now,
+	})
+	for i := 0; i < 4; i++ {
+		write(t, r, "day")
+		clock.Advance(24 * time.Hour)
+	}
+	write(t, r, "last")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app-2024-06-03.log.gz", "app-2024-06-04.log.gz", "app-2024-06-05.log"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestNativeRotatorClosed(t *testing.T) {
+	r := newNativeRotator(t, roator.RotatorConfig{Filename: filepath.Join(t.TempDir(), "app.log")})
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("x")); err == nil {
+		t.Error("expected error writing to closed rotator")
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+}
+
+func TestNativeRotatorRotateFailure(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 22, 0, 0, 0, time.UTC)}
+	var diag strings.Builder
+	r := newNativeRotator(t, roator.RotatorConfig{
+		Filename:        filepath.Join(dir, "app.log"),
+		FilenamePattern: "app-2006-01-02.log",
+		Schedule:        roator.ScheduleDaily,
+		SymlinkName:     "-",
+		ErrorOutput:     &diag,
+		Now:             clock.Now,
+	})
+	// 下一个时间段的文件名被目录占用，轮转时无法打开新文件
+	blocked := filepath.Join(dir, "app-2024-06-02.log")
+	if err := os.Mkdir(blocked, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "day one")
+	clock.Advance(3 * time.Hour)
+	if err := r.Rotate(); err == nil {
+		t.Fatal("Rotate() should fail while the new file cannot be opened")
+	}
+	write(t, r, "after failed rotation")
+	if !strings.Contains(diag.String(), "rotate") {
+		t.Errorf("diagnostics = %q, want the rotation failure reported", diag.String())
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "app-2024-06-01.log"))
+	if string(data) != "day one\nafter failed rotation\n" {
+		t.Errorf("current file = %q, writes should continue after a failed rotation", data)
+	}
+
+	// 恢复后再次轮转成功
+	if err := os.Remove(blocked); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Second)
+	write(t, r, "day two")
+	if data, _ := os.ReadFile(blocked); string(data) != "day two\n" {
+		t.Errorf("new file = %q", data)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestNativeRotatorRenameFailure(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	r := newNativeRotator(t, roator.RotatorConfig{Filename: filename, SymlinkName: "-", ErrorOutput: io.Discard})
+	write(t, r, "before")
+	// 日志目录被替换为文件，重命名与打开新文件都会失败
+	if err := os.Rename(dir, dir+".moved"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir + ".moved") })
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Rotate(); err == nil {
+		t.Fatal("Rotate() should fail")
+	}
+	if _, err := r.Write([]byte("still open\n")); err != nil {
+		t.Errorf("Write() after failed rotation error = %v", err)
+	}
+	// Close 仍然停止后台协程并返回
+	done := make(chan error)
+	go func() { done <- r.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close() did not return")
+	}
+}
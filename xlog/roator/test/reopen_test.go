@@ -0,0 +1,189 @@
+package test
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/xlog/roator"
+)
+
+// newReopenRotator 创建可重新打开的轮转器，测试结束时自动关闭
+func newReopenRotator(t *testing.T, config roator.RotatorConfig) *roator.ReopenRotator {
+	t.Helper()
+	r, err := roator.NewReopenRotator(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := r.(*roator.ReopenRotator)
+	t.Cleanup(func() { _ = rr.Close() })
+	return rr
+}
+
+// readFile 读取文件内容
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestReopenOnInodeChange(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	r := newReopenRotator(t, roator.RotatorConfig{Filename: filename, Now: clock.Now})
+
+	write(t, r, "before")
+	// 模拟 logrotate 的 create 模式：重命名后创建新文件
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "within interval")
+	clock.Advance(2 * time.Second)
+	write(t, r, "after")
+
+	if got := readFile(t, filename+".1"); got != "before\nwithin interval\n" {
+		t.Errorf("rotated file = %q", got)
+	}
+	if got := readFile(t, filename); got != "after\n" {
+		t.Errorf("new file = %q", got)
+	}
+}
+
+func TestReopenRecreatesDeletedFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	r := newReopenRotator(t, roator.RotatorConfig{Filename: filename, ReopenInterval: time.Minute, Now: clock.Now})
+
+	write(t, r, "before")
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	write(t, r, "after")
+	if got := readFile(t, filename); got != "after\n" {
+		t.Errorf("file = %q", got)
+	}
+}
+
+func TestReopenCopyTruncate(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	r := newReopenRotator(t, roator.RotatorConfig{Filename: filename})
+
+	write(t, r, "before")
+	// 模拟 logrotate 的 copytruncate 模式：复制后原地截断
+	if err := os.WriteFile(filename+".1", []byte(readFile(t, filename)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(filename, 0); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "after")
+	if got := readFile(t, filename); got != "after\n" {
+		t.Errorf("file = %q", got)
+	}
+}
+
+func TestReopenOnSIGHUP(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP is not supported on windows")
+	}
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	r := newReopenRotator(t, roator.RotatorConfig{Filename: filename, ReopenInterval: time.Hour, ReopenOnSIGHUP: true})
+
+	write(t, r, "before")
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filename); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file was not reopened after SIGHUP")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	write(t, r, "after")
+	if got := readFile(t, filename); got != "after\n" {
+		t.Errorf("new file = %q", got)
+	}
+	if got := readFile(t, filename+".1"); got != "before\n" {
+		t.Errorf("rotated file = %q", got)
+	}
+}
+
+func TestReopenIgnoresSIGHUPByDefault(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP is not supported on windows")
+	}
+	// 未启用 ReopenOnSIGHUP 时不注册信号处理，由调用方自行监听信号并调用 Reopen
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	r := newReopenRotator(t, roator.RotatorConfig{Filename: filename, ReopenInterval: time.Hour})
+	write(t, r, "before")
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-hup:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGHUP was not delivered")
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Fatalf("file should not be reopened without ReopenOnSIGHUP, Stat() error = %v", err)
+	}
+
+	if err := r.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "after")
+	if got := readFile(t, filename); got != "after\n" {
+		t.Errorf("new file = %q", got)
+	}
+}
+
+func TestReopenClosed(t *testing.T) {
+	r := newReopenRotator(t, roator.RotatorConfig{Filename: filepath.Join(t.TempDir(), "app.log")})
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("x")); err == nil {
+		t.Error("expected error writing to closed rotator")
+	}
+	if err := r.Reopen(); err == nil {
+		t.Error("expected error reopening closed rotator")
+	}
+}
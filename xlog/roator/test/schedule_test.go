@@ -0,0 +1,53 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/xlog/roator"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	base := time.Date(2024, 6, 1, 15, 4, 5, 0, time.UTC) // 周六
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{roator.ScheduleHourly, time.Date(2024, 6, 1, 16, 0, 0, 0, time.UTC)},
+		{roator.ScheduleDaily, time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 6, 1, 15, 15, 0, 0, time.UTC)},
+		{"30 2 * * 1-5", time.Date(2024, 6, 3, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 0", time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)}, // 日与周任一命中
+	}
+	for _, c := range cases {
+		s, err := roator.ParseSchedule(c.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) error = %v", c.spec, err)
+		}
+		if got := s.Next(base); !got.Equal(c.want) {
+			t.Errorf("ParseSchedule(%q).Next() = %v, want %v", c.spec, got, c.want)
+		}
+	}
+}
+
+func TestScheduleNextUsesLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	s, err := roator.ParseSchedule(roator.ScheduleDaily)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Next(time.Date(2024, 6, 1, 23, 0, 0, 0, time.UTC).In(loc))
+	if want := time.Date(2024, 6, 3, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{"", "weekly", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := roator.ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) expected error", spec)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/xlog/roator"
+)
+
+// fakeClock 是可以手动推进的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// listDir 返回目录下排序后的文件名
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// write 通过轮转器写入一行
+func write(t *testing.T, r roator.LogRotator, line string) {
+	t.Helper()
+	w, err := r.GetWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(line + "\n")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDailyRotationWithFilenamePattern(t *testing.T) {
+	factories := map[string]roator.RotatorFactory{
+		"native": roator.NewNativeRotator,
+		"zap":    roator.NewZapRotator,
+	}
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			clock := &fakeClock{now: time.Date(2024, 6, 1, 22, 0, 0, 0, time.UTC)}
+			r, err := factory(roator.RotatorConfig{
+				Filename:        filepath.Join(dir, "app.log"),
+				FilenamePattern: "app-2006-01-02.log",
+				Schedule:        roator.ScheduleDaily,
+				SymlinkName:     "-",
+				MaxSize:         1,
+				Now:             clock.Now,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			write(t, r, "day one")
+			clock.Advance(3 * time.Hour)
+			write(t, r, "day two")
+
+			got := listDir(t, dir)
+			want := []string{"app-2024-06-01.log", "app-2024-06-02.log"}
+			if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+				t.Fatalf("files = %v, want %v", got, want)
+			}
+			data, _ := os.ReadFile(filepath.Join(dir, "app-2024-06-02.log"))
+			if string(data) != "day two\n" {
+				t.Errorf("second file = %q", data)
+			}
+		})
+	}
+}
+
+func TestHourlyRotationRenamesBackup(t *testing.T) {
+	dir := t.TempDir()
+	loc := time.FixedZone("CST", 8*3600)
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 10, 30, 0, 0, loc)}
+	r, err := roator.NewNativeRotator(roator.RotatorConfig{
+		Filename:    filepath.Join(dir, "app.log"),
+		Schedule:    roator.ScheduleHourly,
+		SymlinkName: "-",
+		Now:         clock.Now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "first")
+	clock.Advance(45 * time.Minute)
+	write(t, r, "second")
+
+	// 默认使用 UTC，10:30 CST 之后的整点为 03:00 UTC
+	got := listDir(t, dir)
+	if len(got) != 2 || got[0] != "app.log" || got[1] != "app.log.2024-06-01_03-15-00.000" {
+		t.Fatalf("files = %v", got)
+	}
+}
+
+func TestSizeAndScheduleCombined(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 8, 0, 0, 0, time.Local)}
+	r, err := roator.NewNativeRotator(roator.RotatorConfig{
+		Filename:        filepath.Join(dir, "app.log"),
+		FilenamePattern: "app-2006-01-02.log",
+		Schedule:        roator.ScheduleDaily,
+		LocalTime:       true,
+		SymlinkName:     "-",
+		MaxSize:         1,
+		Now:             clock.Now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	big := make([]byte, 1024*1024+1)
+	w, _ := r.GetWriter()
+	if _, err := w.Write(big); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Second)
+	write(t, r, "after size rotation")
+
+	got := listDir(t, dir)
+	if len(got) != 2 || got[0] != "app-2024-06-01.log" || got[1] != "app-2024-06-01.log.2024-06-01_08-00-00.000" {
+		t.Fatalf("files = %v", got)
+	}
+}
+
+func TestZapRotatorRotateFailure(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{now: time.Date(2024, 6, 1, 22, 0, 0, 0, time.UTC)}
+	r, err := roator.NewZapRotator(roator.RotatorConfig{
+		Filename:        filepath.Join(dir, "app.log"),
+		FilenamePattern: "app-2006-01-02.log",
+		Schedule:        roator.ScheduleDaily,
+		Now:             clock.Now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 下一个时间段的文件名被目录占用，轮转时无法打开新文件
+	blocked := filepath.Join(dir, "app-2024-06-02.log")
+	if err := os.Mkdir(blocked, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "day one")
+	clock.Advance(3 * time.Hour)
+	if err := r.Rotate(); err == nil {
+		t.Fatal("Rotate() should fail while the new file cannot be opened")
+	}
+	write(t, r, "after failed rotation")
+	data, _ := os.ReadFile(filepath.Join(dir, "app-2024-06-01.log"))
+	if string(data) != "day one\nafter failed rotation\n" {
+		t.Errorf("current file = %q, writes should continue after a failed rotation", data)
+	}
+
+	if err := os.Remove(blocked); err != nil {
+		t.Fatal(err)
+	}
+	write(t, r, "day two")
+	if data, _ := os.ReadFile(blocked); string(data) != "day two\n" {
+		t.Errorf("new file = %q", data)
+	}
+}
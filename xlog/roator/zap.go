@@ -3,7 +3,7 @@ package roator
 import (
 	"io"
 	"os"
-	"path/filepath"
+	"sync"
 	"time"
 
 	"go.uber.org/zap/zapcore"
@@ -11,14 +11,24 @@ import (
 
 // ZapRotator 实现了基于 zap 的日志轮转器
 type ZapRotator struct {
-	config RotatorConfig
-	writer zapcore.WriteSyncer
-	size   int64
+	mu       sync.Mutex
+	config   RotatorConfig
+	writer   zapcore.WriteSyncer
+	filename string    // 当前写入的文件名
+	size     int64     // 当前文件大小
+	schedule *Schedule // 按时间轮转的计划
+	next     time.Time // 下一次按时间轮转的时间
 }
 
 // NewZapRotator 创建一个新的基于 zap 的日志轮转器
 func NewZapRotator(config RotatorConfig) (LogRotator, error) {
-	writer, err := os.OpenFile(config.Filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	schedule, err := parseConfigSchedule(config)
+	if err != nil {
+		return nil, err
+	}
+	now := config.now()
+	filename := config.activeFilename(now)
+	writer, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
 	if err != nil {
 		return nil, err
 	}
@@ -27,37 +37,65 @@ func NewZapRotator(config RotatorConfig) (LogRotator, error) {
 		return nil, err
 	}
 	return &ZapRotator{
-		config: config,
-		writer: zapcore.AddSync(writer),
-		size:   info.Size(),
+		config:   config,
+		writer:   zapcore.AddSync(writer),
+		filename: filename,
+		size:     info.Size(),
+		schedule: schedule,
+		next:     nextRotation(schedule, now),
 	}, nil
 }
 
 // GetWriter 返回一个 io.Writer，可以用于写入日志
+// 写入会经过轮转器，从而按大小和时间触发轮转
 func (r *ZapRotator) GetWriter() (io.Writer, error) {
-	return r.writer, nil
+	return r, nil
 }
 
 // Rotate 手动触发日志轮转
 func (r *ZapRotator) Rotate() error {
-	_ = r.writer.Sync()
-	if closer, ok := r.writer.(io.Closer); ok {
-		_ = closer.Close()
-	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotateLocked()
+}
 
-	// 重命名当前日志文件
-	newName := r.config.Filename + "." + time.Now().Format("2006-01-02_15-04-05")
-	if err := os.Rename(r.config.Filename, newName); err != nil {
-		return err
+// rotateLocked 执行日志轮转，调用方需持有锁
+// 新文件打开后才关闭当前文件，重命名或打开失败时恢复原文件名并继续写入当前文件
+func (r *ZapRotator) rotateLocked() error {
+	now := r.config.now()
+	newFilename := r.config.activeFilename(now)
+	// 文件名不随时间变化时，重命名当前日志文件
+	backup := ""
+	if newFilename == r.filename {
+		backup = backupFilename(r.filename, now)
+		if err := os.Rename(r.filename, backup); err != nil {
+			return err
+		}
 	}
 
 	// 创建新的日志文件
-	newFile, err := os.OpenFile(r.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	newFile, err := os.OpenFile(newFilename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	var info os.FileInfo
+	if err == nil {
+		if info, err = newFile.Stat(); err != nil {
+			_ = newFile.Close()
+		}
+	}
 	if err != nil {
+		if backup != "" {
+			_ = os.Rename(backup, r.filename)
+		}
 		return err
 	}
+
+	_ = r.writer.Sync()
+	if closer, ok := r.writer.(io.Closer); ok {
+		_ = closer.Close()
+	}
 	r.writer = zapcore.AddSync(newFile)
-	r.size = 0
+	r.filename = newFilename
+	r.size = info.Size()
+	r.next = nextRotation(r.schedule, now)
 
 	// 清理旧日志文件
 	r.cleanOldLogs()
@@ -65,39 +103,22 @@ func (r *ZapRotator) Rotate() error {
 	return nil
 }
 
-// 清理旧的日志
+// 清理旧的日志，按时间从新到旧保留 MaxBackups 个，并删除超过 MaxAge 天的备份
 func (r *ZapRotator) cleanOldLogs() {
-	dir := filepath.Dir(r.config.Filename)
-	base := filepath.Base(r.config.Filename)
-	pattern := filepath.Join(dir, base+".*")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return
-	}
-
-	for _, match := range matches {
-		info, err := os.Stat(match)
-		if err != nil {
-			continue
-		}
-		if time.Since(info.ModTime()) > time.Duration(r.config.MaxAge)*24*time.Hour {
-			os.Remove(match)
-		}
-	}
-
-	if r.config.MaxBackups > 0 && len(matches) > r.config.MaxBackups {
-		for _, match := range matches[r.config.MaxBackups:] {
-			os.Remove(match)
-		}
-	}
+	_, _ = r.config.removeExpiredBackups(r.filename)
 }
 
 // Write实现些操作
 func (r *ZapRotator) Write(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.next.IsZero() && !r.config.now().Before(r.next) {
+		_ = r.rotateLocked()
+	}
 	n, err = r.writer.Write(p)
 	r.size += int64(n)
-	if r.size > int64(r.config.MaxSize*1024*1024) {
-		_ = r.Rotate()
+	if r.size > r.config.maxSizeBytes() {
+		_ = r.rotateLocked()
 	}
 	return n, err
 }
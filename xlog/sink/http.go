@@ -0,0 +1,324 @@
+package sink
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omeyang/gokit/middleware/pool"
+	"github.com/omeyang/gokit/util/retry"
+)
+
+// HTTP 输出的默认配置
+const (
+	defaultBatchSize     = 100
+	defaultBatchBytes    = 1024 * 1024
+	defaultFlushInterval = time.Second
+	defaultHTTPTimeout   = 10 * time.Second
+)
+
+// ndjsonContentType 是请求体的内容类型
+const ndjsonContentType = "application/x-ndjson"
+
+// HTTPConfig 定义批量 HTTP 输出的配置
+type HTTPConfig struct {
+	// URL 是接收日志的地址，日志以换行分隔的 JSON 作为 POST 请求体发送
+	URL string
+
+	// Client 是发送请求使用的客户端，默认是超时 10 秒的 http.Client
+	Client *http.Client
+
+	// Headers 是附加到每个请求上的请求头，例如认证信息
+	Headers map[string]string
+
+	// BatchSize 是每个请求最多包含的日志条数，默认是 100
+	BatchSize int
+
+	// BatchBytes 是每个请求体压缩前的最大字节数，默认是 1MB
+	BatchBytes int
+
+	// FlushInterval 是未攒满一批时的最长发送间隔，默认是 1 秒
+	FlushInterval time.Duration
+
+	// Gzip 确定是否使用 gzip 压缩请求体
+	Gzip bool
+
+	// RetryPolicy 是请求失败时的重试策略，网络错误、429 和 5xx 响应会重试，默认不重试
+	RetryPolicy retry.RetryPolicy
+
+	// QueueSize 是等待发送的日志条数上限，队列满时丢弃新的日志，默认是 1024
+	QueueSize int
+
+	// OnError 处理后台发送时遇到的错误，默认忽略
+	OnError ErrorHandler
+}
+
+// HTTPWriter 在后台将日志攒批后通过 HTTP POST 发送；所有方法都可以并发调用
+type HTTPWriter struct {
+	config HTTPConfig
+	queue  *asyncQueue
+	batch  bytes.Buffer // 只在后台协程中访问
+	count  int          // 当前批次中的日志条数
+	gz     *gzip.Writer // 复用的 gzip 压缩器，只在后台协程中访问
+}
+
+// StatusError 表示接收端返回了非 2xx 响应
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error 实现 error 接口
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable 判断该响应是否值得重试
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// NewHTTPWriter 创建批量 HTTP 输出
+func NewHTTPWriter(config HTTPConfig) (*HTTPWriter, error) {
+	if config.URL == "" {
+		return nil, errors.New("http sink requires a URL")
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.BatchBytes <= 0 {
+		config.BatchBytes = defaultBatchBytes
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.NoRetryPolicy{}
+	}
+	w := &HTTPWriter{
+		config: config,
+		queue:  newAsyncQueue(config.QueueSize, "http"),
+	}
+	w.queue.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Write 将一条日志放入发送队列，队列满时日志被丢弃
+func (w *HTTPWriter) Write(p []byte) (int, error) {
+	w.queue.push(bytes.TrimRight(p, "\r\n"), false)
+	return len(p), nil
+}
+
+// Flush 发送队列与当前批次中的所有日志，并等待请求完成
+func (w *HTTPWriter) Flush() error {
+	w.queue.flush()
+	return nil
+}
+
+// Close 发送剩余日志后停止后台协程
+func (w *HTTPWriter) Close() error {
+	w.queue.close()
+	return nil
+}
+
+// Dropped 返回因队列已满或请求最终失败而丢弃的日志条数
+func (w *HTTPWriter) Dropped() uint64 {
+	return w.queue.dropped.Load()
+}
+
+// run 是后台发送协程
+func (w *HTTPWriter) run() {
+	defer w.queue.wg.Done()
+	ticker := time.NewTicker(durationOr(w.config.FlushInterval, defaultFlushInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case p := <-w.queue.ch:
+			w.add(p)
+			w.queue.release(p)
+		case <-ticker.C:
+			w.post()
+		case ack := <-w.queue.flushCh:
+			for _, p := range w.queue.drain() {
+				w.add(p)
+				w.queue.release(p)
+			}
+			w.post()
+			close(ack)
+		case <-w.queue.done:
+			for _, p := range w.queue.drain() {
+				w.add(p)
+				w.queue.release(p)
+			}
+			w.post()
+			return
+		}
+	}
+}
+
+// add 将日志加入当前批次，攒满后立即发送
+func (w *HTTPWriter) add(p []byte) {
+	if w.count > 0 && w.batch.Len()+len(p)+1 > w.config.BatchBytes {
+		w.post()
+	}
+	w.batch.Write(p)
+	w.batch.WriteByte('\n')
+	w.count++
+	if w.count >= w.config.BatchSize || w.batch.Len() >= w.config.BatchBytes {
+		w.post()
+	}
+}
+
+// post 发送当前批次，失败时按重试策略重试，关闭时不再重试，最终失败的日志计入丢弃数量
+func (w *HTTPWriter) post() {
+	if w.count == 0 {
+		return
+	}
+	defer func() {
+		w.batch.Reset()
+		w.count = 0
+	}()
+	body, err := w.encode()
+	if err != nil {
+		w.fail(err)
+		return
+	}
+	defer body.release()
+	for attempt := 1; ; attempt++ {
+		err = w.do(body)
+		if err == nil {
+			return
+		}
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && !statusErr.Retryable() {
+			break
+		}
+		if !w.config.RetryPolicy.ShouldRetry(attempt, err) || !w.wait(attempt) {
+			break
+		}
+	}
+	w.fail(fmt.Errorf("http sink: send %d records: %w", w.count, err))
+}
+
+// wait 等待重试间隔，关闭时不再等待并返回 false
+func (w *HTTPWriter) wait(attempt int) bool {
+	d := time.Duration(w.config.RetryPolicy.WaitDuration(attempt)) * time.Second
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-w.queue.done:
+		return false
+	}
+}
+
+// fail 记录当前批次发送失败
+func (w *HTTPWriter) fail(err error) {
+	w.queue.drop(w.count)
+	if w.config.OnError != nil {
+		w.config.OnError(err)
+	}
+}
+
+// encode 将当前批次编码到从 pool.DefaultByteBufferPool 获取的缓冲区中，需要时进行 gzip 压缩
+func (w *HTTPWriter) encode() (*requestBody, error) {
+	buf := pool.DefaultByteBufferPool.GetBuffer()
+	if !w.config.Gzip {
+		buf.Write(w.batch.Bytes())
+		return newRequestBody(buf), nil
+	}
+	if w.gz == nil {
+		w.gz = gzip.NewWriter(buf)
+	} else {
+		w.gz.Reset(buf)
+	}
+	_, err := w.gz.Write(w.batch.Bytes())
+	if err == nil {
+		err = w.gz.Close()
+	}
+	if err != nil {
+		buf.Release()
+		return nil, err
+	}
+	return newRequestBody(buf), nil
+}
+
+// requestBody 是一个批次编码后的请求体，可被多次重试共享。
+// Transport 可能在 Do 返回后才关闭请求体，因此缓冲区在所有请求体都被关闭、且 post 结束后才归还
+type requestBody struct {
+	buf  *pool.ByteBuffer
+	refs atomic.Int32
+}
+
+func newRequestBody(buf *pool.ByteBuffer) *requestBody {
+	b := &requestBody{buf: buf}
+	b.refs.Store(1)
+	return b
+}
+
+// reader 返回一个新的请求体读取器，关闭时释放一次引用
+func (b *requestBody) reader() io.ReadCloser {
+	b.refs.Add(1)
+	return &bodyReader{Reader: bytes.NewReader(b.buf.Bytes()), body: b}
+}
+
+// release 释放一次引用，最后一次释放时归还缓冲区
+func (b *requestBody) release() {
+	if b.refs.Add(-1) == 0 {
+		b.buf.Release()
+	}
+}
+
+// bodyReader 读取共享的请求体，重复关闭只释放一次引用
+type bodyReader struct {
+	*bytes.Reader
+	body *requestBody
+	once sync.Once
+}
+
+// Close 实现 io.Closer
+func (r *bodyReader) Close() error {
+	r.once.Do(r.body.release)
+	return nil
+}
+
+// do 发送一次请求
+func (w *HTTPWriter) do(body *requestBody) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.config.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Body = body.reader()
+	req.ContentLength = int64(body.buf.Len())
+	req.GetBody = func() (io.ReadCloser, error) { return body.reader(), nil }
+	req.Header.Set("Content-Type", ndjsonContentType)
+	if w.config.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range w.config.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &StatusError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(msg))}
+}
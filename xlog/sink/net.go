@@ -0,0 +1,185 @@
+package sink
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+// NetConfig 定义换行分隔 JSON 网络输出的配置
+type NetConfig struct {
+	// Network 是传输协议，"tcp" 或 "udp"，默认是 "tcp"；UDP 下每条日志作为一个数据报发送
+	Network string
+
+	// Address 是接收端地址，例如 "127.0.0.1:5170"
+	Address string
+
+	// TLSConfig 设置后使用 TLS 连接，仅支持 tcp
+	TLSConfig *tls.Config
+
+	// QueueSize 是等待发送的日志条数上限，连接断开期间的日志缓存在队列中，队列满时丢弃新的日志
+	// 默认是 1024
+	QueueSize int
+
+	// DialTimeout 是建立连接的超时时间，默认是 5 秒
+	DialTimeout time.Duration
+
+	// WriteTimeout 是单次写入的超时时间，默认是 5 秒
+	WriteTimeout time.Duration
+
+	// ReconnectInterval 是连接失败后重连的间隔，默认是 1 秒
+	ReconnectInterval time.Duration
+
+	// MaxRetries 是一条日志发送失败后按 ReconnectInterval 重试的最大次数，仍失败时丢弃该日志
+	// 默认是 3，小于 0 时不重试
+	MaxRetries int
+
+	// OnError 处理后台发送时遇到的错误，默认忽略
+	OnError ErrorHandler
+}
+
+// NetWriter 在后台将日志以换行分隔的 JSON 发送到 TCP 或 UDP 接收端
+// 连接断开时自动重连并重发未成功发送的日志，重试 MaxRetries 次后丢弃；所有方法都可以并发调用
+type NetWriter struct {
+	config NetConfig
+	queue  *asyncQueue
+	conn   net.Conn // 只在后台协程中访问
+}
+
+// NewNetWriter 创建网络输出，连接在后台建立
+func NewNetWriter(config NetConfig) (*NetWriter, error) {
+	if config.Network == "" {
+		config.Network = "tcp"
+	}
+	if config.Network != "tcp" && config.Network != "udp" {
+		return nil, fmt.Errorf("unsupported network %q", config.Network)
+	}
+	if config.TLSConfig != nil && config.Network != "tcp" {
+		return nil, fmt.Errorf("TLS requires tcp, got %q", config.Network)
+	}
+	if _, _, err := net.SplitHostPort(config.Address); err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", config.Address, err)
+	}
+	w := &NetWriter{
+		config: config,
+		queue:  newAsyncQueue(config.QueueSize, "net"),
+	}
+	w.queue.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Write 将一条日志放入发送队列，缺少换行符时自动补齐
+// 队列满时日志被丢弃，丢弃数量可以通过 Dropped 获取
+func (w *NetWriter) Write(p []byte) (int, error) {
+	w.queue.push(p, len(p) == 0 || p[len(p)-1] != '\n')
+	return len(p), nil
+}
+
+// Flush 等待队列中已有的日志发送完成或被丢弃
+// 一条日志重试后仍发送失败时，队列中剩余的日志直接丢弃，因此接收端不可用时 Flush 最多阻塞一条日志的重试时间
+func (w *NetWriter) Flush() error {
+	w.queue.flush()
+	return nil
+}
+
+// Close 尽力发送剩余日志后关闭连接
+func (w *NetWriter) Close() error {
+	w.queue.close()
+	return nil
+}
+
+// Dropped 返回因队列已满、发送失败或关闭而丢弃的日志条数
+func (w *NetWriter) Dropped() uint64 {
+	return w.queue.dropped.Load()
+}
+
+// run 是后台发送协程
+func (w *NetWriter) run() {
+	defer w.queue.wg.Done()
+	defer w.closeConn()
+	for {
+		select {
+		case p := <-w.queue.ch:
+			w.send(p)
+			w.queue.release(p)
+		case ack := <-w.queue.flushCh:
+			w.queue.sendAll(w.queue.drain(), w.send)
+			close(ack)
+		case <-w.queue.done:
+			// 关闭时每条日志只尝试一次，避免 Close 长时间阻塞
+			w.queue.sendAll(w.queue.drain(), w.sendOnce)
+			return
+		}
+	}
+}
+
+// send 发送一条日志，失败时按间隔重连重试，重试 MaxRetries 次或 Close 被调用后仍失败时丢弃该日志并返回 false
+func (w *NetWriter) send(p []byte) bool {
+	retries := w.config.MaxRetries
+	if retries == 0 {
+		retries = defaultMaxRetries
+	}
+	for attempt := 0; ; attempt++ {
+		err := w.writeOnce(p)
+		if err == nil {
+			return true
+		}
+		w.report(err)
+		if attempt >= retries {
+			w.queue.drop(1)
+			return false
+		}
+		timer := time.NewTimer(durationOr(w.config.ReconnectInterval, defaultReconnect))
+		select {
+		case <-timer.C:
+		case <-w.queue.done:
+			timer.Stop()
+			// 关闭时再尝试一次
+			return w.sendOnce(p)
+		}
+	}
+}
+
+// sendOnce 只尝试发送一次，失败时丢弃该日志并返回 false
+func (w *NetWriter) sendOnce(p []byte) bool {
+	if err := w.writeOnce(p); err != nil {
+		w.report(err)
+		w.queue.drop(1)
+		return false
+	}
+	return true
+}
+
+// writeOnce 在需要时建立连接并写入一条日志，失败时关闭连接以便下次重连
+func (w *NetWriter) writeOnce(p []byte) error {
+	if w.conn == nil {
+		conn, err := dial(w.config.Network, w.config.Address, w.config.TLSConfig, w.config.DialTimeout)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	_ = w.conn.SetWriteDeadline(time.Now().Add(durationOr(w.config.WriteTimeout, defaultWriteTimeout)))
+	if _, err := w.conn.Write(p); err != nil {
+		w.closeConn()
+		return err
+	}
+	return nil
+}
+
+// closeConn 关闭当前连接
+func (w *NetWriter) closeConn() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// report 调用错误处理函数
+func (w *NetWriter) report(err error) {
+	if w.config.OnError != nil {
+		w.config.OnError(err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package sink 提供将日志发送到远端的 io.Writer 实现，可直接作为 xlog.LogConfig 的 Writer 使用，
+// 包括 RFC 5424 syslog（UDP/TCP/TLS）、基于 TCP/UDP 的换行分隔 JSON 以及批量 HTTP POST
+// 日志处理器每条记录只调用一次 Write，因此每次 Write 的内容被视为一条完整的日志记录
+package sink
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omeyang/gokit/metrics"
+	"github.com/omeyang/gokit/middleware/pool"
+)
+
+// 默认配置
+const (
+	defaultQueueSize    = 1024
+	defaultDialTimeout  = 5 * time.Second
+	defaultWriteTimeout = 5 * time.Second
+	defaultReconnect    = time.Second
+	defaultMaxRetries   = 3
+	defaultCloseTimeout = 5 * time.Second
+)
+
+// ErrorHandler 处理后台发送日志时遇到的错误
+type ErrorHandler func(error)
+
+// asyncQueue 是在后台协程中发送日志的有界队列，队列满时丢弃新的日志
+// 队列中的日志复制到从 pool.DefaultByteBufferPool 获取的缓冲区中，后台协程处理完后调用 release 归还
+type asyncQueue struct {
+	ch          chan []byte
+	flushCh     chan chan struct{}
+	done        chan struct{}
+	wg          sync.WaitGroup
+	mu          sync.RWMutex // push 持有读锁，close 持有写锁，保证关闭后不会再有日志进入队列
+	closed      atomic.Bool
+	dropped     atomic.Uint64
+	droppedStat metrics.Counter // 丢弃的日志条数，按 sink 类型区分
+}
+
+// newAsyncQueue 创建容量为 size 的队列，sink 为记录丢弃指标时使用的 sink 类型
+func newAsyncQueue(size int, sink string) *asyncQueue {
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	return &asyncQueue{
+		ch:      make(chan []byte, size),
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+		droppedStat: metrics.Default().Counter(metrics.Opts{
+			Name:   "gokit_xlog_sink_dropped_total",
+			Help:   "Number of log records dropped by xlog sinks.",
+			Labels: []string{"sink"},
+		}).With(sink),
+	}
+}
+
+// drop 记录丢弃的日志条数
+func (q *asyncQueue) drop(n int) {
+	q.dropped.Add(uint64(n))
+	q.droppedStat.Add(float64(n))
+}
+
+// push 复制一条日志放入队列，newline 为 true 时在末尾补充换行符；队列已满或已关闭时丢弃并返回 false
+func (q *asyncQueue) push(p []byte, newline bool) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed.Load() {
+		q.drop(1)
+		return false
+	}
+	n := len(p)
+	if newline {
+		n++
+	}
+	buf := pool.DefaultByteBufferPool.Get(n)
+	copy(buf, p)
+	if newline {
+		buf[n-1] = '\n'
+	}
+	select {
+	case q.ch <- buf:
+		return true
+	default:
+		q.release(buf)
+		q.drop(1)
+		return false
+	}
+}
+
+// release 归还已处理完的日志的缓冲区
+func (q *asyncQueue) release(p []byte) {
+	pool.DefaultByteBufferPool.Put(p)
+}
+
+// flush 请求后台协程发送队列中已有的日志，并等待其完成
+func (q *asyncQueue) flush() {
+	if q.closed.Load() {
+		return
+	}
+	ack := make(chan struct{})
+	select {
+	case q.flushCh <- ack:
+		<-ack
+	case <-q.done:
+	}
+}
+
+// close 通知后台协程发送剩余日志后退出，并等待其退出
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	if q.closed.Swap(true) {
+		q.mu.Unlock()
+		return
+	}
+	close(q.done)
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// sendAll 依次发送 items 并归还缓冲区，send 返回 false 表示该日志发送失败且已计入丢弃数量
+// 一条日志发送失败后剩余的日志不再尝试发送而直接丢弃，避免连接不可用时 Flush 与 Close 长时间阻塞
+func (q *asyncQueue) sendAll(items [][]byte, send func([]byte) bool) {
+	failed := false
+	for _, p := range items {
+		if failed {
+			q.drop(1)
+		} else {
+			failed = !send(p)
+		}
+		q.release(p)
+	}
+}
+
+// drain 非阻塞地取出队列中剩余的日志
+func (q *asyncQueue) drain() [][]byte {
+	var items [][]byte
+	for {
+		select {
+		case p := <-q.ch:
+			items = append(items, p)
+		default:
+			return items
+		}
+	}
+}
+
+// recordLevel 从 JSON 日志记录中读取级别字段，无法解析时返回空字符串
+func recordLevel(p []byte) string {
+	var record struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(p, &record); err != nil {
+		return ""
+	}
+	return record.Level
+}
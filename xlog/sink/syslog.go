@@ -0,0 +1,300 @@
+package sink
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omeyang/gokit/middleware/pool"
+)
+
+// Facility 是 syslog 的设施值
+type Facility int
+
+// 常用的 syslog 设施
+const (
+	FacilityUser   Facility = 1
+	FacilityDaemon Facility = 3
+	FacilityLocal0 Facility = 16
+	FacilityLocal1 Facility = 17
+	FacilityLocal2 Facility = 18
+	FacilityLocal3 Facility = 19
+	FacilityLocal4 Facility = 20
+	FacilityLocal5 Facility = 21
+	FacilityLocal6 Facility = 22
+	FacilityLocal7 Facility = 23
+)
+
+// syslog 严重性
+const (
+	severityCritical = 2
+	severityError    = 3
+	severityWarning  = 4
+	severityInfo     = 6
+	severityDebug    = 7
+)
+
+// syslogTimeFormat 是 RFC 5424 的时间戳格式，精确到微秒
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// SyslogConfig 定义 syslog 输出的配置
+type SyslogConfig struct {
+	// Network 是传输协议，"udp" 或 "tcp"，默认是 "udp"
+	// TCP 与 TLS 连接使用 RFC 6587 的八位组计数分帧
+	Network string
+
+	// Address 是 syslog 服务器地址，例如 "127.0.0.1:514"
+	Address string
+
+	// TLSConfig 设置后使用 TLS 连接（RFC 5425），仅支持 tcp
+	TLSConfig *tls.Config
+
+	// Facility 是 syslog 设施，默认是 FacilityUser；应用日志不应使用内核设施，因此 0 视为未设置
+	Facility Facility
+
+	// Hostname 是消息头中的主机名，默认是 os.Hostname
+	Hostname string
+
+	// AppName 是消息头中的应用名，默认是当前可执行文件名
+	AppName string
+
+	// MsgID 是消息头中的消息类型，默认为空（输出 "-"）
+	MsgID string
+
+	// DialTimeout 是建立连接的超时时间，默认是 5 秒
+	DialTimeout time.Duration
+
+	// WriteTimeout 是单次写入的超时时间，默认是 5 秒
+	WriteTimeout time.Duration
+
+	// QueueSize 是等待发送的消息条数上限，队列满时丢弃新的消息，默认是 1024
+	QueueSize int
+
+	// OnError 处理后台发送时遇到的错误，默认忽略
+	OnError ErrorHandler
+
+	// Now 返回当前时间，默认是 time.Now，便于测试时注入时钟
+	Now func() time.Time
+}
+
+// SyslogWriter 将每条日志作为一条 RFC 5424 syslog 消息发送，严重性取自 JSON 记录中的 level 字段
+// Write 只负责格式化消息并放入队列，由后台协程发送，接收端不可用时不会阻塞日志写入；
+// TCP 连接断开时重连一次并重发，仍失败时丢弃该消息；所有方法都可以并发调用
+type SyslogWriter struct {
+	config SyslogConfig
+	queue  *asyncQueue
+	conn   net.Conn // 构造完成后只在后台协程中访问
+	header string   // 消息头中不随消息变化的部分：HOSTNAME APP-NAME PROCID MSGID
+}
+
+// NewSyslogWriter 创建 syslog 输出并建立连接
+func NewSyslogWriter(config SyslogConfig) (*SyslogWriter, error) {
+	if config.Network == "" {
+		config.Network = "udp"
+	}
+	if config.Network != "udp" && config.Network != "tcp" {
+		return nil, fmt.Errorf("unsupported syslog network %q", config.Network)
+	}
+	if config.TLSConfig != nil && config.Network != "tcp" {
+		return nil, fmt.Errorf("syslog over TLS requires tcp, got %q", config.Network)
+	}
+	if config.Facility == 0 {
+		config.Facility = FacilityUser
+	}
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+	if config.AppName == "" {
+		config.AppName = filepath.Base(os.Args[0])
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	w := &SyslogWriter{
+		config: config,
+		queue:  newAsyncQueue(config.QueueSize, "syslog"),
+		header: strings.Join([]string{
+			headerField(config.Hostname, 255),
+			headerField(config.AppName, 48),
+			headerField(strconv.Itoa(os.Getpid()), 128),
+			headerField(config.MsgID, 32),
+		}, " "),
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	w.queue.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Write 将一条日志格式化为 syslog 消息后放入发送队列，消息的时间戳取写入时的时间
+// 队列满时消息被丢弃，丢弃数量可以通过 Dropped 获取
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	buf := w.format(p)
+	w.queue.push(buf.Bytes(), false)
+	buf.Release()
+	return len(p), nil
+}
+
+// Flush 等待队列中已有的消息发送完成或被丢弃
+func (w *SyslogWriter) Flush() error {
+	w.queue.flush()
+	return nil
+}
+
+// Close 尽力发送剩余消息后关闭连接
+func (w *SyslogWriter) Close() error {
+	w.queue.close()
+	return nil
+}
+
+// Dropped 返回因队列已满、发送失败或关闭而丢弃的消息条数
+func (w *SyslogWriter) Dropped() uint64 {
+	return w.queue.dropped.Load()
+}
+
+// run 是后台发送协程
+func (w *SyslogWriter) run() {
+	defer w.queue.wg.Done()
+	defer w.closeConn()
+	for {
+		select {
+		case msg := <-w.queue.ch:
+			w.send(msg)
+			w.queue.release(msg)
+		case ack := <-w.queue.flushCh:
+			w.queue.sendAll(w.queue.drain(), w.send)
+			close(ack)
+		case <-w.queue.done:
+			w.queue.sendAll(w.queue.drain(), w.send)
+			return
+		}
+	}
+}
+
+// send 发送一条消息，TCP 下失败时重连后重发一次；仍失败时丢弃该消息并返回 false
+func (w *SyslogWriter) send(msg []byte) bool {
+	err := w.writeOnce(msg)
+	if err != nil && w.config.Network != "udp" {
+		// TCP 连接可能已被对端关闭，重连后重发一次
+		if err = w.connect(); err == nil {
+			err = w.writeOnce(msg)
+		}
+	}
+	if err != nil {
+		if w.config.OnError != nil {
+			w.config.OnError(err)
+		}
+		w.queue.drop(1)
+		return false
+	}
+	return true
+}
+
+// format 生成一条完整的 syslog 消息，TCP 下包含八位组计数前缀
+// 消息写入从 pool.DefaultByteBufferPool 获取的缓冲区，发送后需要调用 Release 归还
+func (w *SyslogWriter) format(p []byte) *pool.ByteBuffer {
+	p = bytes.TrimRight(p, "\r\n")
+	pri := int(w.config.Facility)*8 + syslogSeverity(recordLevel(p))
+
+	buf := pool.DefaultByteBufferPool.GetBuffer()
+	buf.Grow(len(p) + len(w.header) + 64)
+	fmt.Fprintf(buf, "<%d>1 %s %s - ", pri, w.config.Now().Format(syslogTimeFormat), w.header)
+	buf.Write(p)
+	if w.config.Network == "udp" {
+		return buf
+	}
+	defer buf.Release()
+	var length [20]byte
+	frame := pool.DefaultByteBufferPool.GetBuffer()
+	frame.Grow(buf.Len() + len(length))
+	frame.Write(strconv.AppendInt(length[:0], int64(buf.Len()), 10))
+	frame.WriteByte(' ')
+	frame.Write(buf.Bytes())
+	return frame
+}
+
+// connect 关闭旧连接并建立新连接，在后台协程或构造阶段调用
+func (w *SyslogWriter) connect() error {
+	w.closeConn()
+	conn, err := dial(w.config.Network, w.config.Address, w.config.TLSConfig, w.config.DialTimeout)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+// closeConn 关闭当前连接
+func (w *SyslogWriter) closeConn() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// writeOnce 通过当前连接发送消息
+func (w *SyslogWriter) writeOnce(msg []byte) error {
+	if w.conn == nil {
+		return net.ErrClosed
+	}
+	_ = w.conn.SetWriteDeadline(time.Now().Add(durationOr(w.config.WriteTimeout, defaultWriteTimeout)))
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+// syslogSeverity 将日志级别映射为 syslog 严重性
+func syslogSeverity(level string) int {
+	switch strings.ToUpper(level) {
+	case "DEBUG":
+		return severityDebug
+	case "WARN":
+		return severityWarning
+	case "ERROR":
+		return severityError
+	case "FATAL":
+		return severityCritical
+	default:
+		return severityInfo
+	}
+}
+
+// headerField 将消息头字段限制为可打印 ASCII 且不超过 maxLen，空值输出 "-"
+func headerField(s string, maxLen int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < maxLen; i++ {
+		if c := s[i]; c > ' ' && c < 0x7f {
+			b = append(b, c)
+		} else {
+			b = append(b, '_')
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// dial 建立 TCP、TLS 或 UDP 连接
+func dial(network, address string, tlsConfig *tls.Config, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: durationOr(timeout, defaultDialTimeout)}
+	if tlsConfig != nil {
+		return tls.DialWithDialer(dialer, network, address, tlsConfig)
+	}
+	return dialer.Dial(network, address)
+}
+
+// durationOr 在 d 未设置时返回默认值
+func durationOr(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
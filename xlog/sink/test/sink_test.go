@@ -0,0 +1,816 @@
+package test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/util/retry"
+	"github.com/omeyang/gokit/xlog"
+	"github.com/omeyang/gokit/xlog/sink"
+)
+
+var fixedNow = func() time.Time { return time.Date(2024, 6, 1, 10, 0, 0, 123456000, time.UTC) }
+
+// lineServer 是接收换行分隔日志的 TCP 测试服务器
+type lineServer struct {
+	ln    net.Listener
+	mu    sync.Mutex
+	lines []string
+	conns []net.Conn
+}
+
+// newLineServer 在 addr 上启动测试服务器，addr 为空时使用随机端口
+func newLineServer(t *testing.T, addr string, tlsConfig *tls.Config) *lineServer {
+	t.Helper()
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+	s := &lineServer{ln: ln}
+	t.Cleanup(s.close)
+	go s.serve()
+	return s
+}
+
+func (s *lineServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go func() {
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				s.mu.Lock()
+				s.lines = append(s.lines, scanner.Text())
+				s.mu.Unlock()
+			}
+		}()
+	}
+}
+
+// dropConnections 关闭服务器端已建立的连接
+func (s *lineServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		_ = c.Close()
+	}
+}
+
+func (s *lineServer) close() {
+	_ = s.ln.Close()
+	s.dropConnections()
+}
+
+func (s *lineServer) addr() string { return s.ln.Addr().String() }
+
+// waitLines 等待收到至少 n 行
+func (s *lineServer) waitLines(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		lines := append([]string(nil), s.lines...)
+		s.mu.Unlock()
+		if len(lines) >= n {
+			return lines
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d lines %v, want %d", len(lines), lines, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// selfSignedTLS 生成测试用的自签名证书，返回服务端与客户端配置
+func selfSignedTLS(t *testing.T) (*tls.Config, *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client := &tls.Config{RootCAs: pool}
+	return server, client
+}
+
+// readOctetFrame 读取一条八位组计数分帧的 syslog 消息
+func readOctetFrame(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	lenStr, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	w, err := sink.NewSyslogWriter(sink.SyslogConfig{
+		Address:  pc.LocalAddr().String(),
+		Facility: sink.FacilityLocal0,
+		Hostname: "host 1",
+		AppName:  "api",
+		MsgID:    "audit",
+		Now:      fixedNow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	record := `{"level":"ERROR","msg":"boom"}`
+	if _, err := w.Write([]byte(record + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local0(16)*8 + error(3) = 131
+	want := "<131>1 2024-06-01T10:00:00.123456Z host_1 api " + strconv.Itoa(os.Getpid()) + " audit - " + record
+	if got := string(buf[:n]); got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+}
+
+// acceptOne 接受一个连接并返回其读取器，失败时返回 nil；在测试协程之外调用
+func acceptOne(t *testing.T, ln net.Listener) *bufio.Reader {
+	t.Helper()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	// 客户端在建立连接时完成 TLS 握手，服务端需要主动参与握手
+	if tc, ok := conn.(*tls.Conn); ok {
+		if err := tc.Handshake(); err != nil {
+			t.Error(err)
+			return nil
+		}
+	}
+	return bufio.NewReader(conn)
+}
+
+func TestSyslogTCPAndTLS(t *testing.T) {
+	serverTLS, clientTLS := selfSignedTLS(t)
+	cases := map[string]struct {
+		server, client *tls.Config
+	}{
+		"tcp": {},
+		"tls": {serverTLS, clientTLS},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+			if c.server != nil {
+				ln = tls.NewListener(ln, c.server)
+			}
+
+			accepted := make(chan *bufio.Reader, 1)
+			go func() { accepted <- acceptOne(t, ln) }()
+			w, err := sink.NewSyslogWriter(sink.SyslogConfig{
+				Network:   "tcp",
+				Address:   ln.Addr().String(),
+				TLSConfig: c.client,
+				Hostname:  "host",
+				AppName:   "api",
+				Now:       fixedNow,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			for _, level := range []string{"DEBUG", "WARN"} {
+				if _, err := w.Write([]byte(`{"level":"` + level + `","msg":"x"}` + "\n")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r := <-accepted
+			if r == nil {
+				t.FailNow()
+			}
+			for _, pri := range []string{"<15>1 ", "<12>1 "} {
+				if got := readOctetFrame(t, r); !strings.HasPrefix(got, pri) || !strings.HasSuffix(got, `"msg":"x"}`) {
+					t.Errorf("frame = %q, want prefix %q", got, pri)
+				}
+			}
+		})
+	}
+}
+
+func TestSyslogDropsWhenServerGone(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+	var errs atomic.Int32
+	w, err := sink.NewSyslogWriter(sink.SyslogConfig{
+		Network: "tcp",
+		Address: ln.Addr().String(),
+		OnError: func(error) { errs.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	// 接收端关闭后，写入不阻塞，发送失败的消息计入丢弃数量
+	_ = ln.Close()
+	(<-accepted).Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Dropped() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("messages were not dropped after the server went away")
+		}
+		start := time.Now()
+		_, _ = w.Write([]byte(`{"level":"INFO","msg":"x"}`))
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("Write() blocked for %v", elapsed)
+		}
+		_ = w.Flush()
+	}
+	if errs.Load() == 0 {
+		t.Error("OnError was not called")
+	}
+}
+
+func TestSyslogInvalidConfig(t *testing.T) {
+	if _, err := sink.NewSyslogWriter(sink.SyslogConfig{Network: "unix", Address: "x"}); err == nil {
+		t.Error("expected error for unsupported network")
+	}
+	if _, err := sink.NewSyslogWriter(sink.SyslogConfig{Address: "127.0.0.1:1", TLSConfig: &tls.Config{}}); err == nil {
+		t.Error("expected error for TLS over udp")
+	}
+}
+
+func TestNetWriterWithLogger(t *testing.T) {
+	server := newLineServer(t, "", nil)
+	w, err := sink.NewNetWriter(sink.NetConfig{Address: server.addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:           xlog.Info,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          w,
+		AsyncBufferSize: 16,
+		FlushInterval:   time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hello", xlog.Field{Key: "n", Value: 1})
+	logger.Warn("world")
+	logger.Flush()
+	_ = w.Flush()
+
+	lines := server.waitLines(t, 2)
+	if !strings.Contains(lines[0], `"msg":"hello"`) || !strings.Contains(lines[0], `"level":"INFO"`) || !strings.Contains(lines[1], `"msg":"world"`) {
+		t.Errorf("lines = %v", lines)
+	}
+}
+
+func TestNetWriterBuffersUntilConnected(t *testing.T) {
+	// 先占用一个端口再释放，得到一个暂时无人监听的地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	var errCount atomic.Int32
+	w, err := sink.NewNetWriter(sink.NetConfig{
+		Address:           addr,
+		ReconnectInterval: 10 * time.Millisecond,
+		MaxRetries:        500,
+		OnError:           func(error) { errCount.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for _, s := range []string{"a", "b", "c"} {
+		_, _ = w.Write([]byte(s))
+	}
+	time.Sleep(50 * time.Millisecond)
+	if errCount.Load() == 0 {
+		t.Error("expected connection errors while the server is down")
+	}
+
+	server := newLineServer(t, addr, nil)
+	_ = w.Flush()
+	if got := strings.Join(server.waitLines(t, 3), ","); got != "a,b,c" {
+		t.Errorf("lines = %s", got)
+	}
+	if w.Dropped() != 0 {
+		t.Errorf("Dropped() = %d", w.Dropped())
+	}
+}
+
+func TestNetWriterFlushDropsAfterRetries(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	w, err := sink.NewNetWriter(sink.NetConfig{
+		Address:           addr,
+		ReconnectInterval: 10 * time.Millisecond,
+		MaxRetries:        2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for i := 0; i < 100; i++ {
+		_, _ = w.Write([]byte("x"))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_ = w.Flush()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Flush() blocked while the receiver is down")
+	}
+	if w.Dropped() != 100 {
+		t.Errorf("Dropped() = %d, want 100", w.Dropped())
+	}
+}
+
+func TestNetWriterReconnects(t *testing.T) {
+	server := newLineServer(t, "", nil)
+	w, err := sink.NewNetWriter(sink.NetConfig{Address: server.addr(), ReconnectInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	_, _ = w.Write([]byte("first\n"))
+	server.waitLines(t, 1)
+	server.dropConnections()
+
+	// 对端关闭后的第一次写入可能仍然成功，持续写入直到在新连接上收到日志
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 0; ; i++ {
+		_, _ = w.Write([]byte("after-" + strconv.Itoa(i) + "\n"))
+		_ = w.Flush()
+		server.mu.Lock()
+		conns, lines := len(server.conns), len(server.lines)
+		server.mu.Unlock()
+		if conns >= 2 && lines >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no reconnect: conns=%d lines=%d", conns, lines)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNetWriterTLS(t *testing.T) {
+	serverTLS, clientTLS := selfSignedTLS(t)
+	server := newLineServer(t, "", serverTLS)
+	w, err := sink.NewNetWriter(sink.NetConfig{Address: server.addr(), TLSConfig: clientTLS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte(`{"msg":"secure"}`))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := server.waitLines(t, 1); lines[0] != `{"msg":"secure"}` {
+		t.Errorf("lines = %v", lines)
+	}
+}
+
+func TestNetWriterUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	w, err := sink.NewNetWriter(sink.NetConfig{Network: "udp", Address: pc.LocalAddr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	_, _ = w.Write([]byte(`{"msg":"datagram"}`))
+
+	buf := make([]byte, 1024)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "{\"msg\":\"datagram\"}\n" {
+		t.Errorf("datagram = %q", got)
+	}
+}
+
+func TestNetWriterDropsWhenQueueFull(t *testing.T) {
+	w, err := sink.NewNetWriter(sink.NetConfig{Address: "127.0.0.1:1", QueueSize: 2, ReconnectInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		_, _ = w.Write([]byte("x\n"))
+	}
+	if w.Dropped() == 0 {
+		t.Error("expected dropped records")
+	}
+	_ = w.Close()
+	// 关闭后所有未发送的日志都计入丢弃数量
+	if w.Dropped() != 10 {
+		t.Errorf("Dropped() = %d, want 10", w.Dropped())
+	}
+}
+
+// httpSink 是记录请求的 HTTP 测试服务器
+type httpSink struct {
+	mu       sync.Mutex
+	requests [][]string
+	statuses []int // 依次返回的状态码，用完后返回 200
+}
+
+func (h *httpSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+	data, _ := io.ReadAll(body)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.statuses) > 0 {
+		status := h.statuses[0]
+		h.statuses = h.statuses[1:]
+		if status != http.StatusOK {
+			http.Error(w, "try later", status)
+			return
+		}
+	}
+	if r.Header.Get("Content-Type") != "application/x-ndjson" || r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "bad headers", http.StatusBadRequest)
+		return
+	}
+	h.requests = append(h.requests, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"))
+}
+
+func TestHTTPWriterBatchesAndRetries(t *testing.T) {
+	h := &httpSink{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	var errs atomic.Int32
+	w, err := sink.NewHTTPWriter(sink.HTTPConfig{
+		URL:           server.URL,
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Gzip:          true,
+		RetryPolicy:   &retry.SimpleRetryPolicy{MaxAttempts: 3},
+		OnError:       func(error) { errs.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"1", "2", "3", "4", "5"} {
+		_, _ = w.Write([]byte(`{"n":` + s + "}\n"))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var got []string
+	for _, req := range h.requests {
+		got = append(got, strings.Join(req, "|"))
+	}
+	want := `{"n":1}|{"n":2},{"n":3}|{"n":4},{"n":5}`
+	if strings.Join(got, ",") != want {
+		t.Errorf("requests = %v, want %s", got, want)
+	}
+	if w.Dropped() != 0 || errs.Load() != 0 {
+		t.Errorf("Dropped() = %d, errors = %d", w.Dropped(), errs.Load())
+	}
+}
+
+func TestHTTPWriterDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var lastErr atomic.Value
+	w, err := sink.NewHTTPWriter(sink.HTTPConfig{
+		URL:         server.URL,
+		RetryPolicy: &retry.SimpleRetryPolicy{MaxAttempts: 5},
+		OnError:     func(err error) { lastErr.Store(err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte(`{"n":1}`))
+	_, _ = w.Write([]byte(`{"n":2}`))
+	_ = w.Flush()
+
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+	if w.Dropped() != 2 {
+		t.Errorf("Dropped() = %d, want 2", w.Dropped())
+	}
+	err, _ = lastErr.Load().(error)
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 400: invalid payload") {
+		t.Errorf("error = %v", err)
+	}
+	_ = w.Close()
+}
+
+func TestHTTPWriterCloseInterruptsRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "try later", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	w, err := sink.NewHTTPWriter(sink.HTTPConfig{
+		URL:           server.URL,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		RetryPolicy:   &retry.SimpleRetryPolicy{MaxAttempts: 5, WaitTime: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte(`{"n":1}`))
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("batch was not sent")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Close() took %v while waiting to retry", elapsed)
+	}
+	if w.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", w.Dropped())
+	}
+}
+
+func TestHTTPWriterWriteRacingClose(t *testing.T) {
+	h := &httpSink{}
+	server := httptest.NewServer(h)
+	defer server.Close()
+	w, err := sink.NewHTTPWriter(sink.HTTPConfig{
+		URL:           server.URL,
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const writers, records = 4, 200
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < records; j++ {
+				_, _ = w.Write([]byte(`{"n":1}`))
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	_ = w.Close()
+	wg.Wait()
+
+	// 与 Close 并发的写入要么被发送，要么计入丢弃数量
+	h.mu.Lock()
+	sent := 0
+	for _, req := range h.requests {
+		sent += len(req)
+	}
+	h.mu.Unlock()
+	if total := uint64(sent) + w.Dropped(); total != writers*records {
+		t.Errorf("sent %d + dropped %d = %d, want %d", sent, w.Dropped(), total, writers*records)
+	}
+}
+
+func TestHTTPWriterFlushInterval(t *testing.T) {
+	h := &httpSink{}
+	server := httptest.NewServer(h)
+	defer server.Close()
+	w, err := sink.NewHTTPWriter(sink.HTTPConfig{
+		URL:           server.URL,
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		FlushInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	_, _ = w.Write([]byte(`{"n":1}`))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.mu.Lock()
+		n := len(h.requests)
+		h.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("batch was not sent after FlushInterval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// lateReadTransport 立即返回 200，在请求返回后才读取并关闭请求体，模拟 Transport 异步关闭请求体
+type lateReadTransport struct {
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (tr *lateReadTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tr.wg.Add(1)
+	go func() {
+		defer tr.wg.Done()
+		time.Sleep(10 * time.Millisecond)
+		data, _ := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		tr.mu.Lock()
+		tr.bodies = append(tr.bodies, string(data))
+		tr.mu.Unlock()
+	}()
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestHTTPWriterBodyOutlivesRequest(t *testing.T) {
+	tr := &lateReadTransport{}
+	w, err := sink.NewHTTPWriter(sink.HTTPConfig{
+		URL:           "http://example.invalid/logs",
+		Client:        &http.Client{Transport: tr},
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		_, _ = w.Write([]byte(`{"n":` + strconv.Itoa(i) + "}\n"))
+	}
+	_ = w.Close()
+	tr.wg.Wait()
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	seen := map[string]bool{}
+	for _, body := range tr.bodies {
+		seen[body] = true
+	}
+	for i := 0; i < 20; i++ {
+		if want := `{"n":` + strconv.Itoa(i) + "}\n"; !seen[want] {
+			t.Errorf("missing body %q, got %q", want, tr.bodies)
+		}
+	}
+}
+
+// discardTransport 读完请求体后返回 200
+type discardTransport struct{}
+
+func (discardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, _ = io.Copy(io.Discard, req.Body)
+	_ = req.Body.Close()
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func BenchmarkHTTPWriterGzip(b *testing.B) {
+	w, err := sink.NewHTTPWriter(sink.HTTPConfig{
+		URL:           "http://example.invalid/logs",
+		Client:        &http.Client{Transport: discardTransport{}},
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+		QueueSize:     1000,
+		Gzip:          true,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer w.Close()
+	record := []byte(`{"time":"2024-06-01T10:00:00Z","level":"INFO","msg":"request served","status":200,"ms":12}` + "\n")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			_, _ = w.Write(record)
+		}
+		_ = w.Flush()
+	}
+}
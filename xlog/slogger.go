@@ -6,11 +6,16 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"maps"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/omeyang/gokit/metrics"
 	"github.com/omeyang/gokit/metrics/sample"
 
 	"go.opentelemetry.io/otel/trace"
@@ -19,16 +24,44 @@ import (
 // internalErrorLogger 用于记录内部错误
 var internalErrorLogger = log.New(os.Stderr, "INTERNAL_ERROR: ", log.LstdFlags)
 
+// LoggerNameKey 是命名日志器输出名称时使用的字段名
+const LoggerNameKey = "logger"
+
+// 输出链路信息时使用的字段名
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// dedupKeySep 分隔限流去重采样键中的级别与消息
+const dedupKeySep = "|"
+
 // SlogLogger 实现了 HighPerformanceLogger 接口，基于 slog
+// 通过 Named、WithTrace、WithMetadata 派生出的日志器与根日志器共享处理器、缓冲区、采样器和级别表，
+// 自身只保存名称和预绑定的字段
 type SlogLogger struct {
-	handler          atomic.Value     // 存储 slog.Handler
-	level            atomic.Value     // 存储 LogLevel
-	config           LogConfig        // 日志配置
-	buffer           chan slog.Record // 存储日志记录的缓冲通道 实现异步处理日志
-	done             chan struct{}    // 优雅地关闭日志处理goroutine
-	sampler          sample.Sampler   // 采样器
+	handler          atomic.Value                   // 存储 slog.Handler
+	config           atomic.Pointer[LogConfig]      // 日志配置，UpdateConfig 整体替换
+	buffer           chan queuedRecord              // 存储日志记录的缓冲通道 实现异步处理日志
+	flush            chan chan struct{}             // 刷新请求，处理完成后关闭请求中的通道
+	done             chan struct{}                  // 优雅地关闭日志处理goroutine
+	closeOnce        sync.Once                      // 保证 done 只关闭一次，根日志器与派生日志器都可以调用 Close
+	sampler          atomic.Pointer[sample.Sampler] // 采样器，UpdateConfig 整体替换
+	updateMu         sync.Mutex                     // 串行化 UpdateConfig
 	wg               sync.WaitGroup
-	contextExtractor ContextExtractor // context中的提取字段
+	contextExtractor ContextExtractor         // context中的提取字段
+	levels           *levelTable              // 根级别与按名称覆盖的级别
+	redactor         atomic.Pointer[Redactor] // 字段脱敏，未启用时为 nil
+	droppedSampled   metrics.Counter          // 被采样丢弃的日志条数
+	droppedClosed    metrics.Counter          // 日志器关闭后丢弃的日志条数
+
+	root         *SlogLogger                 // 根日志器，根日志器指向自身
+	name         string                      // 日志器名称，以 "." 分隔层级
+	attrs        []slog.Attr                 // 预绑定到每条日志上的字段
+	levelCache   atomic.Pointer[cachedLevel] // 解析后的级别缓存
+	traceID      string                      // WithTrace 绑定的 trace ID，用于一致性采样
+	traceSampled bool                        // WithTrace 绑定的 span 是否已被采样
+	span         trace.Span                  // WithTrace 绑定的 span，用于记录 span 事件
 }
 
 // validateConfig 验证日志配置
@@ -45,9 +78,34 @@ func validateConfig(config *LogConfig) error {
 	if config.Sampling.Rate < 0 || config.Sampling.Rate > 1 {
 		return errors.New("sampling rate must be between 0 and 1")
 	}
+	if config.Sampling.MinRate < 0 || config.Sampling.MaxRate < 0 || config.Sampling.MaxRate > 1 ||
+		(config.Sampling.MaxRate > 0 && config.Sampling.MinRate > config.Sampling.MaxRate) {
+		return errors.New("sampling min/max rate must satisfy 0 <= min <= max <= 1")
+	}
+	if config.Sampling.Target < 0 {
+		return errors.New("sampling target must not be negative")
+	}
+	for pattern, level := range config.LevelOverrides {
+		if _, err := newLevelRule(pattern, level); err != nil {
+			return err
+		}
+	}
+	if config.Redaction.Enabled {
+		if _, err := NewRedactor(config.Redaction); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// newConfigRedactor 根据配置创建 Redactor，未启用脱敏时返回 nil
+func newConfigRedactor(config LogConfig) (*Redactor, error) {
+	if !config.Redaction.Enabled {
+		return nil, nil
+	}
+	return NewRedactor(config.Redaction)
+}
+
 // NewSlogLogger 创建一个新的 SlogLogger 实例
 func NewSlogLogger(config LogConfig, additionalContextKeys ...string) (*SlogLogger, error) {
 	if err := validateConfig(&config); err != nil {
@@ -62,19 +120,52 @@ func NewSlogLogger(config LogConfig, additionalContextKeys ...string) (*SlogLogg
 		sampler = sample.NewRateSampler(config.Sampling.Rate)
 	case sample.JitterSamplerType:
 		sampler = sample.NewJitterSampler(config.Sampling.Rate, config.Sampling.Jitter)
+	case sample.TraceSamplerType:
+		sampler = sample.NewTraceSampler(config.Sampling.Rate)
+	case sample.DedupSamplerType:
+		sampler = sample.NewDedupSampler(config.Sampling.First, config.Sampling.Thereafter, config.Sampling.Interval)
+	case sample.AdaptiveSamplerType:
+		sampler = sample.NewAdaptiveSampler(config.Sampling.Target, config.Sampling.Interval,
+			config.Sampling.MinRate, config.Sampling.MaxRate)
 	default:
 		sampler = sample.NewRateSampler(1) // 默认不采样
 	}
 
+	levels, err := newLevelTable(config.Level, config.LevelOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	redactor, err := newConfigRedactor(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	logger := &SlogLogger{
-		config:           config,
-		buffer:           make(chan slog.Record, config.AsyncBufferSize),
+		buffer:           make(chan queuedRecord, config.AsyncBufferSize),
+		flush:            make(chan chan struct{}),
 		done:             make(chan struct{}),
-		sampler:          sampler,
 		contextExtractor: NewDefaultContextExtractor(additionalContextKeys...),
+		levels:           levels,
 	}
+	dropped := metrics.Default().Counter(metrics.Opts{
+		Name:   "gokit_xlog_dropped_total",
+		Help:   "Number of log records dropped by xlog loggers.",
+		Labels: []string{"reason"},
+	})
+	logger.droppedSampled = dropped.With("sampled")
+	logger.droppedClosed = dropped.With("closed")
+	logger.root = logger
+	logger.config.Store(&config)
+	logger.sampler.Store(&sampler)
 	logger.handler.Store(handler)
-	logger.level.Store(config.Level)
+	logger.redactor.Store(redactor)
+
+	// 运行在 Kubernetes 中时自动附加 Pod 元数据，不在 Kubernetes 中时不附加任何字段
+	if config.EnableKubernetes {
+		if md, ok := LoadKubernetesMetadata(); ok {
+			logger.attrs = logger.metadataAttrs(md.Fields())
+		}
+	}
 
 	// 启动异步处理 goroutine
 	logger.wg.Add(1)
@@ -84,50 +175,79 @@ func NewSlogLogger(config LogConfig, additionalContextKeys ...string) (*SlogLogg
 }
 
 // createHandler 根据配置创建 slog.Handler
+// 级别过滤由日志器按名称完成，处理器本身放行所有级别。
+// JSON 与 Text 处理器的编码缓冲区由 slog 内部的缓冲池复用，每条记录只调用一次 Writer.Write，
+// 因此这里不使用 pool.ByteBufferPool，也不能把多条记录合并后写入（sink 依赖一次 Write 对应一条记录）
 func createHandler(config LogConfig) slog.Handler {
 	opts := &slog.HandlerOptions{
-		Level:     slog.Level(levelOrder[config.Level]),
-		AddSource: config.EnableCaller,
+		Level:       slog.Level(levelOrder[Debug]),
+		AddSource:   config.EnableCaller,
+		ReplaceAttr: replaceLevelAttr,
 	}
 
+	var handler slog.Handler
 	if config.Encoder == TextEncoder {
-		return slog.NewTextHandler(config.Writer, opts)
+		handler = slog.NewTextHandler(config.Writer, opts)
 	} else if config.Encoder == JSONEncoder {
-		return slog.NewJSONHandler(config.Writer, opts)
+		handler = slog.NewJSONHandler(config.Writer, opts)
 	} else if config.Encoder == ProtoEncoder {
 		return nil
 	}
-	return nil
+	if config.LoggerProvider != nil {
+		bridge := NewOTelHandler(config.LoggerProvider)
+		if handler == nil {
+			return bridge
+		}
+		return multiHandler{handler, bridge}
+	}
+	return handler
+}
+
+// queuedRecord 是排队等待异步写入的日志记录
+type queuedRecord struct {
+	record slog.Record
+	span   trace.SpanContext // 记录日志时 ctx 中或 WithTrace 绑定的 span，写入时放回 context 供 OTelHandler 关联
+}
+
+// context 返回携带记录所属 span 的 context
+func (q queuedRecord) context() context.Context {
+	if !q.span.IsValid() {
+		return context.Background()
+	}
+	return trace.ContextWithSpanContext(context.Background(), q.span)
 }
 
 // processLogs 异步处理日志记录
 func (l *SlogLogger) processLogs() {
 	defer l.wg.Done()
-	ticker := time.NewTicker(l.config.FlushInterval)
+	// 缓冲通道在创建时分配，批量大小与刷新间隔使用创建时的配置
+	config := l.config.Load()
+	ticker := time.NewTicker(config.FlushInterval)
 	defer ticker.Stop()
 
-	var records []slog.Record
+	var records []queuedRecord
 	for {
 		select {
 		case record := <-l.buffer:
-			if record.Message == "FLUSH_SIGNAL" {
-				// 刷新信号
-				l.writeBatch(records)
-				records = records[:0]
-				l.buffer <- slog.Record{} // 发送完成信号
-				continue
-			}
 			records = append(records, record)
-			if len(records) >= l.config.AsyncBufferSize {
+			if len(records) >= config.AsyncBufferSize {
 				l.writeBatch(records)
 				records = records[:0]
 			}
+		case flushed := <-l.flush:
+			records = l.drain(records)
+			l.writeBatch(records)
+			records = records[:0]
+			close(flushed)
 		case <-ticker.C:
+			records = l.appendSuppressed(records)
 			if len(records) > 0 {
 				l.writeBatch(records)
 				records = records[:0]
 			}
 		case <-l.done:
+			records = l.drain(records)
+			records = l.appendSuppressed(records)
 			if len(records) > 0 {
 				l.writeBatch(records)
 			}
@@ -136,61 +256,209 @@ func (l *SlogLogger) processLogs() {
 	}
 }
 
+// drain 取出缓冲通道中已经排队的所有日志记录
+func (l *SlogLogger) drain(records []queuedRecord) []queuedRecord {
+	for {
+		select {
+		case record := <-l.buffer:
+			records = append(records, record)
+		default:
+			return records
+		}
+	}
+}
+
+// appendSuppressed 为被限流去重采样器丢弃的日志生成汇总记录
+func (l *SlogLogger) appendSuppressed(records []queuedRecord) []queuedRecord {
+	reporter, ok := (*l.sampler.Load()).(sample.SuppressionReporter)
+	if !ok {
+		return records
+	}
+	for _, s := range reporter.Suppressed() {
+		level, msg, _ := strings.Cut(s.Key, dedupKeySep)
+		record := slog.NewRecord(time.Now(), slog.Level(levelOrder[Warn]),
+			fmt.Sprintf("suppressed %d similar messages", s.Count), 0)
+		record.AddAttrs(
+			slog.String("suppressed_level", level),
+			slog.String("suppressed_msg", msg),
+			slog.Uint64("suppressed_count", s.Count),
+		)
+		records = append(records, queuedRecord{record: record})
+	}
+	return records
+}
+
 // writeBatch 批量写入日志记录
-func (l *SlogLogger) writeBatch(records []slog.Record) {
+func (l *SlogLogger) writeBatch(records []queuedRecord) {
 	handler := l.handler.Load().(slog.Handler)
-	for _, record := range records {
-		if err := handler.Handle(context.Background(), record); err != nil {
+	for _, q := range records {
+		if err := handler.Handle(q.context(), q.record); err != nil {
 			l.logInternalError(fmt.Sprintf("Failed to handle log record: %v", err))
 		}
 	}
 }
 
 // SetLevel 设置日志级别
+// 在根日志器上调用时设置默认级别；在 Named 派生的日志器上调用时，只覆盖该名称的级别
 func (l *SlogLogger) SetLevel(level LogLevel) error {
-	l.level.Store(level)
-	return nil
+	if !level.IsValid() {
+		return fmt.Errorf("invalid log level: %q", level)
+	}
+	if l.name == "" {
+		l.root.levels.setBase(level)
+		return nil
+	}
+	return l.root.levels.set(l.name, level)
 }
 
 // GetLevel 获取当前日志级别
+// 命名日志器返回最具体的覆盖规则对应的级别，未命中时返回根级别
 func (l *SlogLogger) GetLevel() LogLevel {
-	return l.level.Load().(LogLevel)
+	levels := l.root.levels
+	gen := levels.gen.Load()
+	if cached := l.levelCache.Load(); cached != nil && cached.gen == gen {
+		return cached.level
+	}
+	level := levels.resolve(l.name)
+	l.levelCache.Store(&cachedLevel{gen: gen, level: level})
+	return level
+}
+
+// SetLevelOverride 新增或替换一条按名称覆盖级别的规则，例如 "storage.*" 或 "storage.mongo"
+func (l *SlogLogger) SetLevelOverride(pattern string, level LogLevel) error {
+	return l.root.levels.set(pattern, level)
+}
+
+// RemoveLevelOverride 删除一条按名称覆盖级别的规则
+func (l *SlogLogger) RemoveLevelOverride(pattern string) {
+	l.root.levels.remove(pattern)
+}
+
+// Name 返回日志器名称，根日志器返回空字符串
+func (l *SlogLogger) Name() string {
+	return l.name
 }
 
 // log 通用日志记录方法
 func (l *SlogLogger) log(ctx context.Context, level LogLevel, msg string, fields ...Field) {
+	root := l.root
 	currentLevel := l.GetLevel()
 	if currentLevel.IsHighThan(level) {
 		return
 	}
 
-	// 对于 Error 和 Fatal 级别的日志，不进行采样，始终记录
-	// 对于 Warn 及以下级别的日志，进行采样
-	if level.IsLowerOrEqualThan(Warn) {
-		if !l.sampler.Sample() {
-			return // 不记录这条日志
-		}
+	if !l.sample(ctx, level, msg) {
+		root.droppedSampled.Inc()
+		return // 不记录这条日志
 	}
 
-	attrs := make([]slog.Attr, 0, len(fields))
+	buf := getAttrBuffer()
+	defer putAttrBuffer(buf)
+	attrs := (*buf)[:0]
+	if l.name != "" {
+		attrs = append(attrs, slog.String(LoggerNameKey, l.name))
+	}
+	attrs = append(attrs, l.attrs...)
+	config := root.config.Load()
+	// 启用追踪时，未通过 WithTrace 绑定 trace 的日志从 context 中提取 trace 信息
+	if config.EnableTracing && l.traceID == "" {
+		if traceID, spanID := extractTraceInfo(ctx); traceID != "" {
+			attrs = append(attrs, slog.String(TraceIDKey, traceID), slog.String(SpanIDKey, spanID))
+		}
+	}
+	callAttrs := len(attrs)
+	redactor := root.redactor.Load()
 	for _, f := range fields {
-		attrs = append(attrs, slog.Any(f.Key, f.Value))
+		if redactor != nil {
+			f = redactor.RedactField(f)
+		}
+		attrs = append(attrs, f.attr())
+	}
+	// Error 及以上级别的日志附加当前 goroutine 的调用栈，跳过 log 与 Error 等日志方法自身
+	if config.EnableStacktrace && level.IsHighThan(Warn) {
+		attrs = append(attrs, slog.String(StacktraceKey, callerStack(2+config.CallerSkip)))
+	}
+
+	// 日志所属的 span：优先使用 ctx 中的 span，其次使用 WithTrace 绑定的 span
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() && l.span != nil {
+		span = l.span
+	}
+	// Error 及以上级别的日志记录为当前 span 的事件
+	if config.EnableSpanEvents && level.IsHighThan(Warn) {
+		recordSpanEvent(span, level, msg, fields, attrs[callAttrs:], redactor)
 	}
 
 	record := slog.NewRecord(time.Now(), slog.Level(levelOrder[level]), msg, 0)
 	record.AddAttrs(attrs...)
+	*buf = attrs
 
+	queued := queuedRecord{record: record, span: span.SpanContext()}
 	select {
-	case l.buffer <- record:
-	case <-l.done:
+	case root.buffer <- queued:
+	case <-root.done:
 		// 日志记录器已关闭，不再接受新的日志
+		root.droppedClosed.Inc()
 	default:
 		// 缓冲区已满，直接写入
-		handler := l.handler.Load().(slog.Handler)
+		if !trace.SpanContextFromContext(ctx).IsValid() && queued.span.IsValid() {
+			ctx = trace.ContextWithSpanContext(ctx, queued.span)
+		}
+		handler := root.handler.Load().(slog.Handler)
 		_ = handler.Handle(ctx, record)
 	}
 }
 
+// maxPooledAttrs 放回缓冲池的字段缓冲区的最大容量，避免偶发的超大日志长期占用内存
+const maxPooledAttrs = 64
+
+// attrPool 复用组装日志记录时使用的字段缓冲区，字段复制进 slog.Record 后即可归还
+var attrPool = sync.Pool{
+	New: func() any {
+		attrs := make([]slog.Attr, 0, 16)
+		return &attrs
+	},
+}
+
+// getAttrBuffer 从缓冲池获取字段缓冲区
+func getAttrBuffer() *[]slog.Attr {
+	return attrPool.Get().(*[]slog.Attr)
+}
+
+// putAttrBuffer 清空字段缓冲区并放回缓冲池
+func putAttrBuffer(buf *[]slog.Attr) {
+	if cap(*buf) > maxPooledAttrs {
+		return
+	}
+	clear(*buf)
+	*buf = (*buf)[:0]
+	attrPool.Put(buf)
+}
+
+// sample 执行采样判断
+// 限流去重采样器按级别和消息计数，对 Error 同样生效以抑制错误风暴，Fatal 始终记录；
+// 其他采样器只对 Warn 及以下级别生效，Error 和 Fatal 始终记录。
+// 采样器支持 trace 感知时，优先使用 ctx 中的 trace，其次使用 WithTrace 绑定的 trace，
+// 保证同一 trace 的日志采样结果一致
+func (l *SlogLogger) sample(ctx context.Context, level LogLevel, msg string) bool {
+	sampler := *l.root.sampler.Load()
+	if keyed, ok := sampler.(sample.KeyedSampler); ok {
+		return level == Fatal || keyed.SampleKey(string(level)+dedupKeySep+msg)
+	}
+	if level.IsHighThan(Warn) {
+		return true
+	}
+	traceSampler, ok := sampler.(sample.TraceAwareSampler)
+	if !ok {
+		return sampler.Sample()
+	}
+	traceID, sampled := extractTraceSampling(ctx)
+	if traceID == "" {
+		traceID, sampled = l.traceID, l.traceSampled
+	}
+	return traceSampler.SampleTrace(traceID, sampled)
+}
+
 // Debug 记录调试级别的日志
 func (l *SlogLogger) Debug(msg string, fields ...Field) {
 	l.log(context.Background(), Debug, msg, fields...)
@@ -243,67 +511,91 @@ func (l *SlogLogger) FatalContext(ctx context.Context, msg string, fields ...Fie
 	os.Exit(1)
 }
 
+// derive 派生一个共享根日志器状态的子日志器，并追加预绑定字段
+func (l *SlogLogger) derive(name string, attrs ...slog.Attr) *SlogLogger {
+	child := &SlogLogger{
+		root:         l.root,
+		name:         name,
+		attrs:        make([]slog.Attr, 0, len(l.attrs)+len(attrs)),
+		traceID:      l.traceID,
+		traceSampled: l.traceSampled,
+		span:         l.span,
+	}
+	child.attrs = append(child.attrs, l.attrs...)
+	child.attrs = append(child.attrs, attrs...)
+	return child
+}
+
+// Named 创建一个命名子日志器，名称以 "." 与父日志器名称拼接，例如 "storage" -> "storage.mongo"
+// 子日志器的级别可通过 LogConfig.LevelOverrides 或 SetLevelOverride 按名称前缀单独调整，
+// 名称会以 LoggerNameKey 字段输出
+func (l *SlogLogger) Named(name string) HighPerformanceLogger {
+	if name == "" {
+		return l
+	}
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	return l.derive(name)
+}
+
 // WithTrace 添加追踪信息到日志
 func (l *SlogLogger) WithTrace(ctx context.Context) HighPerformanceLogger {
 	traceID, spanID := extractTraceInfo(ctx)
-	oldHandler := l.handler.Load().(slog.Handler)
-
 	// 创建新的属性，包含追踪信息
-	newAttrs := []slog.Attr{
-		slog.String("trace_id", traceID),
-		slog.String("span_id", spanID),
+	child := l.derive(l.name,
+		slog.String(TraceIDKey, traceID),
+		slog.String(SpanIDKey, spanID),
+	)
+	if traceID != "" {
+		child.traceID, child.traceSampled = extractTraceSampling(ctx)
+		child.span = trace.SpanFromContext(ctx)
 	}
-	newHandler := oldHandler.WithAttrs(newAttrs)
-	newLogger := &SlogLogger{
-		config: l.config,
-		buffer: l.buffer,
-		done:   l.done,
-	}
-	newLogger.handler.Store(newHandler)
-	newLogger.level.Store(l.level.Load())
-
-	return newLogger
+	return child
 }
 
 // WithMetadata 添加元数据到日志
 func (l *SlogLogger) WithMetadata(metadata map[string]any) HighPerformanceLogger {
-	oldHandler := l.handler.Load().(slog.Handler)
+	return l.derive(l.name, l.metadataAttrs(metadata)...)
+}
+
+// metadataAttrs 将元数据按键排序后转换为字段，启用脱敏时同时脱敏
+func (l *SlogLogger) metadataAttrs(metadata map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	attrs := make([]slog.Attr, 0, len(metadata))
-	for k, v := range metadata {
+	redactor := l.root.redactor.Load()
+	for _, k := range keys {
+		v := metadata[k]
+		if redactor != nil {
+			v = redactor.Redact(k, v)
+		}
 		attrs = append(attrs, slog.Any(k, v))
 	}
-	// 使用现有的处理器，添加新的属性
-	newHandler := oldHandler.WithAttrs(attrs)
-	newLogger := &SlogLogger{
-		config: l.config,
-		buffer: l.buffer,
-		done:   l.done,
-	}
-	newLogger.handler.Store(newHandler)
-	newLogger.level.Store(l.level.Load())
-	return newLogger
+	return attrs
 }
 
-// Flush 刷新所有缓冲的日志
+// Flush 刷新所有缓冲的日志，阻塞直到已排队的日志写入完成
 func (l *SlogLogger) Flush() error {
-	// 创建一个特殊的刷新信号
-	flushDone := make(chan struct{})
-	// 发送刷新信号到处理goroutine
+	root := l.root
+	flushed := make(chan struct{})
 	select {
-	case l.buffer <- slog.Record{Time: time.Now(), Message: "FLUSH_SIGNAL"}: // 发送一个特殊的Record作为刷新信号
-		// 等待刷新完成的信号
-		<-flushDone
-	default:
-		// 如果缓冲区已满，直接返回，因为所有日志都会被处理
-		return nil
+	case root.flush <- flushed:
+		<-flushed
+	case <-root.done:
+		// 日志记录器已关闭，剩余日志在关闭时已经写出
 	}
 	return nil
 }
 
-// Close 关闭日志记录器
+// Close 关闭日志记录器，派生的日志器会关闭其根日志器，重复调用是安全的
 func (l *SlogLogger) Close() {
-	close(l.done)
-	l.wg.Wait()
+	root := l.root
+	root.closeOnce.Do(func() { close(root.done) })
+	root.wg.Wait()
 }
 
 // extractTraceInfo 从 context 中提取追踪信息
@@ -319,6 +611,15 @@ func extractTraceInfo(ctx context.Context) (string, string) {
 	return traceID, spanID
 }
 
+// extractTraceSampling 从 context 中提取 trace ID 以及 span 是否已被采样
+func extractTraceSampling(ctx context.Context) (string, bool) {
+	traceID, _ := extractTraceInfo(ctx)
+	if traceID == "" {
+		return "", false
+	}
+	return traceID, trace.SpanContextFromContext(ctx).IsSampled()
+}
+
 // SlogFactory 实现 LoggerFactory 接口
 type SlogFactory struct{}
 
@@ -329,34 +630,37 @@ func (f *SlogFactory) CreateLogger(config LogConfig) (HighPerformanceLogger, err
 
 // UpdateSamplingRate 更新采样率
 func (l *SlogLogger) UpdateSamplingRate(rate float64) {
-	if l.sampler != nil {
-		l.sampler.SetRate(rate)
+	if sampler := *l.root.sampler.Load(); sampler != nil {
+		sampler.SetRate(rate)
 	}
 }
 
 // GetSamplingRate 获取当前采样率
 func (l *SlogLogger) GetSamplingRate() float64 {
-	if l.sampler != nil {
-		return l.sampler.GetRate()
+	if sampler := *l.root.sampler.Load(); sampler != nil {
+		return sampler.GetRate()
 	}
 	return 1.0 // 默认不采样
 }
 
-// UpdateConfig 动态更新日志配置
+// UpdateConfig 动态更新日志配置，派生的日志器会更新其根日志器
 func (l *SlogLogger) UpdateConfig(newConfig LogConfig) error {
+	root := l.root
 	if err := validateConfig(&newConfig); err != nil {
 		return fmt.Errorf("invalid config: %w", err)
 	}
-	// 更新日志级别
-	if newConfig.Level != l.config.Level {
-		err := l.SetLevel(newConfig.Level)
-		if err != nil {
+	root.updateMu.Lock()
+	defer root.updateMu.Unlock()
+	oldConfig := root.config.Load()
+	// 更新日志级别与按名称覆盖的级别
+	if newConfig.Level != oldConfig.Level || !maps.Equal(newConfig.LevelOverrides, oldConfig.LevelOverrides) {
+		if err := root.levels.replace(newConfig.Level, newConfig.LevelOverrides); err != nil {
 			return err
 		}
 	}
 
 	// 更新采样配置
-	if newConfig.Sampling != l.config.Sampling {
+	if newConfig.Sampling != oldConfig.Sampling {
 		var newSampler sample.Sampler
 		switch newConfig.Sampling.Type {
 		case sample.RateSamplerType:
@@ -371,19 +675,40 @@ func (l *SlogLogger) UpdateConfig(newConfig LogConfig) error {
 			} else {
 				newSampler = sample.NewJitterSampler(1, newConfig.Sampling.Jitter) // 默认不采样
 			}
+		case sample.TraceSamplerType:
+			if newConfig.Sampling.Rate > 0 && newConfig.Sampling.Rate <= 1 {
+				newSampler = sample.NewTraceSampler(newConfig.Sampling.Rate)
+			} else {
+				newSampler = sample.NewTraceSampler(1) // 默认不采样
+			}
+		case sample.DedupSamplerType:
+			newSampler = sample.NewDedupSampler(newConfig.Sampling.First, newConfig.Sampling.Thereafter, newConfig.Sampling.Interval)
+		case sample.AdaptiveSamplerType:
+			newSampler = sample.NewAdaptiveSampler(newConfig.Sampling.Target, newConfig.Sampling.Interval,
+				newConfig.Sampling.MinRate, newConfig.Sampling.MaxRate)
 		default:
 			newSampler = sample.NewRateSampler(1) // 默认使用 RateSampler 且不采样
 		}
-		l.sampler = newSampler
+		root.sampler.Store(&newSampler)
+	}
+
+	// 更新脱敏规则
+	if !reflect.DeepEqual(newConfig.Redaction, oldConfig.Redaction) {
+		redactor, err := newConfigRedactor(newConfig)
+		if err != nil {
+			return err
+		}
+		root.redactor.Store(redactor)
 	}
 
 	// 更新处理器
-	if newConfig.Encoder != l.config.Encoder || newConfig.Writer != l.config.Writer {
+	if newConfig.Encoder != oldConfig.Encoder || newConfig.Writer != oldConfig.Writer ||
+		newConfig.LoggerProvider != oldConfig.LoggerProvider || newConfig.EnableCaller != oldConfig.EnableCaller {
 		newHandler := createHandler(newConfig)
-		l.handler.Store(newHandler)
+		root.handler.Store(newHandler)
 	}
 	// 更新其他配置
-	l.config = newConfig
+	root.config.Store(&newConfig)
 	return nil
 }
 
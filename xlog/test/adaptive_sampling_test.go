@@ -0,0 +1,38 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/metrics/sample"
+	"github.com/omeyang/gokit/xlog"
+)
+
+func TestAdaptiveSamplingConfig(t *testing.T) {
+	config := xlog.LogConfig{
+		Level:           xlog.Info,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          &syncBuffer{},
+		AsyncBufferSize: 16,
+		FlushInterval:   time.Second,
+	}
+	config.Sampling.Type = sample.AdaptiveSamplerType
+	config.Sampling.Target = 100
+	config.Sampling.Interval = time.Second
+	config.Sampling.MinRate = 0.1
+	config.Sampling.MaxRate = 0.5
+
+	logger, err := xlog.NewSlogLogger(config)
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	defer logger.Close()
+	if rate := logger.GetSamplingRate(); rate != 0.5 {
+		t.Errorf("initial sampling rate = %v, want max rate 0.5", rate)
+	}
+
+	config.Sampling.MinRate = 0.8
+	if _, err := xlog.NewSlogLogger(config); err == nil {
+		t.Error("expected error when min rate exceeds max rate")
+	}
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/metrics/sample"
+	"github.com/omeyang/gokit/xlog"
+)
+
+func TestDedupSamplingSuppressesErrorStorm(t *testing.T) {
+	out := &syncBuffer{}
+	config := xlog.LogConfig{
+		Level:           xlog.Info,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          out,
+		AsyncBufferSize: 1024,
+		FlushInterval:   time.Hour,
+	}
+	config.Sampling.Type = sample.DedupSamplerType
+	config.Sampling.First = 5
+	config.Sampling.Thereafter = 100
+	config.Sampling.Interval = time.Hour
+	logger, err := xlog.NewSlogLogger(config)
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		logger.Error("mongo unavailable", xlog.Field{Key: "attempt", Value: i})
+	}
+	logger.Info("unrelated")
+	logger.Close()
+
+	var errors, summaries int
+	for _, line := range out.lines(t) {
+		switch {
+		case line["msg"] == "mongo unavailable":
+			errors++
+		case line["suppressed_msg"] == "mongo unavailable":
+			summaries++
+			if line["msg"] != "suppressed 986 similar messages" || line["suppressed_level"] != "ERROR" {
+				t.Errorf("unexpected summary %v", line)
+			}
+		}
+	}
+	// 前 5 条全部保留，之后 995 条中每 100 条保留一条，共 14 条，丢弃 986 条
+	if errors != 14 {
+		t.Errorf("error lines = %d, want 14", errors)
+	}
+	if summaries != 1 {
+		t.Errorf("summary lines = %d, want 1", summaries)
+	}
+}
@@ -0,0 +1,158 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/omeyang/gokit/xlog"
+)
+
+// stackError 模拟在创建时采集调用栈的错误
+type stackError struct {
+	msg string
+	pcs []uintptr
+}
+
+func newStackError(msg string) *stackError {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	return &stackError{msg: msg, pcs: pcs[:n]}
+}
+
+func (e *stackError) Error() string         { return e.msg }
+func (e *stackError) StackTrace() []uintptr { return e.pcs }
+
+func TestErrFieldChain(t *testing.T) {
+	logger, out := newTestLogger(t, xlog.Info, nil)
+	defer logger.Close()
+
+	root := newStackError("connection refused")
+	err := fmt.Errorf("query orders: %w", fmt.Errorf("dial: %w", root))
+	logger.Error("query failed", xlog.Err(err))
+	logger.Error("nil error", xlog.Err(nil))
+	_ = logger.Flush()
+
+	lines := out.lines(t)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines", len(lines))
+	}
+	field, ok := lines[0][xlog.ErrorKey].(map[string]any)
+	if !ok {
+		t.Fatalf("error field = %#v", lines[0][xlog.ErrorKey])
+	}
+	if field["msg"] != "query orders: dial: connection refused" || field["type"] != "*fmt.wrapError" {
+		t.Errorf("error field = %v", field)
+	}
+	chain, _ := field["chain"].([]any)
+	if len(chain) != 2 {
+		t.Fatalf("chain = %v", field["chain"])
+	}
+	inner := chain[1].(map[string]any)
+	if inner["msg"] != "connection refused" || inner["type"] != "*test.stackError" {
+		t.Errorf("inner = %v", inner)
+	}
+	if stack, _ := inner["stack"].(string); !strings.Contains(stack, "test.TestErrFieldChain") {
+		t.Errorf("stack = %q", stack)
+	}
+	if _, ok := chain[0].(map[string]any)["stack"]; ok {
+		t.Error("wrapper without captured stack should not have a stack")
+	}
+	if v, ok := lines[1][xlog.ErrorKey]; !ok || v != nil {
+		t.Errorf("nil error field = %#v", v)
+	}
+}
+
+func TestErrFieldJoin(t *testing.T) {
+	logger, out := newTestLogger(t, xlog.Info, nil)
+	defer logger.Close()
+
+	err := fmt.Errorf("close: %w", errors.Join(errors.New("flush failed"), fmt.Errorf("sync: %w", errors.New("disk full"))))
+	logger.Warn("shutdown", xlog.NamedErr("close_error", err))
+	_ = logger.Flush()
+
+	field := out.lines(t)[0]["close_error"].(map[string]any)
+	chain := field["chain"].([]any)
+	if len(chain) != 1 {
+		t.Fatalf("chain = %v", chain)
+	}
+	joined := chain[0].(map[string]any)["errors"].([]any)
+	if len(joined) != 2 {
+		t.Fatalf("joined = %v", joined)
+	}
+	second := joined[1].(map[string]any)
+	if second["msg"] != "sync: disk full" {
+		t.Errorf("second = %v", second)
+	}
+	if c := second["chain"].([]any); len(c) != 1 || c[0].(map[string]any)["msg"] != "disk full" {
+		t.Errorf("second chain = %v", second["chain"])
+	}
+}
+
+func TestStacktraceOnError(t *testing.T) {
+	out := &syncBuffer{}
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:            xlog.Info,
+		Encoder:          xlog.JSONEncoder,
+		Writer:           out,
+		AsyncBufferSize:  16,
+		FlushInterval:    time.Second,
+		EnableStacktrace: true,
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.Warn("no stack")
+	logger.Named("db").ErrorContext(context.Background(), "with stack")
+	_ = logger.Flush()
+
+	lines := out.lines(t)
+	if _, ok := lines[0][xlog.StacktraceKey]; ok {
+		t.Error("WARN log should not have a stacktrace")
+	}
+	stack, _ := lines[1][xlog.StacktraceKey].(string)
+	first, _, _ := strings.Cut(stack, "\n")
+	if !strings.HasSuffix(first, "test.TestStacktraceOnError") {
+		t.Errorf("stacktrace should start at the caller, got %q", stack)
+	}
+}
+
+func TestSpanEventStacktrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:            xlog.Info,
+		Encoder:          xlog.JSONEncoder,
+		Writer:           &syncBuffer{},
+		AsyncBufferSize:  16,
+		FlushInterval:    time.Second,
+		EnableSpanEvents: true,
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.ErrorContext(ctx, "query failed", xlog.Err(fmt.Errorf("query: %w", newStackError("timeout"))))
+	span.End()
+
+	attrs := map[string]string{}
+	for _, kv := range recorder.Ended()[0].Events()[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["exception.message"] != "query: timeout" || attrs["exception.type"] != "*fmt.wrapError" ||
+		!strings.Contains(attrs["exception.stacktrace"], "test.TestSpanEventStacktrace") {
+		t.Errorf("unexpected event attributes %v", attrs)
+	}
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/xlog"
+)
+
+// newBenchLogger 创建一个写入 io.Discard 的 JSON 日志器
+func newBenchLogger(b *testing.B) *xlog.SlogLogger {
+	b.Helper()
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:           xlog.Info,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          io.Discard,
+		AsyncBufferSize: 1024,
+		FlushInterval:   time.Second,
+	})
+	if err != nil {
+		b.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	b.Cleanup(logger.Close)
+	return logger
+}
+
+// BenchmarkLogAnyFields 使用 Field{Key, Value} 构造字段，每个非指针值都会装箱
+func BenchmarkLogAnyFields(b *testing.B) {
+	logger := newBenchLogger(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled",
+			xlog.Field{Key: "method", Value: "GET"},
+			xlog.Field{Key: "status", Value: 200 + i%100},
+			xlog.Field{Key: "bytes", Value: int64(i)},
+			xlog.Field{Key: "latency", Value: time.Duration(i)},
+		)
+	}
+}
+
+// BenchmarkLogTypedFields 使用类型化构造函数，字段值不装箱
+func BenchmarkLogTypedFields(b *testing.B) {
+	logger := newBenchLogger(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled",
+			xlog.String("method", "GET"),
+			xlog.Int("status", 200+i%100),
+			xlog.Int64("bytes", int64(i)),
+			xlog.Duration("latency", time.Duration(i)),
+		)
+	}
+}
+
+// BenchmarkLogFiltered 被级别过滤的日志不应产生分配
+func BenchmarkLogFiltered(b *testing.B) {
+	logger := newBenchLogger(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Debug("filtered", xlog.Int("i", i), xlog.String("k", "v"))
+	}
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/xlog"
+	"github.com/omeyang/gokit/xlog/xlogtest"
+)
+
+// point 是用于测试 Object 字段的对象
+type point struct{ x, y int }
+
+func (p *point) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("x", p.x), slog.Int("y", p.y))
+}
+
+func TestTypedFields(t *testing.T) {
+	logger, out := newTestLogger(t, xlog.Info, nil)
+	defer logger.Close()
+
+	logger.Info("typed",
+		xlog.String("method", "GET"),
+		xlog.Int("status", 200),
+		xlog.Int64("bytes", -5),
+		xlog.Uint64("id", 1<<63),
+		xlog.Float64("ratio", 0.25),
+		xlog.Bool("cached", true),
+		xlog.Duration("latency", 1500*time.Millisecond),
+		xlog.Object("pos", &point{1, 2}),
+		xlog.Any("tags", []string{"a"}),
+	)
+	_ = logger.Flush()
+
+	line := out.lines(t)[0]
+	want := map[string]any{
+		"method":  "GET",
+		"status":  float64(200),
+		"bytes":   float64(-5),
+		"id":      float64(1 << 63),
+		"ratio":   0.25,
+		"cached":  true,
+		"latency": float64(1500 * time.Millisecond),
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, line[k], v)
+		}
+	}
+	if pos, _ := line["pos"].(map[string]any); pos["x"] != float64(1) || pos["y"] != float64(2) {
+		t.Errorf("pos = %#v", line["pos"])
+	}
+	if tags, _ := line["tags"].([]any); len(tags) != 1 || tags[0] != "a" {
+		t.Errorf("tags = %#v", line["tags"])
+	}
+}
+
+func TestTypedFieldAny(t *testing.T) {
+	cases := []struct {
+		field xlog.Field
+		want  any
+	}{
+		{xlog.String("k", "v"), "v"},
+		{xlog.Int("k", 3), int64(3)},
+		{xlog.Uint64("k", 7), uint64(7)},
+		{xlog.Float64("k", 1.5), 1.5},
+		{xlog.Bool("k", false), false},
+		{xlog.Duration("k", time.Second), time.Second},
+		{xlog.Field{Key: "k", Value: 3}, 3},
+	}
+	for _, c := range cases {
+		if got := c.field.Any(); got != c.want {
+			t.Errorf("Any() = %#v, want %#v", got, c.want)
+		}
+	}
+}
+
+func TestTypedFieldsRedaction(t *testing.T) {
+	out := &syncBuffer{}
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:           xlog.Info,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          out,
+		AsyncBufferSize: 16,
+		FlushInterval:   time.Second,
+		Redaction:       xlog.RedactConfig{Enabled: true, Builtin: true, Rules: []xlog.RedactRule{{Key: "pin"}}},
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("login", xlog.Int("pin", 1234), xlog.Int("attempt", 2), xlog.String("password", "hunter2"))
+	_ = logger.Flush()
+
+	line := out.lines(t)[0]
+	if line["pin"] == float64(1234) || line["password"] == "hunter2" {
+		t.Errorf("sensitive fields not redacted: %v", line)
+	}
+	if line["attempt"] != float64(2) {
+		t.Errorf("attempt = %#v", line["attempt"])
+	}
+}
+
+func TestTypedFieldsInTestLogger(t *testing.T) {
+	logger := xlogtest.New(t)
+	logger.Info("retry", xlog.Int("attempt", 2), xlog.Duration("backoff", time.Second))
+	logger.RequireLogged(xlog.Info, "retry", xlog.Field{Key: "attempt", Value: 2}, xlog.Duration("backoff", time.Second))
+}
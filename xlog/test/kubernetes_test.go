@@ -0,0 +1,101 @@
+package test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/omeyang/gokit/xlog"
+)
+
+// fakeEnv 返回一个从 map 读取环境变量的函数
+func fakeEnv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestLoadKubernetesMetadata(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/podinfo/name":      {Data: []byte("file-pod\n")},
+		"etc/podinfo/namespace": {Data: []byte("payments\n")},
+		"etc/podinfo/labels":    {Data: []byte("app=\"checkout\"\ntier=\"backend\"\nbroken\n")},
+	}
+	md, ok := xlog.LoadKubernetesMetadata(
+		xlog.WithKubernetesFS(fsys),
+		xlog.WithKubernetesEnv(fakeEnv(map[string]string{
+			xlog.EnvPodName:       "checkout-7d9f",
+			xlog.EnvNodeName:      "node-3",
+			xlog.EnvContainerName: "app",
+		})),
+	)
+	if !ok {
+		t.Fatal("expected to detect kubernetes")
+	}
+	if md.PodName != "checkout-7d9f" {
+		t.Errorf("PodName = %q, env should win over files", md.PodName)
+	}
+	if md.Namespace != "payments" || md.NodeName != "node-3" || md.ContainerName != "app" {
+		t.Errorf("unexpected metadata %+v", md)
+	}
+	if len(md.Labels) != 2 || md.Labels["app"] != "checkout" || md.Labels["tier"] != "backend" {
+		t.Errorf("Labels = %v", md.Labels)
+	}
+
+	fields := md.Fields()
+	if fields[xlog.K8sPodNameKey] != "checkout-7d9f" || fields[xlog.K8sNamespaceKey] != "payments" {
+		t.Errorf("Fields() = %v", fields)
+	}
+	if _, ok := fields[xlog.K8sPodIPKey]; ok {
+		t.Error("empty values should be omitted")
+	}
+}
+
+func TestLoadKubernetesMetadataCustomDirAndHostname(t *testing.T) {
+	fsys := fstest.MapFS{
+		"var/run/pod/labels": {Data: []byte(`app="api"`)},
+	}
+	md, ok := xlog.LoadKubernetesMetadata(
+		xlog.WithKubernetesFS(fsys),
+		xlog.WithPodInfoDir("/var/run/pod"),
+		xlog.WithKubernetesEnv(fakeEnv(map[string]string{"HOSTNAME": "api-0"})),
+	)
+	if !ok || md.PodName != "api-0" || md.Labels["app"] != "api" {
+		t.Errorf("LoadKubernetesMetadata() = %+v, %v", md, ok)
+	}
+}
+
+func TestLoadKubernetesMetadataOutsideCluster(t *testing.T) {
+	md, ok := xlog.LoadKubernetesMetadata(
+		xlog.WithKubernetesFS(fstest.MapFS{}),
+		xlog.WithKubernetesEnv(fakeEnv(map[string]string{"HOSTNAME": "laptop"})),
+	)
+	if ok || len(md.Fields()) != 0 {
+		t.Errorf("expected no metadata outside kubernetes, got %+v", md)
+	}
+}
+
+func TestSlogLoggerKubernetesMetadata(t *testing.T) {
+	t.Setenv(xlog.EnvPodName, "web-1")
+	t.Setenv(xlog.EnvPodNamespace, "default")
+
+	out := &syncBuffer{}
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:            xlog.Info,
+		Encoder:          xlog.JSONEncoder,
+		Writer:           out,
+		AsyncBufferSize:  16,
+		FlushInterval:    time.Second,
+		EnableKubernetes: true,
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	logger.Named("api").Info("ready")
+	logger.Close()
+
+	lines := out.lines(t)
+	if len(lines) != 1 || lines[0][xlog.K8sPodNameKey] != "web-1" || lines[0][xlog.K8sNamespaceKey] != "default" {
+		t.Errorf("unexpected output %v", lines)
+	}
+}
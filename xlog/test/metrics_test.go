@@ -0,0 +1,45 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/metrics"
+	"github.com/omeyang/gokit/metrics/sample"
+	"github.com/omeyang/gokit/xlog"
+)
+
+func TestSampledDropsAreCounted(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.SetDefault(registry)
+	defer metrics.SetDefault(nil)
+
+	config := xlog.LogConfig{
+		Level:           xlog.Info,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          &syncBuffer{},
+		AsyncBufferSize: 16,
+		FlushInterval:   time.Second,
+	}
+	config.Sampling.Type = sample.RateSamplerType
+	config.Sampling.Rate = 0
+
+	logger, err := xlog.NewSlogLogger(config)
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	for i := 0; i < 3; i++ {
+		logger.Info("dropped")
+	}
+
+	var b strings.Builder
+	if err := registry.WriteText(&b); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	if want := `gokit_xlog_dropped_total{reason="sampled"} 3`; !strings.Contains(b.String(), want) {
+		t.Errorf("metrics output missing %q:\n%s", want, b.String())
+	}
+}
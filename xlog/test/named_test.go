@@ -0,0 +1,213 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/metrics/sample"
+	"github.com/omeyang/gokit/xlog"
+)
+
+// syncBuffer 是并发安全的内存写入器
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// lines 解析写入的 JSON 日志行
+func (b *syncBuffer) lines(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid json line %q: %v", line, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// newTestLogger 创建一个写入内存的 JSON 日志器
+func newTestLogger(t *testing.T, level xlog.LogLevel, overrides map[string]xlog.LogLevel) (*xlog.SlogLogger, *syncBuffer) {
+	t.Helper()
+	out := &syncBuffer{}
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:           level,
+		LevelOverrides:  overrides,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          out,
+		AsyncBufferSize: 16,
+		FlushInterval:   time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	return logger, out
+}
+
+func TestNamedLoggerOverrides(t *testing.T) {
+	logger, out := newTestLogger(t, xlog.Info, map[string]xlog.LogLevel{
+		"storage.*":     xlog.Debug,
+		"storage.redis": xlog.Error,
+	})
+
+	storage := logger.Named("storage")
+	mongo := storage.Named("mongo")
+	redis := storage.Named("redis")
+	other := logger.Named("cfg")
+
+	if got := mongo.GetLevel(); got != xlog.Debug {
+		t.Errorf("mongo level = %s, want DEBUG", got)
+	}
+	if got := redis.GetLevel(); got != xlog.Error {
+		t.Errorf("redis level = %s, want ERROR", got)
+	}
+	if got := other.GetLevel(); got != xlog.Info {
+		t.Errorf("cfg level = %s, want INFO", got)
+	}
+
+	mongo.Debug("mongo debug")
+	redis.Warn("redis warn")
+	other.Debug("cfg debug")
+	logger.Debug("root debug")
+	logger.Close()
+
+	lines := out.lines(t)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %v", len(lines), lines)
+	}
+	if lines[0]["msg"] != "mongo debug" || lines[0][xlog.LoggerNameKey] != "storage.mongo" {
+		t.Errorf("unexpected record %v", lines[0])
+	}
+}
+
+func TestNamedLoggerSetLevel(t *testing.T) {
+	logger, out := newTestLogger(t, xlog.Warn, nil)
+	defer logger.Close()
+
+	child := logger.Named("storage")
+	if err := child.SetLevel(xlog.Debug); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	if got := logger.GetLevel(); got != xlog.Warn {
+		t.Errorf("root level = %s, want WARN", got)
+	}
+	if got := child.Named("mongo").GetLevel(); got != xlog.Warn {
+		t.Errorf("grandchild level = %s, want WARN", got)
+	}
+
+	if err := logger.SetLevelOverride("storage.*", xlog.Info); err != nil {
+		t.Fatalf("SetLevelOverride() error = %v", err)
+	}
+	if got := child.Named("mongo").GetLevel(); got != xlog.Info {
+		t.Errorf("grandchild level = %s, want INFO", got)
+	}
+	if got := child.GetLevel(); got != xlog.Debug {
+		t.Errorf("exact override should win, got %s", got)
+	}
+
+	if err := logger.SetLevel(xlog.Error); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	logger.Named("cfg").Warn("dropped")
+	child.WithMetadata(map[string]any{"k": "v"}).Debug("kept")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	lines := out.lines(t)
+	if len(lines) != 1 || lines[0]["k"] != "v" || lines[0][xlog.LoggerNameKey] != "storage" {
+		t.Fatalf("unexpected output %v", lines)
+	}
+}
+
+func TestParseLevelOverrides(t *testing.T) {
+	got, err := xlog.ParseLevelOverrides("storage.*=debug, xlog=WARN,")
+	if err != nil {
+		t.Fatalf("ParseLevelOverrides() error = %v", err)
+	}
+	if got["storage.*"] != xlog.Debug || got["xlog"] != xlog.Warn || len(got) != 2 {
+		t.Errorf("ParseLevelOverrides() = %v", got)
+	}
+
+	for _, spec := range []string{"storage", "storage=TRACE"} {
+		if _, err := xlog.ParseLevelOverrides(spec); err == nil {
+			t.Errorf("ParseLevelOverrides(%q) expected error", spec)
+		}
+	}
+
+	_, err = xlog.NewSlogLogger(xlog.LogConfig{
+		LevelOverrides:  map[string]xlog.LogLevel{"a*b": xlog.Debug},
+		Writer:          &bytes.Buffer{},
+		AsyncBufferSize: 1,
+		FlushInterval:   time.Second,
+	})
+	if err == nil {
+		t.Error("NewSlogLogger() expected error for invalid pattern")
+	}
+}
+
+func TestDerivedLoggerClose(t *testing.T) {
+	logger, _ := newTestLogger(t, xlog.Info, nil)
+	child := logger.Named("child")
+	child.Close()
+	logger.Close()
+	child.Close()
+}
+
+func TestUpdateConfigConcurrentWithLogging(t *testing.T) {
+	logger, out := newTestLogger(t, xlog.Info, nil)
+	defer logger.Close()
+	named := logger.Named("worker")
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					named.Error("boom")
+					_ = logger.GetSamplingRate()
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		config := xlog.LogConfig{
+			Level:            xlog.Info,
+			Encoder:          xlog.JSONEncoder,
+			Writer:           out,
+			AsyncBufferSize:  16,
+			FlushInterval:    time.Second,
+			EnableStacktrace: i%2 == 0,
+		}
+		config.Sampling.Type = sample.DedupSamplerType
+		config.Sampling.First = i + 1
+		if err := logger.UpdateConfig(config); err != nil {
+			t.Fatalf("UpdateConfig() error = %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,225 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/omeyang/gokit/xlog"
+)
+
+// memoryExporter 是保存导出日志记录的内存 Exporter
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *memoryExporter) snapshot() []sdklog.Record {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]sdklog.Record(nil), e.records...)
+}
+
+// recordAttrs 取出日志记录的字段
+func recordAttrs(r sdklog.Record) map[string]otellog.Value {
+	attrs := map[string]otellog.Value{}
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	return attrs
+}
+
+func TestSpanEventsForErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:            xlog.Info,
+		Encoder:          xlog.JSONEncoder,
+		Writer:           &syncBuffer{},
+		AsyncBufferSize:  16,
+		FlushInterval:    time.Second,
+		EnableSpanEvents: true,
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.InfoContext(ctx, "not an event")
+	logger.ErrorContext(ctx, "query failed",
+		xlog.Field{Key: "error", Value: errors.New("connection refused")},
+		xlog.Field{Key: "collection", Value: "orders"})
+	logger.WithTrace(ctx).Error("bound failure", xlog.Field{Key: "retries", Value: 3})
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	events := spans[0].Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	attrs := map[string]string{}
+	for _, kv := range events[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if events[0].Name != "exception" || attrs["exception.message"] != "connection refused" ||
+		attrs["exception.type"] != "*errors.errorString" || attrs["collection"] != "orders" ||
+		attrs["log.message"] != "query failed" || attrs["log.severity"] != "ERROR" {
+		t.Errorf("unexpected event %s %v", events[0].Name, attrs)
+	}
+	for _, kv := range events[1].Attributes {
+		if kv.Key == "exception.message" && kv.Value.AsString() != "bound failure" {
+			t.Errorf("exception.message = %s", kv.Value.AsString())
+		}
+	}
+}
+
+func TestSpanEventsWithRedaction(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:            xlog.Info,
+		Encoder:          xlog.JSONEncoder,
+		Writer:           &syncBuffer{},
+		AsyncBufferSize:  16,
+		FlushInterval:    time.Second,
+		EnableSpanEvents: true,
+		Redaction:        xlog.RedactConfig{Enabled: true, Builtin: true},
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.ErrorContext(ctx, "login failed",
+		xlog.Err(errors.New("bad password for bob@example.com")),
+		xlog.Field{Key: "token", Value: "t-123"})
+	span.End()
+
+	events := recorder.Ended()[0].Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	attrs := map[string]string{}
+	for _, kv := range events[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["exception.type"] != "*errors.errorString" ||
+		attrs["exception.message"] != "bad password for b**@example.com" || attrs["token"] != "******" {
+		t.Errorf("unexpected event attributes %v", attrs)
+	}
+}
+
+func TestOTelLogBridge(t *testing.T) {
+	exporter := &memoryExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	out := &syncBuffer{}
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:           xlog.Info,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          out,
+		AsyncBufferSize: 16,
+		FlushInterval:   time.Second,
+		EnableTracing:   true,
+		LoggerProvider:  provider,
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	logger.Named("storage").WarnContext(ctx, "slow query",
+		xlog.Field{Key: "elapsed_ms", Value: 1500},
+		xlog.Field{Key: "filter", Value: map[string]any{"status": "paid"}})
+	logger.Close()
+
+	if len(out.lines(t)) != 1 {
+		t.Error("records should still be written to the writer")
+	}
+	records := exporter.snapshot()
+	if len(records) != 1 {
+		t.Fatalf("exported %d records, want 1", len(records))
+	}
+	r := records[0]
+	if r.Body().AsString() != "slow query" || r.Severity() != otellog.SeverityWarn || r.SeverityText() != "WARN" {
+		t.Errorf("unexpected record body=%v severity=%v/%s", r.Body(), r.Severity(), r.SeverityText())
+	}
+	sc := span.SpanContext()
+	if r.TraceID() != sc.TraceID() || r.SpanID() != sc.SpanID() {
+		t.Errorf("record trace = %s/%s, want %s/%s", r.TraceID(), r.SpanID(), sc.TraceID(), sc.SpanID())
+	}
+	attrs := recordAttrs(r)
+	if attrs["logger"].AsString() != "storage" || attrs["elapsed_ms"].AsInt64() != 1500 {
+		t.Errorf("unexpected attributes %v", attrs)
+	}
+	if filter := attrs["filter"]; filter.Kind() != otellog.KindMap || filter.AsMap()[0].Value.AsString() != "paid" {
+		t.Errorf("filter = %v", filter)
+	}
+	if _, ok := attrs["trace_id"]; ok {
+		t.Error("trace_id should be mapped to the record trace context, not an attribute")
+	}
+}
+
+func TestOTelLogBridgeCorrelatesWithoutTracing(t *testing.T) {
+	// 未启用 EnableTracing 且未调用 WithTrace 时，异步写入的记录仍关联到 ctx 中的 span
+	exporter := &memoryExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:           xlog.Info,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          &syncBuffer{},
+		AsyncBufferSize: 16,
+		FlushInterval:   time.Second,
+		LoggerProvider:  provider,
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	logger.InfoContext(ctx, "served")
+	logger.Info("no span")
+	logger.Close()
+
+	records := exporter.snapshot()
+	if len(records) != 2 {
+		t.Fatalf("exported %d records, want 2", len(records))
+	}
+	sc := span.SpanContext()
+	if r := records[0]; r.TraceID() != sc.TraceID() || r.SpanID() != sc.SpanID() {
+		t.Errorf("record trace = %s/%s, want %s/%s", r.TraceID(), r.SpanID(), sc.TraceID(), sc.SpanID())
+	}
+	if r := records[1]; r.TraceID().IsValid() {
+		t.Errorf("record without span has trace %s", r.TraceID())
+	}
+}
@@ -0,0 +1,229 @@
+package test
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/xlog"
+)
+
+type address struct {
+	City  string `json:"city"`
+	Phone string `json:"phone"`
+}
+
+type user struct {
+	Name     string  `json:"name"`
+	Email    string  `json:"email"`
+	IDCard   string  `json:"id_card" redact:"partial"`
+	Password string  `json:"password"`
+	Address  address `json:"address"`
+	internal string
+}
+
+// apiKey 通过 RedactValuer 自定义脱敏输出
+type apiKey string
+
+func (k apiKey) RedactValue() any {
+	return xlog.Mask(string(k), xlog.MaskPartial)
+}
+
+func TestRedactorNested(t *testing.T) {
+	r, err := xlog.NewRedactor(xlog.RedactConfig{
+		Enabled: true,
+		Builtin: true,
+		Rules: []xlog.RedactRule{
+			{Key: "customer_no", Mode: xlog.MaskHash},
+			{Pattern: `order-\d+`, Mode: xlog.MaskFull},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+
+	u := user{
+		Name:     "alice",
+		Email:    "alice@example.com",
+		IDCard:   "110101199003071234",
+		Password: "p@ss",
+		Address:  address{City: "Shanghai", Phone: "13812345678"},
+		internal: "x",
+	}
+	got := r.Redact("user", &u).(map[string]any)
+	if got["name"] != "alice" {
+		t.Errorf("name = %v", got["name"])
+	}
+	if got["email"] != "a****@example.com" {
+		t.Errorf("email = %v", got["email"])
+	}
+	if got["id_card"] != "110***********1234" {
+		t.Errorf("id_card = %v", got["id_card"])
+	}
+	if got["password"] != "******" {
+		t.Errorf("password = %v", got["password"])
+	}
+	addr := got["address"].(map[string]any)
+	if addr["city"] != "Shanghai" || addr["phone"] != "138****5678" {
+		t.Errorf("address = %v", addr)
+	}
+	if _, ok := got["internal"]; ok {
+		t.Error("unexported fields should be skipped")
+	}
+
+	body := map[string]any{
+		"Authorization": "Bearer abc.def",
+		"items":         []any{"order-42 shipped", 3},
+		"customer_no":   "C1001",
+		"created":       time.Unix(0, 0),
+	}
+	gotBody := r.Redact("body", body).(map[string]any)
+	if gotBody["Authorization"] != "******" {
+		t.Errorf("Authorization = %v", gotBody["Authorization"])
+	}
+	if items := gotBody["items"].([]any); items[0] != "****** shipped" || items[1] != 3 {
+		t.Errorf("items = %v", items)
+	}
+	hashed := gotBody["customer_no"].(string)
+	if !strings.HasPrefix(hashed, "sha256:") || hashed != r.Redact("customer_no", "C1001") {
+		t.Errorf("customer_no = %v, hash should be stable", hashed)
+	}
+	if _, ok := gotBody["created"].(time.Time); !ok {
+		t.Errorf("time values should be kept, got %T", gotBody["created"])
+	}
+
+	if got := r.Redact("key", apiKey("sk-0123456789abcdef")); got != "sk-0***********cdef" {
+		t.Errorf("RedactValuer = %v", got)
+	}
+	if got := r.Redact("msg", "call eyJhbGciOi.eyJzdWIiOi.sig now"); got != "call ****** now" {
+		t.Errorf("jwt = %v", got)
+	}
+}
+
+// contact 的 String 与 MarshalJSON 会输出敏感内容
+type contact struct {
+	email string
+	token string
+}
+
+func (c contact) String() string { return "contact " + c.email }
+
+func (c contact) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"email": c.email, "token": c.token, "id": 9007199254740993})
+}
+
+// label 只实现了 fmt.Stringer
+type label struct{ owner string }
+
+func (l label) String() string { return "owned by " + l.owner }
+
+func TestRedactorMarshalers(t *testing.T) {
+	r, err := xlog.NewRedactor(xlog.RedactConfig{Enabled: true, Builtin: true})
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	got, ok := r.Redact("contact", contact{email: "bob@example.com", token: "t-123"}).(map[string]any)
+	if !ok {
+		t.Fatalf("MarshalJSON output should be redacted as an object, got %T", got)
+	}
+	if got["email"] != "b**@example.com" || got["token"] != "******" {
+		t.Errorf("contact = %v", got)
+	}
+	if id, _ := got["id"].(json.Number); id != "9007199254740993" {
+		t.Errorf("id = %v, numbers should keep their precision", got["id"])
+	}
+	if got := r.Redact("label", label{owner: "carol@example.com"}); got != "owned by c****@example.com" {
+		t.Errorf("Stringer = %v", got)
+	}
+	addr := netip.MustParseAddr("10.0.0.1")
+	if got := r.Redact("addr", addr); got != addr {
+		t.Errorf("netip.Addr = %v, should be kept", got)
+	}
+}
+
+// userID 是非字符串类型的 map 键
+type userID int
+
+func TestRedactorNonStringMapKeys(t *testing.T) {
+	r, err := xlog.NewRedactor(xlog.RedactConfig{Enabled: true, Builtin: true})
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	users := map[int]user{1: {Name: "erin", Email: "erin@example.com", Password: "secret"}}
+	got, ok := r.Redact("users", users).(map[string]any)
+	if !ok {
+		t.Fatalf("int-keyed map should be walked, got %T", r.Redact("users", users))
+	}
+	u := got["1"].(map[string]any)
+	if u["email"] != "e***@example.com" || u["password"] != "******" || u["name"] != "erin" {
+		t.Errorf("users[1] = %v", u)
+	}
+	emails := r.Redact("emails", map[userID]string{7: "frank@example.com"}).(map[string]any)
+	if emails["7"] != "f****@example.com" {
+		t.Errorf("emails = %v", emails)
+	}
+}
+
+func TestRedactorMaxDepth(t *testing.T) {
+	r, err := xlog.NewRedactor(xlog.RedactConfig{Enabled: true, Builtin: true})
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	var value any = map[string]any{"email": "dave@example.com"}
+	for i := 0; i < 20; i++ {
+		value = []any{value}
+	}
+	out, _ := json.Marshal(r.Redact("payload", value))
+	if strings.Contains(string(out), "dave@example.com") {
+		t.Fatalf("deeply nested value leaked: %s", out)
+	}
+	if !strings.Contains(string(out), "[REDACTED: max depth]") {
+		t.Errorf("output = %s, want the max depth placeholder", out)
+	}
+}
+
+func TestRedactorInvalidConfig(t *testing.T) {
+	cases := []xlog.RedactConfig{
+		{Rules: []xlog.RedactRule{{}}},
+		{Rules: []xlog.RedactRule{{Key: "a", Pattern: "b"}}},
+		{Rules: []xlog.RedactRule{{Pattern: "("}}},
+		{Rules: []xlog.RedactRule{{Key: "a", Mode: "blur"}}},
+	}
+	for i, c := range cases {
+		if _, err := xlog.NewRedactor(c); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestSlogLoggerRedaction(t *testing.T) {
+	out := &syncBuffer{}
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:           xlog.Info,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          out,
+		AsyncBufferSize: 16,
+		FlushInterval:   time.Second,
+		Redaction:       xlog.RedactConfig{Enabled: true, Builtin: true},
+	})
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+	logger.WithMetadata(map[string]any{"token": "t0ken"}).
+		Info("login", xlog.Field{Key: "user", Value: user{Name: "bob", Email: "bob@example.com"}})
+	logger.Close()
+
+	lines := out.lines(t)
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines", len(lines))
+	}
+	if lines[0]["token"] != "******" {
+		t.Errorf("metadata token = %v", lines[0]["token"])
+	}
+	u := lines[0]["user"].(map[string]any)
+	if u["email"] != "b**@example.com" || u["name"] != "bob" {
+		t.Errorf("user = %v", u)
+	}
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/omeyang/gokit/metrics/sample"
+	"github.com/omeyang/gokit/xlog"
+)
+
+// traceContext 构造一个携带指定 trace 的 context
+func traceContext(t *testing.T, i byte, sampled bool) context.Context {
+	t.Helper()
+	cfg := trace.SpanContextConfig{
+		TraceID: trace.TraceID{15: i, 8: i * 7},
+		SpanID:  trace.SpanID{7: 1},
+	}
+	if sampled {
+		cfg.TraceFlags = trace.FlagsSampled
+	}
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(cfg))
+}
+
+func TestTraceSamplingKeepsWholeTraces(t *testing.T) {
+	out := &syncBuffer{}
+	config := xlog.LogConfig{
+		Level:           xlog.Debug,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          out,
+		AsyncBufferSize: 1024,
+		FlushInterval:   time.Second,
+	}
+	config.Sampling.Type = sample.TraceSamplerType
+	config.Sampling.Rate = 0.5
+	logger, err := xlog.NewSlogLogger(config)
+	if err != nil {
+		t.Fatalf("NewSlogLogger() error = %v", err)
+	}
+
+	const traces, linesPerTrace = 64, 5
+	for i := byte(1); i <= traces; i++ {
+		ctx := traceContext(t, i, false)
+		traced := logger.WithTrace(ctx)
+		for j := 0; j < linesPerTrace; j++ {
+			if j%2 == 0 {
+				logger.InfoContext(ctx, "ctx line", xlog.Field{Key: "trace", Value: i})
+			} else {
+				traced.Debug("bound line", xlog.Field{Key: "trace", Value: i})
+			}
+		}
+	}
+	logger.InfoContext(traceContext(t, 200, true), "sampled span")
+	logger.Close()
+
+	perTrace := map[float64]int{}
+	sampledKept := false
+	for _, line := range out.lines(t) {
+		if line["msg"] == "sampled span" {
+			sampledKept = true
+			continue
+		}
+		perTrace[line["trace"].(float64)]++
+	}
+	if !sampledKept {
+		t.Error("records of sampled spans should always be kept")
+	}
+	for id, n := range perTrace {
+		if n != linesPerTrace {
+			t.Errorf("trace %v kept %d of %d lines", id, n, linesPerTrace)
+		}
+	}
+	if len(perTrace) == 0 || len(perTrace) == traces {
+		t.Errorf("expected a partial sample, kept %d traces", len(perTrace))
+	}
+}
@@ -0,0 +1,79 @@
+// Package xlogcat 读取、过滤和格式化 xlog 以 JSON 编码输出的日志，
+// 支持 roator 轮转产生的备份文件（包括 .gz 压缩文件）以及持续跟踪当前日志文件
+package xlogcat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/omeyang/gokit/xlog"
+)
+
+// Entry 表示一条日志记录
+type Entry struct {
+	Time    time.Time      // 记录时间，无法解析时为零值
+	Level   xlog.LogLevel  // 日志级别，无法识别时为原始字符串的大写形式
+	Message string         // 日志消息，无法解析为 JSON 的行为整行内容
+	TraceID string         // 链路 ID
+	Logger  string         // 日志器名称
+	Fields  map[string]any // 解码后的完整记录，数字以 json.Number 表示
+	Raw     []byte         // 原始的一行内容，不含换行符
+	Source  string         // 记录所在的文件
+}
+
+// errNotJSON 表示日志行不是 JSON 对象
+var errNotJSON = errors.New("not a JSON object")
+
+// ParseEntry 解析一行 JSON 日志
+func ParseEntry(line []byte) (Entry, error) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return Entry{}, errNotJSON
+	}
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return Entry{}, err
+	}
+
+	e := Entry{Fields: fields, Raw: line}
+	if s, ok := fields[slog.TimeKey].(string); ok {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return Entry{}, fmt.Errorf("invalid time %q: %w", s, err)
+		}
+		e.Time = t
+	}
+	if s, ok := fields[slog.LevelKey].(string); ok {
+		e.Level = xlog.LogLevel(strings.ToUpper(s))
+	}
+	e.Message, _ = fields[slog.MessageKey].(string)
+	e.TraceID, _ = fields[xlog.TraceIDKey].(string)
+	e.Logger, _ = fields[xlog.LoggerNameKey].(string)
+	return e, nil
+}
+
+// Lookup 按字段名查找记录中的值
+// 先按完整字段名查找（例如 "k8s.pod.name"），找不到时按 "." 分隔逐层查找嵌套对象
+func (e Entry) Lookup(key string) (any, bool) {
+	if v, ok := e.Fields[key]; ok {
+		return v, true
+	}
+	var current any = e.Fields
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
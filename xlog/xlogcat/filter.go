@@ -0,0 +1,175 @@
+package xlogcat
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omeyang/gokit/xlog"
+)
+
+// Filter 定义日志记录的过滤条件，零值不过滤任何记录
+type Filter struct {
+	Level   xlog.LogLevel // 最低日志级别
+	Since   time.Time     // 只保留不早于该时间的记录
+	Until   time.Time     // 只保留早于该时间的记录
+	TraceID string        // 只保留指定链路的记录
+	Exprs   []Expr        // 字段表达式，需要全部满足
+}
+
+// Match 判断记录是否满足过滤条件
+func (f Filter) Match(e Entry) bool {
+	if f.Level != "" && (!e.Level.IsValid() || f.Level.IsHighThan(e.Level)) {
+		return false
+	}
+	if !f.Since.IsZero() && (e.Time.IsZero() || e.Time.Before(f.Since)) {
+		return false
+	}
+	if !f.Until.IsZero() && (e.Time.IsZero() || !e.Time.Before(f.Until)) {
+		return false
+	}
+	if f.TraceID != "" && e.TraceID != f.TraceID {
+		return false
+	}
+	for _, expr := range f.Exprs {
+		if !expr.Match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// 字段表达式支持的运算符，同一位置上较长的运算符优先匹配
+var exprOps = []string{"!=", "!~", ">=", "<=", "=", "~", ">", "<"}
+
+// Expr 是针对单个字段的过滤表达式
+// 支持 key=value、key!=value、key~regexp、key!~regexp、key>n、key>=n、key<n、key<=n，
+// 以及 key（字段存在）和 !key（字段不存在）
+type Expr struct {
+	Key   string
+	Op    string
+	Value string
+	re    *regexp.Regexp
+	num   float64
+	isNum bool
+}
+
+// ParseExpr 解析字段表达式
+func ParseExpr(s string) (Expr, error) {
+	s = strings.TrimSpace(s)
+	i, op := findOp(s)
+	if op != "" {
+		e := Expr{Key: strings.TrimSpace(s[:i]), Op: op, Value: strings.TrimSpace(s[i+len(op):])}
+		switch op {
+		case "~", "!~":
+			re, err := regexp.Compile(e.Value)
+			if err != nil {
+				return Expr{}, fmt.Errorf("invalid expression %q: %w", s, err)
+			}
+			e.re = re
+		case ">", ">=", "<", "<=":
+			n, err := strconv.ParseFloat(e.Value, 64)
+			if err != nil {
+				return Expr{}, fmt.Errorf("invalid expression %q: %s needs a number", s, op)
+			}
+			e.num, e.isNum = n, true
+		default:
+			if n, err := strconv.ParseFloat(e.Value, 64); err == nil {
+				e.num, e.isNum = n, true
+			}
+		}
+		return e, nil
+	}
+	if key, ok := strings.CutPrefix(s, "!"); ok && key != "" {
+		return Expr{Key: key, Op: "!"}, nil
+	}
+	if s == "" {
+		return Expr{}, fmt.Errorf("empty expression")
+	}
+	return Expr{Key: s}, nil
+}
+
+// findOp 查找表达式中第一个运算符的位置，字段名不能为空
+func findOp(s string) (int, string) {
+	for i := 1; i < len(s); i++ {
+		for _, op := range exprOps {
+			if strings.HasPrefix(s[i:], op) {
+				return i, op
+			}
+		}
+	}
+	return 0, ""
+}
+
+// Match 判断记录是否满足表达式
+func (e Expr) Match(entry Entry) bool {
+	v, ok := entry.Lookup(e.Key)
+	switch e.Op {
+	case "":
+		return ok
+	case "!":
+		return !ok
+	case "!=", "!~":
+		return !ok || !e.matchValue(v)
+	}
+	return ok && e.matchValue(v)
+}
+
+// matchValue 判断字段值是否满足表达式的运算符（"!=" 与 "!~" 按 "=" 与 "~" 计算）
+func (e Expr) matchValue(v any) bool {
+	switch e.Op {
+	case "=", "!=":
+		if n, ok := number(v); ok && e.isNum {
+			return n == e.num
+		}
+		return valueString(v) == e.Value
+	case "~", "!~":
+		return e.re.MatchString(valueString(v))
+	}
+	n, ok := number(v)
+	if !ok {
+		return false
+	}
+	switch e.Op {
+	case ">":
+		return n > e.num
+	case ">=":
+		return n >= e.num
+	case "<":
+		return n < e.num
+	default:
+		return n <= e.num
+	}
+}
+
+// number 将字段值转换为数字
+func number(v any) (float64, bool) {
+	switch x := v.(type) {
+	case json.Number:
+		n, err := x.Float64()
+		return n, err == nil
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// valueString 返回字段值的字符串形式，对象和数组使用 JSON 编码
+func valueString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case nil:
+		return "null"
+	case map[string]any, []any:
+		data, _ := json.Marshal(x)
+		return string(data)
+	default:
+		return fmt.Sprint(x)
+	}
+}
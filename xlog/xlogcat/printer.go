@@ -0,0 +1,140 @@
+package xlogcat
+
+import (
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omeyang/gokit/middleware/pool"
+	"github.com/omeyang/gokit/xlog"
+)
+
+// ANSI 颜色控制码
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+)
+
+// levelColors 定义各日志级别的颜色
+var levelColors = map[xlog.LogLevel]string{
+	xlog.Debug: colorGray,
+	xlog.Info:  colorGreen,
+	xlog.Warn:  colorYellow,
+	xlog.Error: colorRed,
+	xlog.Fatal: colorMagenta,
+}
+
+// printedKeys 是单独输出、不再出现在字段列表中的字段
+var printedKeys = map[string]bool{
+	slog.TimeKey:       true,
+	slog.LevelKey:      true,
+	slog.MessageKey:    true,
+	xlog.LoggerNameKey: true,
+}
+
+// Printer 将日志记录格式化为便于阅读的单行文本
+// 格式为：时间 级别 [日志器] 消息 key=value ...，字段按名称排序
+type Printer struct {
+	w          io.Writer
+	color      bool
+	timeFormat string
+}
+
+// NewPrinter 创建一个输出到 w 的 Printer，color 为 true 时使用 ANSI 颜色
+func NewPrinter(w io.Writer, color bool) *Printer {
+	return &Printer{w: w, color: color, timeFormat: "2006-01-02T15:04:05.000Z07:00"}
+}
+
+// Print 输出一条日志记录，无法解析为 JSON 的记录原样输出
+// 每条记录在从 pool.DefaultByteBufferPool 获取的缓冲区中格式化后一次写出
+func (p *Printer) Print(e Entry) error {
+	buf := pool.DefaultByteBufferPool.GetBuffer()
+	defer buf.Release()
+	if e.Fields == nil {
+		buf.Write(e.Raw)
+		buf.WriteByte('\n')
+		_, err := p.w.Write(buf.Bytes())
+		return err
+	}
+
+	if !e.Time.IsZero() {
+		p.colored(buf, colorGray, e.Time.Format(p.timeFormat))
+		buf.WriteByte(' ')
+	}
+	p.colored(buf, levelColors[e.Level], padLevel(e.Level))
+	buf.WriteByte(' ')
+	if e.Logger != "" {
+		p.colored(buf, colorCyan, "["+e.Logger+"]")
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(e.Message)
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		if !printedKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteByte(' ')
+		p.colored(buf, colorGray, k+"=")
+		buf.WriteString(quoteIfNeeded(valueString(e.Fields[k])))
+	}
+	buf.WriteByte('\n')
+	_, err := p.w.Write(buf.Bytes())
+	return err
+}
+
+// colored 写入文本，启用颜色时使用指定颜色
+func (p *Printer) colored(buf *pool.ByteBuffer, color, s string) {
+	if !p.color || color == "" {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(color)
+	buf.WriteString(s)
+	buf.WriteString(colorReset)
+}
+
+// padLevel 将级别补齐到相同宽度，便于对齐
+func padLevel(level xlog.LogLevel) string {
+	s := string(level)
+	if len(s) < 5 {
+		s += strings.Repeat(" ", 5-len(s))
+	}
+	return s
+}
+
+// quoteIfNeeded 在值包含空白、引号或等号时加上引号
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// ParseTime 解析命令行中的时间参数
+// 支持 RFC3339 时间、"2006-01-02 15:04:05"、"2006-01-02"，以及相对 now 的时长（例如 "15m" 表示 15 分钟前）
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	var lastErr error
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		t, err := time.ParseInLocation(layout, s, now.Location())
+		if err == nil {
+			return t, nil
+		}
+		lastErr = err
+	}
+	return time.Time{}, lastErr
+}
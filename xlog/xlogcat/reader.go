@@ -0,0 +1,282 @@
+package xlogcat
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/omeyang/gokit/xlog/roator"
+)
+
+// HandlerFunc 处理一条满足过滤条件的日志记录，返回错误时停止读取
+type HandlerFunc func(Entry) error
+
+// Files 按时间从旧到新返回 roator 为 config 产生的所有日志文件，包括 .gz 压缩的备份
+// Filename 本身不属于任何轮转序列（例如直接指定某个备份文件）时只返回该文件
+func Files(config roator.RotatorConfig) ([]string, error) {
+	files, err := roator.LogFiles(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		if _, err := os.Stat(config.Filename); err != nil {
+			return nil, err
+		}
+		files = []string{config.Filename}
+	}
+	return files, nil
+}
+
+// Open 打开日志文件，.gz 文件会被透明解压
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+// gzipFile 在关闭时同时关闭解压器和底层文件
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close 关闭解压器和底层文件
+func (g *gzipFile) Close() error {
+	return errors.Join(g.Reader.Close(), g.file.Close())
+}
+
+// Scan 逐行读取 r 中的日志，对满足过滤条件的记录调用 fn
+// 无法解析为 JSON 的行作为只有 Message 和 Raw 的记录处理
+func Scan(r io.Reader, source string, filter Filter, fn HandlerFunc) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if herr := handleLine(line, source, filter, fn); herr != nil {
+				return herr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReadFiles 按顺序读取多个日志文件
+func ReadFiles(files []string, filter Filter, fn HandlerFunc) error {
+	for _, path := range files {
+		if err := readFile(path, filter, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFile 读取单个日志文件
+func readFile(path string, filter Filter, fn HandlerFunc) error {
+	r, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return Scan(r, path, filter, fn)
+}
+
+// handleLine 解析一行日志并在满足过滤条件时调用 fn
+func handleLine(line []byte, source string, filter Filter, fn HandlerFunc) error {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil
+	}
+	e, err := ParseEntry(line)
+	if err != nil {
+		e = Entry{Message: string(line), Raw: line}
+	}
+	e.Source = source
+	if !filter.Match(e) {
+		return nil
+	}
+	return fn(e)
+}
+
+// followOptions 定义跟踪日志文件的选项
+type followOptions struct {
+	pollInterval time.Duration
+	fromEnd      bool
+}
+
+// FollowOption 定义了跟踪日志文件的可选配置函数
+type FollowOption func(*followOptions)
+
+// WithPollInterval 设置检查新内容和文件轮转的间隔，默认是 200 毫秒
+func WithPollInterval(d time.Duration) FollowOption {
+	return func(o *followOptions) {
+		o.pollInterval = d
+	}
+}
+
+// WithFromEnd 从文件末尾开始跟踪，默认从文件开头读取
+func WithFromEnd() FollowOption {
+	return func(o *followOptions) {
+		o.fromEnd = true
+	}
+}
+
+// Follow 持续读取 path 中新写入的日志，直到 ctx 结束或 fn 返回错误
+// 文件被轮转（inode 变化，例如 roator 重命名备份或切换 current 软链接）时读完旧文件后从新文件开头继续，
+// 文件被截断（logrotate 的 copytruncate 模式）时从开头重新读取；文件不存在时等待其被创建
+func Follow(ctx context.Context, path string, filter Filter, fn HandlerFunc, opts ...FollowOption) error {
+	o := &followOptions{pollInterval: 200 * time.Millisecond}
+	for _, opt := range opts {
+		opt(o)
+	}
+	t := &tailer{path: path, filter: filter, fn: fn}
+	defer t.close()
+	if err := t.open(o.fromEnd); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := t.poll(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// tailer 保存跟踪日志文件的状态
+type tailer struct {
+	path    string
+	filter  Filter
+	fn      HandlerFunc
+	file    *os.File
+	info    os.FileInfo
+	reader  *bufio.Reader
+	offset  int64  // 已读取的字节数
+	partial []byte // 尚未读到换行符的半行内容
+}
+
+// open 打开文件，fromEnd 为 true 时跳到文件末尾
+func (t *tailer) open(fromEnd bool) error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	t.offset = 0
+	if fromEnd {
+		if t.offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	t.file, t.info = f, info
+	t.reader = bufio.NewReader(f)
+	t.partial = nil
+	return nil
+}
+
+// close 关闭当前文件
+func (t *tailer) close() {
+	if t.file != nil {
+		_ = t.file.Close()
+		t.file = nil
+	}
+}
+
+// poll 读取所有新内容，并处理文件的轮转与截断
+func (t *tailer) poll() error {
+	if t.file == nil {
+		if err := t.open(false); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+	}
+	if err := t.drain(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(t.path)
+	switch {
+	case err != nil && !os.IsNotExist(err):
+		return err
+	case err != nil || !os.SameFile(info, t.info):
+		// 文件已被轮转，旧文件已读完，末尾没有换行符的内容作为最后一行处理，然后切换到新文件
+		if len(t.partial) > 0 {
+			if err := handleLine(t.partial, t.path, t.filter, t.fn); err != nil {
+				return err
+			}
+		}
+		t.close()
+		if err == nil {
+			if err := t.open(false); err != nil {
+				return err
+			}
+			return t.drain()
+		}
+	case info.Size() < t.offset:
+		// 文件被截断，从头读取
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		t.offset = 0
+		t.reader.Reset(t.file)
+		t.partial = nil
+		return t.drain()
+	}
+	return nil
+}
+
+// drain 读取当前文件中所有完整的行
+func (t *tailer) drain() error {
+	for {
+		line, err := t.reader.ReadBytes('\n')
+		t.offset += int64(len(line))
+		if err == nil {
+			if len(t.partial) > 0 {
+				line = append(t.partial, line...)
+				t.partial = nil
+			}
+			if herr := handleLine(line, t.path, t.filter, t.fn); herr != nil {
+				return herr
+			}
+			continue
+		}
+		t.partial = append(t.partial, line...)
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+}
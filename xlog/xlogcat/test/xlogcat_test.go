@@ -0,0 +1,313 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omeyang/gokit/xlog"
+	"github.com/omeyang/gokit/xlog/roator"
+	"github.com/omeyang/gokit/xlog/xlogcat"
+)
+
+// collect 返回收集记录消息的处理函数
+func collect(messages *[]string) xlogcat.HandlerFunc {
+	return func(e xlogcat.Entry) error {
+		*messages = append(*messages, e.Message)
+		return nil
+	}
+}
+
+func TestReadRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	config := roator.RotatorConfig{Filename: filepath.Join(dir, "app.log"), Compress: true}
+	rotator, err := roator.NewNativeRotator(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, _ := rotator.GetWriter()
+	logger, err := xlog.NewSlogLogger(xlog.LogConfig{
+		Level:           xlog.Debug,
+		Encoder:         xlog.JSONEncoder,
+		Writer:          writer,
+		AsyncBufferSize: 16,
+		FlushInterval:   time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, msg := range []string{"first", "second", "third"} {
+		logger.Named("storage").Warn(msg, xlog.Field{Key: "attempt", Value: i})
+		logger.Debug("noise")
+		logger.Flush()
+		if err := rotator.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	logger.Error("live")
+	logger.Flush()
+	if err := rotator.(*roator.NativeRotator).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := xlogcat.Files(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 || !strings.HasSuffix(files[0], ".gz") || files[3] != config.Filename {
+		t.Fatalf("files = %v", files)
+	}
+
+	var messages []string
+	if err := xlogcat.ReadFiles(files, xlogcat.Filter{Level: xlog.Warn}, collect(&messages)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(messages, ","); got != "first,second,third,live" {
+		t.Errorf("messages = %s", got)
+	}
+
+	messages = nil
+	filter := xlogcat.Filter{Exprs: []xlogcat.Expr{mustExpr(t, "attempt>=1"), mustExpr(t, "logger=storage")}}
+	if err := xlogcat.ReadFiles(files, filter, collect(&messages)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(messages, ","); got != "second,third" {
+		t.Errorf("messages = %s", got)
+	}
+}
+
+// mustExpr 解析字段表达式，失败时终止测试
+func mustExpr(t *testing.T, s string) xlogcat.Expr {
+	t.Helper()
+	expr, err := xlogcat.ParseExpr(s)
+	if err != nil {
+		t.Fatalf("ParseExpr(%q) error = %v", s, err)
+	}
+	return expr
+}
+
+func TestFilterMatch(t *testing.T) {
+	line := `{"time":"2024-06-01T10:00:00.5Z","level":"ERROR","msg":"query failed","trace_id":"abc",` +
+		`"k8s.pod.name":"api-0","db":{"op":"find","ms":120},"url":"/a?x>=1","tags":["a","b"]}`
+	entry, err := xlogcat.ParseEntry([]byte(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Level != xlog.Error || entry.TraceID != "abc" || !entry.Time.Equal(time.Date(2024, 6, 1, 10, 0, 0, 5e8, time.UTC)) {
+		t.Fatalf("entry = %+v", entry)
+	}
+
+	cases := []struct {
+		filter xlogcat.Filter
+		want   bool
+	}{
+		{xlogcat.Filter{}, true},
+		{xlogcat.Filter{Level: xlog.Error}, true},
+		{xlogcat.Filter{Level: xlog.Fatal}, false},
+		{xlogcat.Filter{Since: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)}, true},
+		{xlogcat.Filter{Until: time.Date(2024, 6, 1, 10, 0, 0, 5e8, time.UTC)}, false},
+		{xlogcat.Filter{TraceID: "abc"}, true},
+		{xlogcat.Filter{TraceID: "def"}, false},
+	}
+	for i, c := range cases {
+		if got := c.filter.Match(entry); got != c.want {
+			t.Errorf("case %d: Match() = %v, want %v", i, got, c.want)
+		}
+	}
+
+	exprs := map[string]bool{
+		"k8s.pod.name=api-0": true,
+		"db.op=find":         true,
+		"db.ms>100":          true,
+		"db.ms<=100":         false,
+		"db.ms=120.0":        true,
+		"db.op!=find":        false,
+		"missing!=x":         true,
+		"msg~^query":         true,
+		"msg!~fail":          false,
+		"url=/a?x>=1":        true,
+		`tags=["a","b"]`:     true,
+		"db":                 true,
+		"!db":                false,
+		"!missing":           true,
+	}
+	for s, want := range exprs {
+		if got := mustExpr(t, s).Match(entry); got != want {
+			t.Errorf("%s: Match() = %v, want %v", s, got, want)
+		}
+	}
+	for _, s := range []string{"", "a>b", "a~("} {
+		if _, err := xlogcat.ParseExpr(s); err == nil {
+			t.Errorf("ParseExpr(%q) expected error", s)
+		}
+	}
+}
+
+func TestScanNonJSONLines(t *testing.T) {
+	input := "plain text\n{\"level\":\"INFO\",\"msg\":\"json\"}\n\n{\"level\":\"INFO\",\"msg\":\"no newline\"}"
+	var messages []string
+	if err := xlogcat.Scan(strings.NewReader(input), "-", xlogcat.Filter{}, collect(&messages)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(messages, ","); got != "plain text,json,no newline" {
+		t.Errorf("messages = %s", got)
+	}
+
+	messages = nil
+	if err := xlogcat.Scan(strings.NewReader(input), "-", xlogcat.Filter{Level: xlog.Debug}, collect(&messages)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(messages, ","); got != "json,no newline" {
+		t.Errorf("messages with level filter = %s", got)
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	entry, err := xlogcat.ParseEntry([]byte(`{"time":"2024-06-01T10:00:00Z","level":"WARN","msg":"slow query","logger":"storage","ms":120,"q":"a b"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := xlogcat.NewPrinter(&buf, false).Print(entry); err != nil {
+		t.Fatal(err)
+	}
+	want := "2024-06-01T10:00:00.000Z WARN  [storage] slow query ms=120 q=\"a b\"\n"
+	if buf.String() != want {
+		t.Errorf("Print() = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := xlogcat.NewPrinter(&buf, true).Print(entry); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\x1b[33mWARN \x1b[0m") {
+		t.Errorf("coloured Print() = %q", buf.String())
+	}
+}
+
+func BenchmarkPrinter(b *testing.B) {
+	entry, err := xlogcat.ParseEntry([]byte(`{"time":"2024-06-01T10:00:00Z","level":"WARN","msg":"slow query","logger":"storage","ms":120,"q":"a b"}`))
+	if err != nil {
+		b.Fatal(err)
+	}
+	p := xlogcat.NewPrinter(io.Discard, true)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = p.Print(entry)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"15m":                  now.Add(-15 * time.Minute),
+		"2024-06-01T10:00:00Z": time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+		"2024-06-01 10:30:00":  time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC),
+		"2024-05-31":           time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC),
+	}
+	for s, want := range cases {
+		got, err := xlogcat.ParseTime(s, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := xlogcat.ParseTime("yesterday", now); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// lineCollector 并发安全地收集 Follow 输出的消息
+type lineCollector struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (c *lineCollector) handle(e xlogcat.Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, e.Message)
+	return nil
+}
+
+// waitFor 等待收集到 want 条消息
+func (c *lineCollector) waitFor(t *testing.T, want int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.Lock()
+		got := append([]string(nil), c.messages...)
+		c.mu.Unlock()
+		if len(got) >= want {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %v, want %d messages", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// appendLine 向文件追加内容
+func appendLine(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLine(t, path, "{\"level\":\"INFO\",\"msg\":\"existing\"}\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := &lineCollector{}
+	done := make(chan error, 1)
+	go func() {
+		done <- xlogcat.Follow(ctx, path, xlogcat.Filter{}, c.handle, xlogcat.WithPollInterval(5*time.Millisecond))
+	}()
+	c.waitFor(t, 1)
+
+	// 半行内容在写完前不会被输出
+	appendLine(t, path, "{\"level\":\"INFO\",")
+	appendLine(t, path, "\"msg\":\"appended\"}\n")
+	c.waitFor(t, 2)
+
+	// 重命名后创建新文件
+	appendLine(t, path, "{\"level\":\"INFO\",\"msg\":\"before rotate\"}\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLine(t, path, "{\"level\":\"INFO\",\"msg\":\"after rotate\"}\n")
+	c.waitFor(t, 4)
+
+	// 原地截断
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	appendLine(t, path, "{\"level\":\"INFO\",\"msg\":\"truncated\"}\n")
+	got := c.waitFor(t, 5)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	want := "existing,appended,before rotate,after rotate,truncated"
+	if strings.Join(got, ",") != want {
+		t.Errorf("messages = %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package xlogtest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/omeyang/gokit/xlog"
+)
+
+// RequireLogged 断言存在指定级别、消息包含 msgSubstr 且包含所有指定字段的日志，不存在时立即终止测试
+// 返回第一条匹配的记录
+func (l *Logger) RequireLogged(level xlog.LogLevel, msgSubstr string, fields ...xlog.Field) Record {
+	l.store.tb.Helper()
+	r, ok := l.find(level, msgSubstr, fields)
+	if !ok {
+		l.store.tb.Fatalf("%s", l.describeMissing(level, msgSubstr, fields))
+	}
+	return r
+}
+
+// AssertLogged 与 RequireLogged 相同，但断言失败时只标记测试失败而不终止
+func (l *Logger) AssertLogged(level xlog.LogLevel, msgSubstr string, fields ...xlog.Field) bool {
+	l.store.tb.Helper()
+	if _, ok := l.find(level, msgSubstr, fields); !ok {
+		l.store.tb.Errorf("%s", l.describeMissing(level, msgSubstr, fields))
+		return false
+	}
+	return true
+}
+
+// RequireNotLogged 断言不存在指定级别且消息包含 msgSubstr 的日志，存在时立即终止测试
+func (l *Logger) RequireNotLogged(level xlog.LogLevel, msgSubstr string, fields ...xlog.Field) {
+	l.store.tb.Helper()
+	for _, r := range l.Records() {
+		if r.Level == level && r.Match(msgSubstr, fields...) {
+			l.store.tb.Fatalf("unexpected log record: %s", r)
+		}
+	}
+}
+
+// find 查找第一条匹配的记录，并将所有匹配的记录标记为预期内的日志
+func (l *Logger) find(level xlog.LogLevel, msgSubstr string, fields []xlog.Field) (Record, bool) {
+	s := l.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first Record
+	found := false
+	for i, r := range s.records {
+		if r.Level != level || !r.Match(msgSubstr, fields...) {
+			continue
+		}
+		s.expected[i] = true
+		if !found {
+			first, found = r, true
+		}
+	}
+	return first, found
+}
+
+// describeMissing 生成断言失败时的说明，列出所有捕获到的日志
+func (l *Logger) describeMissing(level xlog.LogLevel, msgSubstr string, fields []xlog.Field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "no %s log containing %q", level, msgSubstr)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Any())
+	}
+	records := l.Records()
+	if len(records) == 0 {
+		b.WriteString("; no records were captured")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "; captured %d records:", len(records))
+	for _, r := range records {
+		b.WriteString("\n\t")
+		b.WriteString(r.String())
+	}
+	return b.String()
+}
+
+// checkUnexpectedErrors 在测试结束时报告未被断言且未被允许的 ERROR 及以上级别日志
+func (s *store) checkUnexpectedErrors() {
+	s.mu.Lock()
+	var unexpected []Record
+	for i, r := range s.records {
+		if r.Level.IsLowerOrEqualThan(xlog.Warn) || s.expected[i] || s.allowed(r) {
+			continue
+		}
+		unexpected = append(unexpected, r)
+	}
+	s.mu.Unlock()
+	for _, r := range unexpected {
+		s.tb.Errorf("unexpected %s log: %s", r.Level, r)
+	}
+}
+
+// allowed 判断错误日志是否匹配 FailOnUnexpectedErrors 中允许的消息
+func (s *store) allowed(r Record) bool {
+	for _, substr := range s.opts.allowedErrors {
+		if strings.Contains(r.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
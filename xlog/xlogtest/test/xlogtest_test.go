@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/omeyang/gokit/xlog"
+	"github.com/omeyang/gokit/xlog/xlogtest"
+)
+
+// fakeTB 记录测试失败与输出，用于验证断言本身的行为
+type fakeTB struct {
+	testing.TB
+	mu       sync.Mutex
+	logs     []string
+	errors   []string
+	fatal    bool
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Log(args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs = append(f.logs, fmt.Sprint(args...))
+}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	f.fatal = true
+	runtime.Goexit()
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+// finish 执行注册的清理函数
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+// run 在独立的协程中执行 fn，使 Fatalf 可以通过 runtime.Goexit 终止
+func (f *fakeTB) run(fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	<-done
+}
+
+// testSpanContext 构造一个有效的链路上下文
+func testSpanContext() context.Context {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3},
+		SpanID:  trace.SpanID{4, 5, 6},
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func TestCaptureRecords(t *testing.T) {
+	logger := xlogtest.New(t)
+	var hp xlog.HighPerformanceLogger = logger
+
+	child := hp.Named("storage").Named("mongo").WithMetadata(map[string]any{"pod": "api-0"})
+	child.Warn("slow query", xlog.Field{Key: "ms", Value: 120})
+	hp.WithTrace(testSpanContext()).Info("traced")
+	hp.ErrorContext(testSpanContext(), "ctx traced", xlog.Field{Key: "err", Value: errors.New("boom")})
+
+	records := logger.Records()
+	if len(records) != 3 {
+		t.Fatalf("records = %v", records)
+	}
+	r := records[0]
+	if r.Level != xlog.Warn || r.Logger != "storage.mongo" || r.Fields["pod"] != "api-0" || r.Fields["ms"] != 120 {
+		t.Errorf("record = %+v", r)
+	}
+	wantTrace := trace.TraceID{1, 2, 3}.String()
+	if records[1].TraceID != wantTrace || records[2].TraceID != wantTrace || records[2].SpanID == "" {
+		t.Errorf("trace ids = %q, %q", records[1].TraceID, records[2].TraceID)
+	}
+
+	logger.RequireLogged(xlog.Warn, "slow", xlog.Field{Key: "ms", Value: 120}, xlog.Field{Key: "pod", Value: xlogtest.AnyValue})
+	logger.RequireLogged(xlog.Error, "ctx", xlog.Field{Key: "err", Value: xlogtest.AnyValue})
+	logger.RequireNotLogged(xlog.Error, "slow")
+	if got := len(logger.RecordsAt(xlog.Info)); got != 1 {
+		t.Errorf("RecordsAt(INFO) = %d", got)
+	}
+	logger.Reset()
+	if len(logger.Records()) != 0 {
+		t.Error("Reset() did not clear records")
+	}
+}
+
+func TestLevelFiltering(t *testing.T) {
+	logger := xlogtest.New(t, xlogtest.WithLevel(xlog.Info))
+	logger.Debug("hidden")
+	logger.Named("child").Info("shown")
+	if err := logger.SetLevel(xlog.Error); err != nil {
+		t.Fatal(err)
+	}
+	logger.Named("child").Warn("hidden too")
+	if got := len(logger.Records()); got != 1 {
+		t.Errorf("records = %v", logger.Records())
+	}
+	if err := logger.SetLevel("TRACE"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestRequireLoggedFailure(t *testing.T) {
+	tb := &fakeTB{}
+	logger := xlogtest.New(tb)
+	logger.Info("connected", xlog.Field{Key: "host", Value: "db-1"})
+
+	tb.run(func() {
+		logger.RequireLogged(xlog.Info, "connected", xlog.Field{Key: "host", Value: "db-2"})
+		t.Error("RequireLogged did not stop the test")
+	})
+	if !tb.fatal || len(tb.errors) != 1 {
+		t.Fatalf("errors = %v", tb.errors)
+	}
+	if msg := tb.errors[0]; !strings.Contains(msg, `no INFO log containing "connected" host=db-2`) || !strings.Contains(msg, "INFO connected host=db-1") {
+		t.Errorf("failure message = %q", msg)
+	}
+	if len(tb.logs) != 1 || tb.logs[0] != "INFO connected host=db-1" {
+		t.Errorf("tb output = %v", tb.logs)
+	}
+
+	tb = &fakeTB{}
+	logger = xlogtest.New(tb, xlogtest.WithoutOutput())
+	logger.Info("x")
+	if logger.AssertLogged(xlog.Warn, "x") || len(tb.errors) != 1 || tb.fatal {
+		t.Errorf("AssertLogged errors = %v, fatal = %v", tb.errors, tb.fatal)
+	}
+	if len(tb.logs) != 0 {
+		t.Errorf("WithoutOutput still logged %v", tb.logs)
+	}
+}
+
+func TestFailOnUnexpectedErrors(t *testing.T) {
+	tb := &fakeTB{}
+	logger := xlogtest.New(tb, xlogtest.FailOnUnexpectedErrors("cache miss"))
+	logger.Error("asserted failure")
+	logger.Error("cache miss for key")
+	logger.Named("worker").Fatal("unexpected crash")
+	logger.Warn("warnings are fine")
+
+	logger.RequireLogged(xlog.Error, "asserted")
+	tb.finish()
+
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "unexpected FATAL log: FATAL [worker] unexpected crash") {
+		t.Errorf("errors = %v", tb.errors)
+	}
+}
+
+func TestConcurrentLogging(t *testing.T) {
+	logger := xlogtest.New(t, xlogtest.WithoutOutput())
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			named := logger.Named(fmt.Sprint("w", id))
+			for j := 0; j < 100; j++ {
+				named.Info("tick", xlog.Field{Key: "j", Value: j})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if got := len(logger.Records()); got != 800 {
+		t.Errorf("records = %d, want 800", got)
+	}
+}
@@ -0,0 +1,355 @@
+// Package xlogtest 提供用于测试的内存日志器以及日志断言工具
+//
+// Logger 实现了 xlog.HighPerformanceLogger，可以替换业务代码中的日志器：
+//
+//	logger := xlogtest.New(t, xlogtest.FailOnUnexpectedErrors())
+//	svc := NewService(logger)
+//	svc.Do()
+//	logger.RequireLogged(xlog.Warn, "retrying", xlog.Field{Key: "attempt", Value: 2})
+package xlogtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/omeyang/gokit/xlog"
+)
+
+// AnyValue 作为断言中的字段值时，只要求字段存在而不比较值
+var AnyValue any = anyValue{}
+
+type anyValue struct{}
+
+// Record 是捕获到的一条日志记录
+type Record struct {
+	Time    time.Time
+	Level   xlog.LogLevel
+	Message string
+	Logger  string         // 日志器名称
+	TraceID string         // 链路 ID，来自 WithTrace 或 *Context 方法的 context
+	SpanID  string         // Span ID
+	Fields  map[string]any // 预绑定的元数据与调用时传入的字段，后者覆盖前者
+}
+
+// String 返回便于阅读的单行文本
+func (r Record) String() string {
+	var b strings.Builder
+	b.WriteString(string(r.Level))
+	if r.Logger != "" {
+		fmt.Fprintf(&b, " [%s]", r.Logger)
+	}
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, r.Fields[k])
+	}
+	if r.TraceID != "" {
+		fmt.Fprintf(&b, " %s=%s", xlog.TraceIDKey, r.TraceID)
+	}
+	return b.String()
+}
+
+// Match 判断记录的消息是否包含 msgSubstr，且包含所有指定的字段
+// 字段值使用 reflect.DeepEqual 比较，整数按数值比较，值为 AnyValue 时只要求字段存在
+func (r Record) Match(msgSubstr string, fields ...xlog.Field) bool {
+	if !strings.Contains(r.Message, msgSubstr) {
+		return false
+	}
+	for _, f := range fields {
+		v, ok := r.Fields[f.Key]
+		if !ok {
+			return false
+		}
+		if want := f.Any(); want != AnyValue && !valueEqual(v, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// valueEqual 比较字段值，整数之间按数值比较，使 xlog.Int 与 Field{Value: 1} 等价
+func valueEqual(got, want any) bool {
+	if g, ok := toInt64(got); ok {
+		if w, ok := toInt64(want); ok {
+			return g == w
+		}
+	}
+	return reflect.DeepEqual(got, want)
+}
+
+// toInt64 将有符号整数转换为 int64
+func toInt64(v any) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	}
+	return 0, false
+}
+
+// options 定义 Logger 的选项
+type options struct {
+	level         xlog.LogLevel
+	output        bool
+	failOnErrors  bool
+	allowedErrors []string
+	now           func() time.Time
+}
+
+// Option 定义了 Logger 的可选配置函数
+type Option func(*options)
+
+// WithLevel 设置最低日志级别，默认是 DEBUG
+func WithLevel(level xlog.LogLevel) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// WithoutOutput 不再通过 testing.TB.Log 输出捕获到的日志
+// 默认每条日志都会输出，仅在测试失败或使用 -v 时可见
+func WithoutOutput() Option {
+	return func(o *options) {
+		o.output = false
+	}
+}
+
+// FailOnUnexpectedErrors 在测试结束时，如果存在未被断言且未被允许的 ERROR 及以上级别日志，则判定测试失败
+// 被 RequireLogged、AssertLogged 匹配的日志视为预期内的日志
+func FailOnUnexpectedErrors(allowedSubstrs ...string) Option {
+	return func(o *options) {
+		o.failOnErrors = true
+		o.allowedErrors = append(o.allowedErrors, allowedSubstrs...)
+	}
+}
+
+// WithClock 设置记录时间使用的时钟，默认是 time.Now
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// store 保存同一个根日志器派生出的所有日志器共享的状态
+type store struct {
+	mu       sync.Mutex
+	tb       testing.TB
+	opts     options
+	level    xlog.LogLevel
+	records  []Record
+	expected []bool // 与 records 一一对应，记录是否已被断言匹配
+}
+
+// Logger 是把日志保存在内存中的 xlog.HighPerformanceLogger 实现，所有方法都可以并发调用
+// Fatal 级别的日志只会被记录，不会退出进程
+type Logger struct {
+	store   *store
+	name    string
+	fields  []xlog.Field
+	traceID string
+	spanID  string
+}
+
+var _ xlog.HighPerformanceLogger = (*Logger)(nil)
+
+// New 创建一个内存日志器，tb 用于输出日志和报告断言失败
+func New(tb testing.TB, opts ...Option) *Logger {
+	o := options{level: xlog.Debug, output: true, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := &store{tb: tb, opts: o, level: o.level}
+	if o.failOnErrors {
+		tb.Cleanup(s.checkUnexpectedErrors)
+	}
+	return &Logger{store: s}
+}
+
+// SetLevel 设置日志级别，对所有派生的日志器生效
+func (l *Logger) SetLevel(level xlog.LogLevel) error {
+	if !level.IsValid() {
+		return fmt.Errorf("invalid log level: %q", level)
+	}
+	l.store.mu.Lock()
+	l.store.level = level
+	l.store.mu.Unlock()
+	return nil
+}
+
+// GetLevel 获取日志级别
+func (l *Logger) GetLevel() xlog.LogLevel {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	return l.store.level
+}
+
+// Debug 记录调试级别的日志
+func (l *Logger) Debug(msg string, fields ...xlog.Field) {
+	l.log(context.Background(), xlog.Debug, msg, fields)
+}
+
+// Info 记录信息级别的日志
+func (l *Logger) Info(msg string, fields ...xlog.Field) {
+	l.log(context.Background(), xlog.Info, msg, fields)
+}
+
+// Warn 记录告警级别的日志
+func (l *Logger) Warn(msg string, fields ...xlog.Field) {
+	l.log(context.Background(), xlog.Warn, msg, fields)
+}
+
+// Error 记录错误级别的日志
+func (l *Logger) Error(msg string, fields ...xlog.Field) {
+	l.log(context.Background(), xlog.Error, msg, fields)
+}
+
+// Fatal 记录致命级别的日志，不会退出进程
+func (l *Logger) Fatal(msg string, fields ...xlog.Field) {
+	l.log(context.Background(), xlog.Fatal, msg, fields)
+}
+
+// DebugContext 记录带有上下文的调试级别日志
+func (l *Logger) DebugContext(ctx context.Context, msg string, fields ...xlog.Field) {
+	l.log(ctx, xlog.Debug, msg, fields)
+}
+
+// InfoContext 记录带有上下文的信息级别日志
+func (l *Logger) InfoContext(ctx context.Context, msg string, fields ...xlog.Field) {
+	l.log(ctx, xlog.Info, msg, fields)
+}
+
+// WarnContext 记录带有上下文的告警级别日志
+func (l *Logger) WarnContext(ctx context.Context, msg string, fields ...xlog.Field) {
+	l.log(ctx, xlog.Warn, msg, fields)
+}
+
+// ErrorContext 记录带有上下文的错误级别日志
+func (l *Logger) ErrorContext(ctx context.Context, msg string, fields ...xlog.Field) {
+	l.log(ctx, xlog.Error, msg, fields)
+}
+
+// FatalContext 记录带有上下文的致命级别日志，不会退出进程
+func (l *Logger) FatalContext(ctx context.Context, msg string, fields ...xlog.Field) {
+	l.log(ctx, xlog.Fatal, msg, fields)
+}
+
+// Named 返回命名子日志器，名称以 "." 连接到父日志器的名称之后
+func (l *Logger) Named(name string) xlog.HighPerformanceLogger {
+	child := *l
+	switch {
+	case name == "":
+	case l.name == "":
+		child.name = name
+	default:
+		child.name = l.name + "." + name
+	}
+	return &child
+}
+
+// WithTrace 返回绑定了 ctx 中链路信息的日志器
+func (l *Logger) WithTrace(ctx context.Context) xlog.HighPerformanceLogger {
+	child := *l
+	child.traceID, child.spanID = traceInfo(ctx)
+	return &child
+}
+
+// WithMetadata 返回预绑定了元数据字段的日志器
+func (l *Logger) WithMetadata(metadata map[string]any) xlog.HighPerformanceLogger {
+	child := *l
+	child.fields = append([]xlog.Field(nil), l.fields...)
+	for k, v := range metadata {
+		child.fields = append(child.fields, xlog.Field{Key: k, Value: v})
+	}
+	return &child
+}
+
+// Flush 内存日志器无需刷新
+func (l *Logger) Flush() error {
+	return nil
+}
+
+// Close 内存日志器无需关闭
+func (l *Logger) Close() {}
+
+// Records 返回捕获到的所有日志记录
+func (l *Logger) Records() []Record {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	return append([]Record(nil), l.store.records...)
+}
+
+// RecordsAt 返回指定级别的日志记录
+func (l *Logger) RecordsAt(level xlog.LogLevel) []Record {
+	var out []Record
+	for _, r := range l.Records() {
+		if r.Level == level {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Reset 清空捕获到的日志记录
+func (l *Logger) Reset() {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+	l.store.records = nil
+	l.store.expected = nil
+}
+
+// log 记录一条日志
+func (l *Logger) log(ctx context.Context, level xlog.LogLevel, msg string, fields []xlog.Field) {
+	s := l.store
+	s.mu.Lock()
+	if !level.IsValid() || s.level.IsHighThan(level) {
+		s.mu.Unlock()
+		return
+	}
+	r := Record{
+		Time:    s.opts.now(),
+		Level:   level,
+		Message: msg,
+		Logger:  l.name,
+		TraceID: l.traceID,
+		SpanID:  l.spanID,
+		Fields:  make(map[string]any, len(l.fields)+len(fields)),
+	}
+	if r.TraceID == "" {
+		r.TraceID, r.SpanID = traceInfo(ctx)
+	}
+	for _, f := range l.fields {
+		r.Fields[f.Key] = f.Any()
+	}
+	for _, f := range fields {
+		r.Fields[f.Key] = f.Any()
+	}
+	s.records = append(s.records, r)
+	s.expected = append(s.expected, false)
+	s.mu.Unlock()
+
+	if s.opts.output {
+		s.tb.Log(r.String())
+	}
+}
+
+// traceInfo 从 ctx 中提取链路 ID 和 Span ID
+func traceInfo(ctx context.Context) (string, string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+	return sc.TraceID().String(), sc.SpanID().String()
+}